@@ -11,6 +11,7 @@ import (
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
 	"github.com/tohjustin/kube-lineage/internal/version"
+	"github.com/tohjustin/kube-lineage/pkg/cmd/access"
 	"github.com/tohjustin/kube-lineage/pkg/cmd/helm"
 	"github.com/tohjustin/kube-lineage/pkg/cmd/lineage"
 )
@@ -27,6 +28,7 @@ func init() {
 
 func NewCmd(streams genericclioptions.IOStreams) *cobra.Command {
 	cmd := lineage.NewCmd(streams, rootCmdName, "")
+	cmd.AddCommand(access.NewCmd(streams, "", rootCmdName))
 	cmd.AddCommand(helm.NewCmd(streams, "", rootCmdName))
 	cmd.SetVersionTemplate("{{printf \"%s\" .Version}}\n")
 	cmd.Version = fmt.Sprintf("%#v", version.Get())
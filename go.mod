@@ -65,6 +65,7 @@ require (
 	golang.org/x/sync v0.0.0-20220722155255-886fb9371eb4
 	helm.sh/helm/v3 v3.8.0
 	k8s.io/api v0.24.10
+	k8s.io/apiextensions-apiserver v0.24.0
 	k8s.io/apimachinery v0.27.1
 	k8s.io/apiserver v0.24.10
 	k8s.io/cli-runtime v0.24.2
@@ -194,7 +195,6 @@ require (
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
-	k8s.io/apiextensions-apiserver v0.24.0 // indirect
 	k8s.io/component-base v0.24.10 // indirect
 	k8s.io/kube-openapi v0.0.0-20220328201542-3ee0da9b0b42 // indirect
 	k8s.io/utils v0.0.0-20230406110748-d93618cff8a2 // indirect
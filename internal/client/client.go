@@ -2,11 +2,15 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
 	"sync"
 
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,19 +19,17 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
 	_ "k8s.io/client-go/plugin/pkg/client/auth" //nolint:gci
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 )
 
-const (
-	clientQPS   = 300
-	clientBurst = 400
-)
-
 type GetOptions struct {
 	APIResource APIResource
 	Namespace   string
@@ -43,25 +45,91 @@ type ListOptions struct {
 	APIResourcesToExclude []APIResource
 	APIResourcesToInclude []APIResource
 	Namespaces            []string
+	// LabelSelector restricts the list of returned objects by their labels.
+	// Defaults to everything.
+	LabelSelector string
+
+	// FullObjectFn determines whether full objects are required for the
+	// provided API resource. API resources for which it returns false are
+	// listed with the metadata API, so only their ObjectMeta is populated. If
+	// not set, full objects are listed for all API resources.
+	FullObjectFn func(api APIResource) bool
+}
+
+// SkippedResource represents objects of an API resource that couldn't be
+// fetched from the server, resulting in partial results.
+type SkippedResource struct {
+	// Cluster is the name of the cluster (ie. the kubeconfig context) that the
+	// objects were skipped in. Only set for clients created with
+	// Flags.ForContext when fetching objects across multiple clusters.
+	Cluster     string
+	APIResource APIResource
+	// Namespace of the skipped objects. Empty if the objects were skipped at
+	// the cluster scope.
+	Namespace string
+	// Name of the skipped object. Empty if all objects of the API resource
+	// were skipped.
+	Name string
+	// Reason describes why the objects were skipped.
+	Reason string
+}
+
+func (r SkippedResource) String() string {
+	s := r.APIResource.WithGroupString()
+	if len(r.Name) > 0 {
+		s += "/" + r.Name
+	}
+	switch {
+	case r.APIResource.Namespaced && len(r.Namespace) > 0:
+		s = fmt.Sprintf("%s in the namespace \"%s\"", s, r.Namespace)
+	case r.APIResource.Namespaced:
+		s = fmt.Sprintf("%s at the cluster scope", s)
+	}
+	if len(r.Cluster) > 0 {
+		s = fmt.Sprintf("%s of the cluster \"%s\"", s, r.Cluster)
+	}
+	return s
 }
 
 type Interface interface {
 	GetMapper() meta.RESTMapper
 	IsReachable() error
 	ResolveAPIResource(s string) (*APIResource, error)
+	ResolveGroupKind(gk schema.GroupKind) (*APIResource, error)
 
 	Get(ctx context.Context, name string, opts GetOptions) (*unstructuredv1.Unstructured, error)
 	GetAPIResources(ctx context.Context) ([]APIResource, error)
 	GetTable(ctx context.Context, opts GetTableOptions) (*metav1.Table, error)
 	List(ctx context.Context, opts ListOptions) (*unstructuredv1.UnstructuredList, error)
+
+	// SkippedResources returns the resources that were skipped by previous
+	// requests due to insufficient permissions or API resources which are no
+	// longer served.
+	SkippedResources() []SkippedResource
 }
 
 type client struct {
 	configFlags *Flags
+	// cluster is the name of the kubeconfig context that the client was created
+	// for when fetching objects across multiple clusters.
+	cluster string
 
 	discoveryClient discovery.DiscoveryInterface
 	dynamicClient   dynamic.Interface
+	metadataClient  metadata.Interface
 	mapper          meta.RESTMapper
+
+	// backoff is the backoff used for retrying requests failing with a 429 or
+	// 5xx status code.
+	backoff wait.Backoff
+	// chunkSize is the maximum number of objects returned per list request.
+	chunkSize int64
+	// requestSemaphore limits the number of concurrent get & list requests.
+	// Unlimited if nil.
+	requestSemaphore *semaphore.Weighted
+
+	skippedMu        sync.Mutex
+	skippedResources map[string]SkippedResource
 }
 
 func (c *client) GetMapper() meta.RESTMapper {
@@ -130,6 +198,31 @@ func (c *client) ResolveAPIResource(s string) (*APIResource, error) {
 	return res, nil
 }
 
+// ResolveGroupKind resolves the provided GroupKind into the APIResource of its
+// preferred version.
+func (c *client) ResolveGroupKind(gk schema.GroupKind) (*APIResource, error) {
+	mapping, err := c.mapper.RESTMapping(gk)
+	if err != nil {
+		if len(gk.Group) == 0 {
+			err = fmt.Errorf("the server doesn't have a resource type with kind \"%s\"", gk.Kind)
+		} else {
+			err = fmt.Errorf("the server doesn't have a resource type with kind \"%s\" in group \"%s\"", gk.Kind, gk.Group)
+		}
+		return nil, err
+	}
+	// NOTE: This is a rather incomplete APIResource object, but it has enough
+	//       information inside for our use case, which is to fetch API objects
+	res := &APIResource{
+		Name:       mapping.Resource.Resource,
+		Namespaced: mapping.Scope.Name() == meta.RESTScopeNameNamespace,
+		Group:      mapping.GroupVersionKind.Group,
+		Version:    mapping.GroupVersionKind.Version,
+		Kind:       mapping.GroupVersionKind.Kind,
+	}
+
+	return res, nil
+}
+
 // Get returns an object that matches the provided name & options on the server.
 func (c *client) Get(ctx context.Context, name string, opts GetOptions) (*unstructuredv1.Unstructured, error) {
 	klog.V(4).Infof("Get \"%s\" with options: %+v", name, opts)
@@ -140,7 +233,32 @@ func (c *client) Get(ctx context.Context, name string, opts GetOptions) (*unstru
 	} else {
 		ri = c.dynamicClient.Resource(gvr)
 	}
-	return ri.Get(ctx, name, metav1.GetOptions{})
+	release, err := c.acquireRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	var obj *unstructuredv1.Unstructured
+	err = c.retryOnServerError(func() (err error) {
+		obj, err = ri.Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if apierrors.IsForbidden(err) {
+		c.addSkippedResource(opts.APIResource, opts.Namespace, name, err)
+	}
+	return obj, err
+}
+
+// acquireRequest blocks until another request may be made to the server,
+// returning the function to call once the request completes.
+func (c *client) acquireRequest(ctx context.Context) (func(), error) {
+	if c.requestSemaphore == nil {
+		return func() {}, nil
+	}
+	if err := c.requestSemaphore.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	return func() { c.requestSemaphore.Release(1) }, nil
 }
 
 // GetTable returns a table output from the server which contains data of the
@@ -263,10 +381,28 @@ func (c *client) List(ctx context.Context, opts ListOptions) (*unstructuredv1.Un
 	var items []unstructuredv1.Unstructured
 	createListFn := func(ctx context.Context, api APIResource, ns string) func() error {
 		return func() error {
-			objs, err := c.listByAPI(ctx, api, ns)
+			release, err := c.acquireRequest(ctx)
 			if err != nil {
 				return err
 			}
+			defer release()
+			var objs *unstructuredv1.UnstructuredList
+			if opts.FullObjectFn == nil || opts.FullObjectFn(api) {
+				objs, err = c.listByAPI(ctx, api, ns, opts.LabelSelector)
+			} else {
+				objs, err = c.listMetadataByAPI(ctx, api, ns, opts.LabelSelector)
+			}
+			if err != nil {
+				// If the resource is no longer served, suppress the error to allow
+				// other goroutines to continue listing. Permission errors are handled
+				// by the caller, while every other error which persists after
+				// retrying fails the request
+				if noMatchErr := unwrapNoMatchError(err); noMatchErr != nil {
+					c.addSkippedResource(api, ns, "", noMatchErr)
+					return nil
+				}
+				return err
+			}
 			mu.Lock()
 			items = append(items, objs.Items...)
 			mu.Unlock()
@@ -282,12 +418,13 @@ func (c *client) List(ctx context.Context, opts ListOptions) (*unstructuredv1.Un
 		namespaceScopeListFn := func() error {
 			egInner, ctxInner := errgroup.WithContext(ctx)
 			for ns := range nsSet {
-				listFn := createListFn(ctxInner, api, ns)
+				ns, listFn := ns, createListFn(ctxInner, api, ns)
 				egInner.Go(func() error {
-					err = listFn()
+					err := listFn()
 					// If no permissions to list the resource at the namespace scope,
 					// suppress the error to allow other goroutines to continue listing
 					if apierrors.IsForbidden(err) {
+						c.addSkippedResource(api, ns, "", err)
 						err = nil
 					}
 					return err
@@ -302,14 +439,21 @@ func (c *client) List(ctx context.Context, opts ListOptions) (*unstructuredv1.Un
 				// If no permissions to list the cluster-scoped resource,
 				// suppress the error to allow other goroutines to continue listing
 				if !api.Namespaced && apierrors.IsForbidden(err) {
+					c.addSkippedResource(api, "", "", err)
 					err = nil
 				}
 				// If no permissions to list the namespaced resource at the cluster
 				// scope, don't return the error yet & reattempt to list the resource
-				// in other namespace(s)
+				// in other namespace(s). The resource is only recorded as skipped
+				// at the cluster scope if there are no namespaces to fall back to,
+				// otherwise the namespaces that can't be listed either are recorded
 				if !api.Namespaced || !apierrors.IsForbidden(err) {
 					return err
 				}
+				if len(nsSet) == 0 {
+					c.addSkippedResource(api, "", "", err)
+					return nil
+				}
 			}
 			return namespaceScopeListFn()
 		})
@@ -374,9 +518,10 @@ func (c *client) GetAPIResources(_ context.Context) ([]APIResource, error) {
 	return apis, nil
 }
 
-// listByAPI list all objects of the provided API & namespace. If listing the
-// API at the cluster scope, set the namespace argument as an empty string.
-func (c *client) listByAPI(ctx context.Context, api APIResource, ns string) (*unstructuredv1.UnstructuredList, error) {
+// listByAPI list all objects of the provided API & namespace that matches the
+// provided label selector. If listing the API at the cluster scope, set the
+// namespace argument as an empty string.
+func (c *client) listByAPI(ctx context.Context, api APIResource, ns, labelSelector string) (*unstructuredv1.UnstructuredList, error) {
 	var ri dynamic.ResourceInterface
 	var items []unstructuredv1.Unstructured
 	var next string
@@ -388,13 +533,20 @@ func (c *client) listByAPI(ctx context.Context, api APIResource, ns string) (*un
 		ri = c.dynamicClient.Resource(api.GroupVersionResource()).Namespace(ns)
 	}
 	for {
-		objectList, err := ri.List(ctx, metav1.ListOptions{
-			Limit:    250,
-			Continue: next,
+		var objectList *unstructuredv1.UnstructuredList
+		err := c.retryOnServerError(func() (err error) {
+			objectList, err = ri.List(ctx, metav1.ListOptions{
+				LabelSelector: labelSelector,
+				Limit:         c.chunkSize,
+				Continue:      next,
+			})
+			return err
 		})
 		if err != nil {
 			switch {
 			case apierrors.IsForbidden(err):
+				// Recorded as skipped by the caller, which may reattempt to list
+				// the resource in other namespace(s)
 				if isClusterScopeRequest {
 					klog.V(4).Infof("No access to list at cluster scope for resource: %s", api)
 				} else {
@@ -429,3 +581,163 @@ func (c *client) listByAPI(ctx context.Context, api APIResource, ns string) (*un
 	}
 	return &unstructuredv1.UnstructuredList{Items: items}, nil
 }
+
+// listMetadataByAPI list the metadata of all objects of the provided API &
+// namespace that matches the provided label selector. If listing the API at
+// the cluster scope, set the namespace argument as an empty string.
+//
+// The returned objects only contain the TypeMeta & ObjectMeta of the listed
+// objects, which is sufficient for resolving relationships based on owner
+// references, labels & names.
+func (c *client) listMetadataByAPI(ctx context.Context, api APIResource, ns, labelSelector string) (*unstructuredv1.UnstructuredList, error) {
+	var ri metadata.ResourceInterface
+	var items []unstructuredv1.Unstructured
+	var next string
+
+	isClusterScopeRequest := !api.Namespaced || ns == ""
+	if isClusterScopeRequest {
+		ri = c.metadataClient.Resource(api.GroupVersionResource())
+	} else {
+		ri = c.metadataClient.Resource(api.GroupVersionResource()).Namespace(ns)
+	}
+	for {
+		var objectList *metav1.PartialObjectMetadataList
+		err := c.retryOnServerError(func() (err error) {
+			objectList, err = ri.List(ctx, metav1.ListOptions{
+				LabelSelector: labelSelector,
+				Limit:         c.chunkSize,
+				Continue:      next,
+			})
+			return err
+		})
+		if err != nil {
+			switch {
+			case apierrors.IsForbidden(err):
+				// Recorded as skipped by the caller, which may reattempt to list
+				// the resource in other namespace(s)
+				if isClusterScopeRequest {
+					klog.V(4).Infof("No access to list at cluster scope for resource: %s", api)
+				} else {
+					klog.V(4).Infof("No access to list in the namespace \"%s\" for resource: %s", ns, api)
+				}
+				return nil, err
+			case apierrors.IsNotFound(err):
+				break
+			default:
+				if isClusterScopeRequest {
+					err = fmt.Errorf("failed to list metadata of resource type \"%s\" in API group \"%s\" at the cluster scope: %w", api.Name, api.Group, err)
+				} else {
+					err = fmt.Errorf("failed to list metadata of resource type \"%s\" in API group \"%s\" in the namespace \"%s\": %w", api.Name, api.Group, ns, err)
+				}
+				return nil, err
+			}
+		}
+		if objectList == nil {
+			break
+		}
+		for ix := range objectList.Items {
+			u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&objectList.Items[ix])
+			if err != nil {
+				return nil, err
+			}
+			// The metadata API returns objects as PartialObjectMetadata, so we
+			// restore the object's actual GVK for them to be mapped to their
+			// resource type
+			obj := unstructuredv1.Unstructured{Object: u}
+			obj.SetGroupVersionKind(api.GroupVersionKind())
+			items = append(items, obj)
+		}
+		next = objectList.GetContinue()
+		if len(next) == 0 {
+			break
+		}
+	}
+
+	if isClusterScopeRequest {
+		klog.V(4).Infof("Got %4d objects (metadata only) from resource at the cluster scope: %s", len(items), api)
+	} else {
+		klog.V(4).Infof("Got %4d objects (metadata only) from resource in the namespace \"%s\": %s", len(items), ns, api)
+	}
+	return &unstructuredv1.UnstructuredList{Items: items}, nil
+}
+
+// SkippedResources returns the resources that were skipped by previous
+// requests due to insufficient permissions or API resources which are no
+// longer served, sorted by their
+// resource, namespace & name.
+func (c *client) SkippedResources() []SkippedResource {
+	c.skippedMu.Lock()
+	defer c.skippedMu.Unlock()
+
+	keys := make([]string, 0, len(c.skippedResources))
+	for k := range c.skippedResources {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]SkippedResource, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, c.skippedResources[k])
+	}
+	return result
+}
+
+// addSkippedResource records the objects of the provided API resource,
+// namespace & name as skipped due to the provided error.
+func (c *client) addSkippedResource(api APIResource, ns, name string, err error) {
+	if !api.Namespaced {
+		ns = ""
+	}
+	reason := err.Error()
+	if r := apierrors.ReasonForError(err); r != metav1.StatusReasonUnknown {
+		reason = string(r)
+	}
+
+	c.skippedMu.Lock()
+	defer c.skippedMu.Unlock()
+	if c.skippedResources == nil {
+		c.skippedResources = map[string]SkippedResource{}
+	}
+	key := fmt.Sprintf("%s\\%s\\%s", api.WithGroupString(), ns, name)
+	c.skippedResources[key] = SkippedResource{
+		Cluster:     c.cluster,
+		APIResource: api,
+		Namespace:   ns,
+		Name:        name,
+		Reason:      reason,
+	}
+}
+
+// unwrapNoMatchError returns the error caused by an API resource which is no
+// longer served if the provided error wraps one, otherwise returns nil.
+func unwrapNoMatchError(err error) error {
+	var noKindMatchErr *meta.NoKindMatchError
+	if errors.As(err, &noKindMatchErr) {
+		return noKindMatchErr
+	}
+	var noResourceMatchErr *meta.NoResourceMatchError
+	if errors.As(err, &noResourceMatchErr) {
+		return noResourceMatchErr
+	}
+	return nil
+}
+
+// retryOnServerError calls the provided function & retries it with exponential
+// backoff for as long as it fails with a 429 or 5xx status code.
+func (c *client) retryOnServerError(fn func() error) error {
+	return retry.OnError(c.backoff, isRetriableError, fn)
+}
+
+// isRetriableError returns true if the provided error is caused by the server
+// either throttling the request or failing with a 5xx status code.
+func isRetriableError(err error) bool {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return false
+	}
+	code := status.Status().Code
+	retriable := code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	if retriable {
+		klog.V(4).Infof("Retrying request which failed with status code %d: %s", code, err)
+	}
+	return retriable
+}
@@ -0,0 +1,439 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// testDiscovery is a fake discovery client serving the API resources of the
+// underlying fake as the server's preferred resources.
+type testDiscovery struct {
+	*fakediscovery.FakeDiscovery
+}
+
+func (d *testDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return d.Resources, nil
+}
+
+// testAPIResources contains the API resources served by the test client.
+var testAPIResources = []APIResource{
+	{Version: "v1", Kind: "ConfigMap", Name: "configmaps", Namespaced: true},
+	{Version: "v1", Kind: "Pod", Name: "pods", Namespaced: true},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole", Name: "clusterroles"},
+}
+
+// newTestClient returns a client serving the test API resources, with the
+// provided objects served by both its dynamic & metadata clients.
+func newTestClient(objects ...unstructuredv1.Unstructured) (*client, *dynamicfake.FakeDynamicClient, *metadatafake.FakeMetadataClient) {
+	resources := map[string]*metav1.APIResourceList{}
+	gvrToListKind := map[schema.GroupVersionResource]string{}
+	for _, api := range testAPIResources {
+		gv := schema.GroupVersion{Group: api.Group, Version: api.Version}.String()
+		if _, ok := resources[gv]; !ok {
+			resources[gv] = &metav1.APIResourceList{GroupVersion: gv}
+		}
+		resources[gv].APIResources = append(resources[gv].APIResources, metav1.APIResource{
+			Name:       api.Name,
+			Kind:       api.Kind,
+			Namespaced: api.Namespaced,
+			Verbs:      metav1.Verbs{"get", "list", "watch"},
+		})
+		gvrToListKind[api.GroupVersionResource()] = api.Kind + "List"
+	}
+	discovery := &testDiscovery{FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}}
+	for _, rl := range resources {
+		discovery.Resources = append(discovery.Resources, rl)
+	}
+
+	var objs, metadataObjs []runtime.Object
+	for ix := range objects {
+		obj := objects[ix].DeepCopy()
+		objs = append(objs, obj)
+		metadataObjs = append(metadataObjs, &metav1.PartialObjectMetadata{
+			TypeMeta: metav1.TypeMeta{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind()},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+				UID:       obj.GetUID(),
+				Labels:    obj.GetLabels(),
+			},
+		})
+	}
+	scheme := metadatafake.NewTestScheme()
+	if err := metav1.AddMetaToScheme(scheme); err != nil {
+		panic(err)
+	}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrToListKind, objs...)
+	md := metadatafake.NewSimpleMetadataClient(scheme, metadataObjs...)
+
+	return &client{
+		discoveryClient: discovery,
+		dynamicClient:   dyn,
+		metadataClient:  md,
+		backoff:         newBackoff(0),
+	}, dyn, md
+}
+
+// concurrentListCounter counts the list requests in flight, recording the
+// highest number of concurrent requests.
+type concurrentListCounter struct {
+	active int32
+	max    int32
+}
+
+func (c *concurrentListCounter) track() func() {
+	active := atomic.AddInt32(&c.active, 1)
+	for {
+		max := atomic.LoadInt32(&c.max)
+		if active <= max || atomic.CompareAndSwapInt32(&c.max, max, active) {
+			break
+		}
+	}
+	// Hold on to the request to allow other requests to run concurrently
+	time.Sleep(10 * time.Millisecond)
+	return func() { atomic.AddInt32(&c.active, -1) }
+}
+
+// countingDynamicClient is a dynamic client counting its concurrent list
+// requests, as the fake dynamic client serializes all of its requests.
+type countingDynamicClient struct {
+	dynamic.Interface
+	counter *concurrentListCounter
+}
+
+func (d *countingDynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &countingNamespaceableResource{NamespaceableResourceInterface: d.Interface.Resource(gvr), counter: d.counter}
+}
+
+type countingNamespaceableResource struct {
+	dynamic.NamespaceableResourceInterface
+	counter *concurrentListCounter
+}
+
+func (r *countingNamespaceableResource) Namespace(ns string) dynamic.ResourceInterface {
+	return &countingResource{ResourceInterface: r.NamespaceableResourceInterface.Namespace(ns), counter: r.counter}
+}
+
+func (r *countingNamespaceableResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructuredv1.UnstructuredList, error) {
+	defer r.counter.track()()
+	return r.NamespaceableResourceInterface.List(ctx, opts)
+}
+
+type countingResource struct {
+	dynamic.ResourceInterface
+	counter *concurrentListCounter
+}
+
+func (r *countingResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructuredv1.UnstructuredList, error) {
+	defer r.counter.track()()
+	return r.ResourceInterface.List(ctx, opts)
+}
+
+func newTestObject(apiVersion, kind, ns, name string, fields map[string]interface{}) unstructuredv1.Unstructured {
+	metadata := map[string]interface{}{"name": name, "uid": kind + "/" + ns + "/" + name}
+	if len(ns) > 0 {
+		metadata["namespace"] = ns
+	}
+	obj := map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "metadata": metadata}
+	for k, v := range fields {
+		obj[k] = v
+	}
+	return unstructuredv1.Unstructured{Object: obj}
+}
+
+func TestListMetadataOnly(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("v1", "ConfigMap", "default", "config", map[string]interface{}{
+			"data": map[string]interface{}{"key": "value"},
+		}),
+		newTestObject("v1", "Pod", "default", "pod", map[string]interface{}{
+			"spec": map[string]interface{}{"nodeName": "node"},
+		}),
+	}
+	tests := []struct {
+		name         string
+		fullObjectFn func(api APIResource) bool
+		expected     map[string]bool
+	}{
+		{
+			name:         "FullObjects",
+			fullObjectFn: nil,
+			expected:     map[string]bool{"ConfigMap": true, "Pod": true},
+		},
+		{
+			name:         "MetadataOnly",
+			fullObjectFn: func(api APIResource) bool { return api.Kind == "Pod" },
+			expected:     map[string]bool{"ConfigMap": false, "Pod": true},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, _, _ := newTestClient(objects...)
+			objs, err := c.List(context.Background(), ListOptions{
+				Namespaces:   []string{"default"},
+				FullObjectFn: tt.fullObjectFn,
+			})
+			if err != nil {
+				t.Fatalf("failed to list objects: %v", err)
+			}
+			if len(objs.Items) != len(tt.expected) {
+				t.Fatalf("expected %d objects got %d: %v", len(tt.expected), len(objs.Items), objs.Items)
+			}
+			for _, obj := range objs.Items {
+				full, ok := tt.expected[obj.GetKind()]
+				if !ok {
+					t.Fatalf("unexpected object of kind \"%s\": %v", obj.GetKind(), obj)
+				}
+				if obj.GetName() == "" || obj.GetUID() == "" {
+					t.Fatalf("expected object of kind \"%s\" to have its ObjectMeta, got %v", obj.GetKind(), obj)
+				}
+				_, hasData := obj.Object["data"]
+				_, hasSpec := obj.Object["spec"]
+				if got := hasData || hasSpec; got != full {
+					t.Fatalf("expected full object of kind \"%s\" to be %t, got %v", obj.GetKind(), full, obj)
+				}
+			}
+		})
+	}
+}
+
+func TestListRetriesServerErrors(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("v1", "ConfigMap", "default", "config", nil),
+	}
+	tests := []struct {
+		name       string
+		err        error
+		failures   int
+		maxRetries int
+		expected   int
+		expectErr  bool
+	}{
+		{
+			name:       "TooManyRequests",
+			err:        apierrors.NewTooManyRequests("throttled", 0),
+			failures:   2,
+			maxRetries: 2,
+			expected:   3,
+		},
+		{
+			name:       "ServiceUnavailable",
+			err:        apierrors.NewServiceUnavailable("unavailable"),
+			failures:   1,
+			maxRetries: 2,
+			expected:   2,
+		},
+		{
+			name:       "InternalErrorExceedingRetries",
+			err:        apierrors.NewInternalError(fmt.Errorf("internal")),
+			failures:   3,
+			maxRetries: 2,
+			expected:   3,
+			expectErr:  true,
+		},
+		{
+			name:       "BadRequestNotRetried",
+			err:        apierrors.NewGenericServerResponse(http.StatusBadRequest, "list", schema.GroupResource{Resource: "configmaps"}, "", "bad request", 0, false),
+			failures:   1,
+			maxRetries: 2,
+			expected:   1,
+			expectErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, dyn, _ := newTestClient(objects...)
+			c.backoff = wait.Backoff{Steps: tt.maxRetries + 1, Duration: time.Millisecond}
+			var attempts int32
+			dyn.PrependReactor("list", "configmaps", func(_ clienttesting.Action) (bool, runtime.Object, error) {
+				if int(atomic.AddInt32(&attempts, 1)) <= tt.failures {
+					return true, nil, tt.err
+				}
+				return false, nil, nil
+			})
+
+			objs, err := c.List(context.Background(), ListOptions{Namespaces: []string{"default"}})
+			if got := int(atomic.LoadInt32(&attempts)); got != tt.expected {
+				t.Fatalf("expected %d attempts got %d", tt.expected, got)
+			}
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error got %v", objs.Items)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to list objects: %v", err)
+			}
+			if len(objs.Items) != 1 {
+				t.Fatalf("expected 1 object got %d: %v", len(objs.Items), objs.Items)
+			}
+		})
+	}
+}
+
+func TestListLimitsConcurrentRequests(t *testing.T) {
+	t.Parallel()
+
+	namespaces := make([]string, 8)
+	for ix := range namespaces {
+		namespaces[ix] = fmt.Sprintf("ns-%d", ix)
+	}
+	tests := []struct {
+		name  string
+		limit int64
+	}{
+		{name: "Serial", limit: 1},
+		{name: "Limited", limit: 3},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, _, _ := newTestClient()
+			counter := &concurrentListCounter{}
+			c.dynamicClient = &countingDynamicClient{Interface: c.dynamicClient, counter: counter}
+			c.requestSemaphore = semaphore.NewWeighted(tt.limit)
+
+			if _, err := c.List(context.Background(), ListOptions{Namespaces: namespaces}); err != nil {
+				t.Fatalf("failed to list objects: %v", err)
+			}
+			if got := atomic.LoadInt32(&counter.max); got < 1 || int64(got) > tt.limit {
+				t.Fatalf("expected at most %d concurrent list requests got %d", tt.limit, got)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestListSkippedResources(t *testing.T) {
+	t.Parallel()
+
+	forbidden := func(resource, ns string) clienttesting.ReactionFunc {
+		return func(action clienttesting.Action) (bool, runtime.Object, error) {
+			if action.GetNamespace() != ns {
+				return false, nil, nil
+			}
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: resource}, "", fmt.Errorf("forbidden"))
+		}
+	}
+	noMatch := func(_ clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, &meta.NoKindMatchError{GroupKind: schema.GroupKind{Kind: "ConfigMap"}}
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("v1", "Pod", "default", "pod", nil),
+		newTestObject("v1", "Pod", "other", "pod", nil),
+		newTestObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "role", nil),
+	}
+	tests := []struct {
+		name       string
+		resource   string
+		include    []APIResource
+		namespaces []string
+		reactor    clienttesting.ReactionFunc
+		expected   []SkippedResource
+		remaining  int
+	}{
+		{
+			name:       "ForbiddenInNamespace",
+			resource:   "pods",
+			include:    []APIResource{testAPIResources[1]},
+			namespaces: []string{"default", "other"},
+			reactor:    forbidden("pods", "other"),
+			expected: []SkippedResource{
+				{Cluster: "ctx", APIResource: testAPIResources[1], Namespace: "other", Reason: string(metav1.StatusReasonForbidden)},
+			},
+			remaining: 1,
+		},
+		{
+			name:       "ForbiddenAtClusterScope",
+			resource:   "clusterroles",
+			include:    []APIResource{testAPIResources[1], testAPIResources[2]},
+			namespaces: []string{"", "default", "other"},
+			reactor:    forbidden("clusterroles", ""),
+			expected: []SkippedResource{
+				{Cluster: "ctx", APIResource: testAPIResources[2], Reason: string(metav1.StatusReasonForbidden)},
+			},
+			remaining: 2,
+		},
+		{
+			name:       "NoLongerServed",
+			resource:   "configmaps",
+			include:    []APIResource{testAPIResources[0], testAPIResources[1]},
+			namespaces: []string{"default", "other"},
+			reactor:    noMatch,
+			expected: []SkippedResource{
+				{Cluster: "ctx", APIResource: testAPIResources[0], Namespace: "default", Reason: (&meta.NoKindMatchError{GroupKind: schema.GroupKind{Kind: "ConfigMap"}}).Error()},
+				{Cluster: "ctx", APIResource: testAPIResources[0], Namespace: "other", Reason: (&meta.NoKindMatchError{GroupKind: schema.GroupKind{Kind: "ConfigMap"}}).Error()},
+			},
+			remaining: 2,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, dyn, _ := newTestClient(objects...)
+			c.cluster = "ctx"
+			dyn.PrependReactor("list", tt.resource, tt.reactor)
+
+			objs, err := c.List(context.Background(), ListOptions{
+				APIResourcesToInclude: tt.include,
+				Namespaces:            tt.namespaces,
+			})
+			if err != nil {
+				t.Fatalf("failed to list objects: %v", err)
+			}
+			if len(objs.Items) != tt.remaining {
+				t.Fatalf("expected %d objects got %d: %v", tt.remaining, len(objs.Items), objs.Items)
+			}
+			if got := c.SkippedResources(); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected skipped resources %#v got %#v", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("ForbiddenGet", func(t *testing.T) {
+		t.Parallel()
+		c, dyn, _ := newTestClient(objects...)
+		dyn.PrependReactor("get", "pods", forbidden("pods", "other"))
+
+		_, err := c.Get(context.Background(), "pod", GetOptions{APIResource: testAPIResources[1], Namespace: "other"})
+		if !apierrors.IsForbidden(err) {
+			t.Fatalf("expected forbidden error got %v", err)
+		}
+		expected := []SkippedResource{
+			{APIResource: testAPIResources[1], Namespace: "other", Name: "pod", Reason: string(metav1.StatusReasonForbidden)},
+		}
+		if got := c.SkippedResources(); !reflect.DeepEqual(got, expected) {
+			t.Fatalf("expected skipped resources %#v got %#v", expected, got)
+		}
+	})
+}
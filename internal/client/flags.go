@@ -1,18 +1,48 @@
 package client
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"golang.org/x/sync/semaphore"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/completion"
 )
 
+const (
+	flagBurst                 = "burst"
+	flagChunkSize             = "chunk-size"
+	flagMaxConcurrentRequests = "max-concurrent-requests"
+	flagMaxRetries            = "max-retries"
+	flagQPS                   = "qps"
+)
+
+const (
+	defaultBurst                 = 400
+	defaultChunkSize             = 250
+	defaultMaxConcurrentRequests = 64
+	defaultMaxRetries            = 5
+	defaultQPS                   = 300
+)
+
 // Flags composes common client configuration flag structs used in the command.
 type Flags struct {
 	*genericclioptions.ConfigFlags
+
+	Burst                 *int
+	ChunkSize             *int64
+	MaxConcurrentRequests *int
+	MaxRetries            *int
+	QPS                   *float32
+
+	// context is the name of the kubeconfig context set by ForContext.
+	context string
 }
 
 // Copy returns a copy of Flags for mutation.
@@ -21,10 +51,58 @@ func (f *Flags) Copy() Flags {
 	return Flags
 }
 
+// ForContext returns a copy of Flags for creating a client for the provided
+// kubeconfig context. Every other flag (eg. "--certificate-authority",
+// "--as") is carried over unchanged.
+func (f *Flags) ForContext(name string) *Flags {
+	configFlags := genericclioptions.NewConfigFlags(true)
+	configFlags.CacheDir = f.CacheDir
+	configFlags.KubeConfig = f.KubeConfig
+	configFlags.ClusterName = f.ClusterName
+	configFlags.AuthInfoName = f.AuthInfoName
+	configFlags.Context = &name
+	configFlags.Namespace = f.Namespace
+	configFlags.APIServer = f.APIServer
+	configFlags.TLSServerName = f.TLSServerName
+	configFlags.Insecure = f.Insecure
+	configFlags.CertFile = f.CertFile
+	configFlags.KeyFile = f.KeyFile
+	configFlags.CAFile = f.CAFile
+	configFlags.BearerToken = f.BearerToken
+	configFlags.Impersonate = f.Impersonate
+	configFlags.ImpersonateUID = f.ImpersonateUID
+	configFlags.ImpersonateGroup = f.ImpersonateGroup
+	configFlags.Username = f.Username
+	configFlags.Password = f.Password
+	configFlags.Timeout = f.Timeout
+	configFlags.WrapConfigFn = f.WrapConfigFn
+
+	flags := f.Copy()
+	flags.ConfigFlags = configFlags
+	flags.context = name
+	return &flags
+}
+
 // AddFlags receives a pflag.FlagSet reference and binds flags related to client
 // configuration to it.
 func (f *Flags) AddFlags(flags *pflag.FlagSet) {
 	f.ConfigFlags.AddFlags(flags)
+
+	if f.Burst != nil {
+		flags.IntVar(f.Burst, flagBurst, *f.Burst, "Maximum burst for throttling requests to the server")
+	}
+	if f.ChunkSize != nil {
+		flags.Int64Var(f.ChunkSize, flagChunkSize, *f.ChunkSize, "Return large lists in chunks rather than all at once. Pass 0 to disable")
+	}
+	if f.MaxConcurrentRequests != nil {
+		flags.IntVar(f.MaxConcurrentRequests, flagMaxConcurrentRequests, *f.MaxConcurrentRequests, "Maximum number of concurrent get & list requests to the server. Pass 0 to disable")
+	}
+	if f.MaxRetries != nil {
+		flags.IntVar(f.MaxRetries, flagMaxRetries, *f.MaxRetries, "Maximum number of retries with exponential backoff for requests failing with a 429 or 5xx status code. Pass 0 to disable")
+	}
+	if f.QPS != nil {
+		flags.Float32Var(f.QPS, flagQPS, *f.QPS, "Maximum queries per second for throttling requests to the server")
+	}
 }
 
 // RegisterFlagCompletionFunc receives a *cobra.Command & register functions to
@@ -62,14 +140,23 @@ func (f *Flags) ToClient() (Interface, error) {
 		return nil, err
 	}
 	config.WarningHandler = rest.NoWarnings{}
-	config.QPS = clientQPS
-	config.Burst = clientBurst
-	f.WithDiscoveryBurst(clientBurst)
+	config.QPS, config.Burst = defaultQPS, defaultBurst
+	if f.QPS != nil {
+		config.QPS = *f.QPS
+	}
+	if f.Burst != nil {
+		config.Burst = *f.Burst
+	}
+	f.WithDiscoveryBurst(config.Burst)
 
 	dyn, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
+	md, err := metadata.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
 	dis, err := f.ToDiscoveryClient()
 	if err != nil {
 		return nil, err
@@ -80,9 +167,26 @@ func (f *Flags) ToClient() (Interface, error) {
 	}
 	c := &client{
 		configFlags:     f,
+		cluster:         f.context,
 		discoveryClient: dis,
 		dynamicClient:   dyn,
+		metadataClient:  md,
 		mapper:          mapper,
+		chunkSize:       defaultChunkSize,
+		backoff:         newBackoff(defaultMaxRetries),
+	}
+	if f.ChunkSize != nil {
+		c.chunkSize = *f.ChunkSize
+	}
+	if f.MaxRetries != nil {
+		c.backoff = newBackoff(*f.MaxRetries)
+	}
+	maxConcurrentRequests := defaultMaxConcurrentRequests
+	if f.MaxConcurrentRequests != nil {
+		maxConcurrentRequests = *f.MaxConcurrentRequests
+	}
+	if maxConcurrentRequests > 0 {
+		c.requestSemaphore = semaphore.NewWeighted(int64(maxConcurrentRequests))
 	}
 
 	return c, nil
@@ -91,7 +195,33 @@ func (f *Flags) ToClient() (Interface, error) {
 // NewFlags returns flags associated with client configuration, with default
 // values set.
 func NewFlags() *Flags {
+	burst := defaultBurst
+	chunkSize := int64(defaultChunkSize)
+	maxConcurrentRequests := defaultMaxConcurrentRequests
+	maxRetries := defaultMaxRetries
+	qps := float32(defaultQPS)
+
 	return &Flags{
-		ConfigFlags: genericclioptions.NewConfigFlags(true),
+		ConfigFlags:           genericclioptions.NewConfigFlags(true),
+		Burst:                 &burst,
+		ChunkSize:             &chunkSize,
+		MaxConcurrentRequests: &maxConcurrentRequests,
+		MaxRetries:            &maxRetries,
+		QPS:                   &qps,
+	}
+}
+
+// newBackoff returns the backoff used for retrying requests up to the provided
+// number of times.
+func newBackoff(maxRetries int) wait.Backoff {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	return wait.Backoff{
+		Steps:    maxRetries + 1,
+		Duration: 500 * time.Millisecond,
+		Factor:   2.0,
+		Jitter:   0.1,
+		Cap:      10 * time.Second,
 	}
 }
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestFlagsForContext(t *testing.T) {
+	t.Parallel()
+
+	f := NewFlags()
+	current, kubeconfig, namespace, chunkSize := "current", "/tmp/kubeconfig", "default", int64(100)
+	f.Context = &current
+	f.KubeConfig = &kubeconfig
+	f.Namespace = &namespace
+	f.ChunkSize = &chunkSize
+
+	tests := []struct {
+		name    string
+		context string
+	}{
+		{name: "Management", context: "mgmt"},
+		{name: "Workload", context: "workload-admin@workload"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			flags := f.ForContext(tt.context)
+			if got := *flags.Context; got != tt.context {
+				t.Fatalf("expected context \"%s\" got \"%s\"", tt.context, got)
+			}
+			if flags.context != tt.context {
+				t.Fatalf("expected client cluster \"%s\" got \"%s\"", tt.context, flags.context)
+			}
+			if *flags.KubeConfig != kubeconfig || *flags.Namespace != namespace || *flags.ChunkSize != chunkSize {
+				t.Fatalf("expected flags to be carried over, got kubeconfig \"%s\", namespace \"%s\" & chunk size %d", *flags.KubeConfig, *flags.Namespace, *flags.ChunkSize)
+			}
+			if *f.Context != current || f.context != "" {
+				t.Fatalf("expected original flags to be unchanged, got context \"%s\"", *f.Context)
+			}
+		})
+	}
+}
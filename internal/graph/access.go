@@ -0,0 +1,246 @@
+package graph
+
+import (
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+)
+
+// AccessRule is an effective RBAC policy rule granted to a subject by a
+// ClusterRoleBinding or RoleBinding.
+type AccessRule struct {
+	rbacv1.PolicyRule
+	Binding *Node
+	Role    *Node
+	// Namespace is the namespace which the rule applies to, empty if the rule
+	// applies cluster-wide.
+	Namespace string
+}
+
+// GetSubjectBindings returns the relationships to the ClusterRoleBindings &
+// RoleBindings in the provided objects that bind roles to the User or Group
+// with the provided name. Users are assumed to be members of the
+// "system:authenticated" group.
+func GetSubjectBindings(objects []unstructuredv1.Unstructured, kind, name string) map[types.UID]Relationship {
+	matches := func(s rbacv1.Subject) bool {
+		if s.APIGroup != rbacv1.GroupName {
+			return false
+		}
+		switch {
+		case s.Kind == kind && s.Name == name:
+			return true
+		case kind == rbacv1.UserKind && s.Kind == rbacv1.GroupKind && s.Name == user.AllAuthenticated:
+			return true
+		}
+		return false
+	}
+
+	result := map[types.UID]Relationship{}
+	for _, o := range objects {
+		if o.GroupVersionKind().Group != rbacv1.GroupName {
+			continue
+		}
+		var subjects []rbacv1.Subject
+		var r Relationship
+		switch o.GetKind() {
+		case "ClusterRoleBinding":
+			var crb rbacv1.ClusterRoleBinding
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &crb); err != nil {
+				klog.V(4).Infof("Failed to convert ClusterRoleBinding named \"%s\": %s", o.GetName(), err)
+				continue
+			}
+			subjects, r = crb.Subjects, RelationshipClusterRoleBindingSubject
+		case "RoleBinding":
+			var rb rbacv1.RoleBinding
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &rb); err != nil {
+				klog.V(4).Infof("Failed to convert RoleBinding named \"%s\" in namespace \"%s\": %s", o.GetName(), o.GetNamespace(), err)
+				continue
+			}
+			subjects, r = rb.Subjects, RelationshipRoleBindingSubject
+		default:
+			continue
+		}
+		for _, s := range subjects {
+			if matches(s) {
+				result[o.GetUID()] = r
+				break
+			}
+		}
+	}
+	return result
+}
+
+// ResolveAccess returns the effective RBAC policy rules of the subject with the
+// provided UID, along with a NodeMap containing only the subject & the
+// bindings, roles (including aggregated ClusterRoles) & objects covered by the
+// rules which are reachable from it. Objects covered by roles bound with
+// RoleBindings are limited to the namespaces of the RoleBindings.
+//
+//nolint:funlen,gocognit
+func ResolveAccess(nodeMap NodeMap, uid types.UID) (NodeMap, []AccessRule, error) {
+	root, ok := nodeMap[uid]
+	if !ok {
+		return nil, nil, fmt.Errorf("subject with UID \"%s\" not found", uid)
+	}
+
+	result := NodeMap{}
+	addNode := func(n *Node, depth uint) *Node {
+		if node, ok := result[n.UID]; ok {
+			if depth < node.Depth {
+				node.Depth = depth
+			}
+			return node
+		}
+		node := *n
+		node.Depth = depth
+		node.Dependencies = map[types.UID]RelationshipSet{}
+		node.Dependents = map[types.UID]RelationshipSet{}
+		result[node.UID] = &node
+		return &node
+	}
+	addRelationship := func(from, to *Node, r Relationship) {
+		from.AddDependency(to.UID, r)
+		to.AddDependent(from.UID, r)
+	}
+
+	var rules []AccessRule
+	ruleKeys := sets.NewString()
+	roleScopes := map[types.UID]sets.String{}
+	var walkRole func(parent, binding, role *Node, r Relationship, ns string, depth uint, visited sets.String)
+	walkRole = func(parent, binding, role *Node, r Relationship, ns string, depth uint, visited sets.String) {
+		if visited.Has(string(role.UID)) {
+			return
+		}
+		visited.Insert(string(role.UID))
+		node := addNode(role, depth)
+		addRelationship(parent, node, r)
+		if _, ok := roleScopes[role.UID]; !ok {
+			roleScopes[role.UID] = sets.NewString()
+		}
+		roleScopes[role.UID].Insert(ns)
+
+		// Rules of the role, ignoring rules already granted by the binding (ie.
+		// rules of aggregated ClusterRoles which are also found in the
+		// aggregating ClusterRole)
+		policyRules, err := getPolicyRules(role)
+		if err != nil {
+			klog.V(4).Infof("Failed to get policy rules for %s named \"%s\": %s", role.Kind, role.Name, err)
+		}
+		for _, pr := range policyRules {
+			k := fmt.Sprintf("%s\\%s\\%s", binding.UID, ns, pr.String())
+			if ruleKeys.Has(k) {
+				continue
+			}
+			ruleKeys.Insert(k)
+			rules = append(rules, AccessRule{PolicyRule: pr, Binding: binding, Role: role, Namespace: ns})
+		}
+
+		// Aggregated ClusterRoles
+		for depUID, rset := range role.Dependencies {
+			if _, ok := rset[RelationshipClusterRoleAggregationRule]; !ok {
+				continue
+			}
+			if dep, ok := nodeMap[depUID]; ok {
+				walkRole(node, binding, dep, RelationshipClusterRoleAggregationRule, ns, depth+1, visited)
+			}
+		}
+	}
+
+	// Bindings of the subject & their roles
+	subject := addNode(root, 0)
+	for bindingUID, rset := range root.Dependencies {
+		b, ok := nodeMap[bindingUID]
+		if !ok || b.Group != rbacv1.GroupName {
+			continue
+		}
+		var r Relationship
+		var ns string
+		switch b.Kind {
+		case "ClusterRoleBinding":
+			r = RelationshipClusterRoleBindingSubject
+		case "RoleBinding":
+			r, ns = RelationshipRoleBindingSubject, b.Namespace
+		default:
+			continue
+		}
+		if _, ok := rset[r]; !ok {
+			continue
+		}
+		binding := addNode(b, 1)
+		addRelationship(subject, binding, r)
+		for roleUID, rrset := range b.Dependencies {
+			if _, ok := rrset[RelationshipRoleBindingRole]; !ok {
+				continue
+			}
+			if role, ok := nodeMap[roleUID]; ok {
+				walkRole(binding, binding, role, RelationshipRoleBindingRole, ns, 2, sets.NewString())
+			}
+		}
+	}
+
+	// Objects covered by the rules of the roles, within the scopes which the
+	// roles are bound to
+	for roleUID, scopes := range roleScopes {
+		role := result[roleUID]
+		for depUID, rset := range nodeMap[roleUID].Dependencies {
+			dep, ok := nodeMap[depUID]
+			if !ok {
+				continue
+			}
+			for r := range rset {
+				if !isPolicyRuleRelationship(r) {
+					continue
+				}
+				inScope := scopes.Has("") || (len(dep.Namespace) > 0 && scopes.Has(dep.Namespace))
+				if !inScope && !isPolicyRuleWildcardRelationship(r) {
+					continue
+				}
+				addRelationship(role, addNode(dep, role.Depth+1), r)
+			}
+		}
+	}
+
+	return result, rules, nil
+}
+
+// getPolicyRules returns the policy rules of the provided ClusterRole or Role.
+func getPolicyRules(n *Node) ([]rbacv1.PolicyRule, error) {
+	switch n.Kind {
+	case "ClusterRole":
+		var cr rbacv1.ClusterRole
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &cr)
+		return cr.Rules, err
+	case "Role":
+		var ro rbacv1.Role
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &ro)
+		return ro.Rules, err
+	}
+	return nil, nil
+}
+
+// isPolicyRuleRelationship returns true if the provided relationship is a
+// ClusterRole or Role policy rule relationship.
+func isPolicyRuleRelationship(r Relationship) bool {
+	switch edgeRelationship(r) {
+	case RelationshipClusterRolePolicyRule, RelationshipRolePolicyRule:
+		return true
+	}
+	return isPolicyRuleWildcardRelationship(r)
+}
+
+// isPolicyRuleWildcardRelationship returns true if the provided relationship
+// is a ClusterRole or Role policy rule relationship summarising a wildcard
+// rule.
+func isPolicyRuleWildcardRelationship(r Relationship) bool {
+	switch edgeRelationship(r) {
+	case RelationshipClusterRolePolicyRuleWildcard, RelationshipRolePolicyRuleWildcard:
+		return true
+	}
+	return false
+}
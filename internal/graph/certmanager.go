@@ -0,0 +1,185 @@
+package graph
+
+import (
+	"strings"
+)
+
+// cert-manager groups & well-known values.
+const (
+	CertManagerGroupName     = "cert-manager.io"
+	CertManagerACMEGroupName = "acme.cert-manager.io"
+
+	// Annotations on Ingresses & Gateways used by cert-manager's ingress-shim
+	// to request certificates from an issuer.
+	CertManagerClusterIssuerAnnotation = "cert-manager.io/cluster-issuer"
+	CertManagerIssuerAnnotation        = "cert-manager.io/issuer"
+	CertManagerIssuerGroupAnnotation   = "cert-manager.io/issuer-group"
+	CertManagerIssuerKindAnnotation    = "cert-manager.io/issuer-kind"
+
+	// DefaultCertManagerNamespace is the namespace which cert-manager is
+	// installed in by default, which it reads the Secrets referenced by
+	// ClusterIssuers from (ie. its cluster resource namespace).
+	DefaultCertManagerNamespace = "cert-manager"
+)
+
+const (
+	// cert-manager Certificate relationships.
+	RelationshipCertManagerCertificateIssuer           Relationship = "CertManagerCertificateIssuer"
+	RelationshipCertManagerCertificateKeystorePassword Relationship = "CertManagerCertificateKeystorePassword"
+	RelationshipCertManagerCertificateSecret           Relationship = "CertManagerCertificateSecret"
+
+	// cert-manager CertificateRequest, Order & Challenge relationships.
+	RelationshipCertManagerCertificateRequestIssuer Relationship = "CertManagerCertificateRequestIssuer"
+	RelationshipCertManagerChallengeIssuer          Relationship = "CertManagerChallengeIssuer"
+	RelationshipCertManagerOrderIssuer              Relationship = "CertManagerOrderIssuer"
+
+	// cert-manager Issuer & ClusterIssuer relationships.
+	RelationshipCertManagerIssuerACMEAccountKey                Relationship = "CertManagerIssuerACMEAccountKey"
+	RelationshipCertManagerIssuerACMEExternalAccountBindingKey Relationship = "CertManagerIssuerACMEExternalAccountBindingKey"
+	RelationshipCertManagerIssuerCA                            Relationship = "CertManagerIssuerCA"
+	RelationshipCertManagerIssuerVaultAuth                     Relationship = "CertManagerIssuerVaultAuth"
+
+	// cert-manager ingress-shim relationships (eg. on Ingresses & Gateways).
+	RelationshipCertManagerIssuerAnnotation Relationship = "CertManagerIssuerAnnotation"
+)
+
+// getCertManagerCertificateRelationships returns a map of relationships that
+// this cert-manager Certificate has with other objects, based on what was
+// referenced in its manifest.
+func getCertManagerCertificateRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipCertManagerCertificateIssuer
+	if ref, ok := getCertManagerIssuerReference(n, "spec", "issuerRef"); ok {
+		result.AddDependencyByKey(ref.Key(), RelationshipCertManagerCertificateIssuer)
+	}
+
+	// RelationshipCertManagerCertificateKeystorePassword
+	for _, ks := range []string{"jks", "pkcs12"} {
+		if name := n.GetNestedString("spec", "keystores", ks, "passwordSecretRef", "name"); len(name) > 0 {
+			ref = ObjectReference{Kind: "Secret", Name: name, Namespace: n.Namespace}
+			result.AddDependencyByKey(ref.Key(), RelationshipCertManagerCertificateKeystorePassword)
+		}
+	}
+
+	// RelationshipCertManagerCertificateSecret
+	if name := n.GetNestedString("spec", "secretName"); len(name) > 0 {
+		ref = ObjectReference{Kind: "Secret", Name: name, Namespace: n.Namespace}
+		result.AddDependentByKey(ref.Key(), RelationshipCertManagerCertificateSecret)
+	}
+
+	return &result, nil
+}
+
+// getCertManagerIssuerReferenceRelationships returns a map of relationships
+// that this cert-manager CertificateRequest, Order or Challenge has with other
+// objects, based on what was referenced in its manifest.
+func getCertManagerIssuerReferenceRelationships(n *Node) (*RelationshipMap, error) {
+	result := newRelationshipMap()
+
+	// RelationshipCertManagerCertificateRequestIssuer
+	// RelationshipCertManagerChallengeIssuer
+	// RelationshipCertManagerOrderIssuer
+	r := RelationshipCertManagerCertificateRequestIssuer
+	switch n.Kind {
+	case "Challenge":
+		r = RelationshipCertManagerChallengeIssuer
+	case "Order":
+		r = RelationshipCertManagerOrderIssuer
+	}
+	if ref, ok := getCertManagerIssuerReference(n, "spec", "issuerRef"); ok {
+		result.AddDependencyByKey(ref.Key(), r)
+	}
+
+	return &result, nil
+}
+
+// getCertManagerIssuerRelationships returns a map of relationships that this
+// cert-manager Issuer or ClusterIssuer has with other objects, based on what
+// was referenced in its manifest. ClusterIssuers reference Secrets in the
+// provided cert-manager namespace.
+func getCertManagerIssuerRelationships(n *Node, certManagerNamespace string) (*RelationshipMap, error) {
+	var ref ObjectReference
+	ns := n.Namespace
+	if n.Kind == "ClusterIssuer" {
+		ns = certManagerNamespace
+	}
+	result := newRelationshipMap()
+
+	// RelationshipCertManagerIssuerACMEAccountKey
+	if name := n.GetNestedString("spec", "acme", "privateKeySecretRef", "name"); len(name) > 0 {
+		ref = ObjectReference{Kind: "Secret", Name: name, Namespace: ns}
+		result.AddDependencyByKey(ref.Key(), RelationshipCertManagerIssuerACMEAccountKey)
+	}
+
+	// RelationshipCertManagerIssuerACMEExternalAccountBindingKey
+	if name := n.GetNestedString("spec", "acme", "externalAccountBinding", "keySecretRef", "name"); len(name) > 0 {
+		ref = ObjectReference{Kind: "Secret", Name: name, Namespace: ns}
+		result.AddDependencyByKey(ref.Key(), RelationshipCertManagerIssuerACMEExternalAccountBindingKey)
+	}
+
+	// RelationshipCertManagerIssuerCA
+	if name := n.GetNestedString("spec", "ca", "secretName"); len(name) > 0 {
+		ref = ObjectReference{Kind: "Secret", Name: name, Namespace: ns}
+		result.AddDependencyByKey(ref.Key(), RelationshipCertManagerIssuerCA)
+	}
+
+	// RelationshipCertManagerIssuerVaultAuth
+	for _, fields := range [][]string{
+		{"spec", "vault", "auth", "appRole", "secretRef", "name"},
+		{"spec", "vault", "auth", "kubernetes", "secretRef", "name"},
+		{"spec", "vault", "auth", "tokenSecretRef", "name"},
+	} {
+		if name := n.GetNestedString(fields...); len(name) > 0 {
+			ref = ObjectReference{Kind: "Secret", Name: name, Namespace: ns}
+			result.AddDependencyByKey(ref.Key(), RelationshipCertManagerIssuerVaultAuth)
+		}
+	}
+
+	return &result, nil
+}
+
+// getCertManagerIssuerReference returns the reference to the issuer nested in
+// the provided fields of the node. Issuers default to a cert-manager Issuer in
+// the node's namespace, while issuers with a kind ending with "ClusterIssuer"
+// (eg. cert-manager's ClusterIssuer) are assumed to be cluster-scoped.
+func getCertManagerIssuerReference(n *Node, fields ...string) (ObjectReference, bool) {
+	name := n.GetNestedString(append(fields, "name")...)
+	if len(name) == 0 {
+		return ObjectReference{}, false
+	}
+	kind := n.GetNestedString(append(fields, "kind")...)
+	group := n.GetNestedString(append(fields, "group")...)
+	return newCertManagerIssuerReference(group, kind, name, n.Namespace), true
+}
+
+// getCertManagerIssuerAnnotationReference returns the reference to the issuer
+// specified by the cert-manager ingress-shim annotations of the node.
+func getCertManagerIssuerAnnotationReference(n *Node) (ObjectReference, bool) {
+	annotations := n.GetAnnotations()
+	if name := annotations[CertManagerClusterIssuerAnnotation]; len(name) > 0 {
+		return ObjectReference{Group: CertManagerGroupName, Kind: "ClusterIssuer", Name: name}, true
+	}
+	if name := annotations[CertManagerIssuerAnnotation]; len(name) > 0 {
+		group := annotations[CertManagerIssuerGroupAnnotation]
+		kind := annotations[CertManagerIssuerKindAnnotation]
+		return newCertManagerIssuerReference(group, kind, name, n.Namespace), true
+	}
+	return ObjectReference{}, false
+}
+
+// newCertManagerIssuerReference returns the reference to the issuer with the
+// provided group, kind & name, defaulting to a cert-manager Issuer.
+func newCertManagerIssuerReference(group, kind, name, ns string) ObjectReference {
+	if len(group) == 0 {
+		group = CertManagerGroupName
+	}
+	if len(kind) == 0 {
+		kind = "Issuer"
+	}
+	if strings.HasSuffix(kind, "ClusterIssuer") {
+		ns = ""
+	}
+	return ObjectReference{Group: group, Kind: kind, Name: name, Namespace: ns}
+}
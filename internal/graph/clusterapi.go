@@ -0,0 +1,213 @@
+package graph
+
+import (
+	"encoding/base64"
+	"net"
+	"net/url"
+	"strconv"
+
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Cluster API group & well-known values.
+const (
+	ClusterAPIGroupName = "cluster.x-k8s.io"
+
+	// clusterAPIKubeconfigSecretSuffix is the suffix of the name of the Secret
+	// containing the kubeconfig of a Cluster API workload cluster.
+	clusterAPIKubeconfigSecretSuffix = "-kubeconfig"
+)
+
+const (
+	// Cluster API Cluster relationships.
+	RelationshipClusterAPIClusterControlPlane   Relationship = "ClusterAPIClusterControlPlane"
+	RelationshipClusterAPIClusterInfrastructure Relationship = "ClusterAPIClusterInfrastructure"
+	RelationshipClusterAPIClusterKubeconfig     Relationship = "ClusterAPIClusterKubeconfig"
+
+	// Cluster API Machine relationships.
+	RelationshipClusterAPIMachineBootstrapData  Relationship = "ClusterAPIMachineBootstrapData"
+	RelationshipClusterAPIMachineCluster        Relationship = "ClusterAPIMachineCluster"
+	RelationshipClusterAPIMachineInfrastructure Relationship = "ClusterAPIMachineInfrastructure"
+	RelationshipClusterAPIMachineNode           Relationship = "ClusterAPIMachineNode"
+)
+
+// getClusterAPIClusterRelationships returns a map of relationships that this
+// Cluster API Cluster has with other objects, based on what was referenced in
+// its manifest.
+func getClusterAPIClusterRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipClusterAPIClusterControlPlane
+	if ref, ok := getNestedObjectReference(n, "spec", "controlPlaneRef"); ok {
+		result.AddDependentByKey(ref.Key(), RelationshipClusterAPIClusterControlPlane)
+	}
+
+	// RelationshipClusterAPIClusterInfrastructure
+	if ref, ok := getNestedObjectReference(n, "spec", "infrastructureRef"); ok {
+		result.AddDependentByKey(ref.Key(), RelationshipClusterAPIClusterInfrastructure)
+	}
+
+	// RelationshipClusterAPIClusterKubeconfig
+	ref = ObjectReference{Kind: "Secret", Name: n.Name + clusterAPIKubeconfigSecretSuffix, Namespace: n.Namespace}
+	result.AddDependentByKey(ref.Key(), RelationshipClusterAPIClusterKubeconfig)
+
+	return &result, nil
+}
+
+// getClusterAPIMachineRelationships returns a map of relationships that this
+// Cluster API Machine has with other objects, based on what was referenced in
+// its manifest.
+func getClusterAPIMachineRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipClusterAPIMachineBootstrapData
+	if name := n.GetNestedString("spec", "bootstrap", "dataSecretName"); len(name) > 0 {
+		ref = ObjectReference{Kind: "Secret", Name: name, Namespace: n.Namespace}
+		result.AddDependencyByKey(ref.Key(), RelationshipClusterAPIMachineBootstrapData)
+	}
+
+	// RelationshipClusterAPIMachineCluster
+	if name := n.GetNestedString("spec", "clusterName"); len(name) > 0 {
+		ref = ObjectReference{Group: ClusterAPIGroupName, Kind: "Cluster", Name: name, Namespace: n.Namespace}
+		result.AddDependencyByKey(ref.Key(), RelationshipClusterAPIMachineCluster)
+	}
+
+	// RelationshipClusterAPIMachineInfrastructure
+	if ref, ok := getNestedObjectReference(n, "spec", "infrastructureRef"); ok {
+		result.AddDependentByKey(ref.Key(), RelationshipClusterAPIMachineInfrastructure)
+	}
+
+	return &result, nil
+}
+
+// getClusterAPIMachineCrossClusterRelationships returns a map of relationships
+// that this Cluster API Machine has with objects in its workload cluster,
+// based on what was referenced in its manifest.
+func getClusterAPIMachineCrossClusterRelationships(n *Node, idx *clusterIndex) (map[string]*RelationshipMap, error) {
+	nodeName := n.GetNestedString("status", "nodeRef", "name")
+	clusterName := n.GetNestedString("spec", "clusterName")
+	if len(nodeName) == 0 || len(clusterName) == 0 {
+		return nil, nil
+	}
+	ref := ObjectReference{Group: ClusterAPIGroupName, Kind: "Cluster", Name: clusterName, Namespace: n.Namespace}
+	c, ok := idx.getNode(n.Cluster, ref.Key())
+	if !ok {
+		return nil, nil
+	}
+	workloadCluster, ok := idx.getClusterAPIWorkloadCluster(c)
+	if !ok {
+		return nil, nil
+	}
+
+	result := newRelationshipMap()
+
+	// RelationshipClusterAPIMachineNode
+	ref = ObjectReference{Kind: "Node", Name: nodeName}
+	result.AddDependentByKey(ref.Key(), RelationshipClusterAPIMachineNode)
+
+	return map[string]*RelationshipMap{workloadCluster: &result}, nil
+}
+
+// getClusterAPIWorkloadCluster returns the name of the cluster that is the
+// workload cluster of the provided Cluster API Cluster. A cluster matches if
+// its API server address matches either the Cluster's control plane endpoint
+// or a server in the kubeconfig stored in the Cluster's kubeconfig Secret.
+func (idx *clusterIndex) getClusterAPIWorkloadCluster(c *Node) (string, bool) {
+	host := c.GetNestedString("spec", "controlPlaneEndpoint", "host")
+	port, _, _ := unstructuredv1.NestedInt64(c.UnstructuredContent(), "spec", "controlPlaneEndpoint", "port")
+	servers := idx.getClusterAPIKubeconfigServers(c)
+	for _, co := range idx.clusters {
+		if co.Cluster == c.Cluster {
+			continue
+		}
+		if len(host) > 0 && serverMatchesEndpoint(co.Server, host, port) {
+			return co.Cluster, true
+		}
+		for _, s := range servers {
+			if h, p, ok := splitServer(s); ok && serverMatchesEndpoint(co.Server, h, p) {
+				return co.Cluster, true
+			}
+		}
+	}
+	return "", false
+}
+
+// getClusterAPIKubeconfigServers returns the API server addresses in the
+// kubeconfig stored in the kubeconfig Secret of the provided Cluster API
+// Cluster. Returns nil if the Secret wasn't fetched with its data.
+func (idx *clusterIndex) getClusterAPIKubeconfigServers(c *Node) []string {
+	ref := ObjectReference{Kind: "Secret", Name: c.Name + clusterAPIKubeconfigSecretSuffix, Namespace: c.Namespace}
+	secret, ok := idx.getNode(c.Cluster, ref.Key())
+	if !ok {
+		return nil
+	}
+	value := secret.GetNestedString("data", "value")
+	if len(value) == 0 {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return nil
+	}
+	var servers []string
+	for _, cluster := range config.Clusters {
+		if cluster != nil && len(cluster.Server) > 0 {
+			servers = append(servers, cluster.Server)
+		}
+	}
+	return servers
+}
+
+// serverMatchesEndpoint returns true if the provided API server address points
+// to the provided host & port. A port of 0 matches any port.
+func serverMatchesEndpoint(server, host string, port int64) bool {
+	h, p, ok := splitServer(server)
+	return ok && h == host && (port == 0 || p == port)
+}
+
+// splitServer splits the provided API server address into its host & port.
+// Addresses without a port default to port 443.
+func splitServer(server string) (string, int64, bool) {
+	u, err := url.Parse(server)
+	if err != nil || len(u.Host) == 0 {
+		return "", 0, false
+	}
+	h, p, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return u.Host, 443, true
+	}
+	port, err := strconv.ParseInt(p, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return h, port, true
+}
+
+// getNestedObjectReference returns the object reference (with "apiVersion",
+// "kind", "name" & optionally "namespace" fields) nested in the provided
+// fields of the node. References without a namespace default to the node's
+// namespace.
+func getNestedObjectReference(n *Node, fields ...string) (ObjectReference, bool) {
+	kind := n.GetNestedString(append(fields, "kind")...)
+	name := n.GetNestedString(append(fields, "name")...)
+	if len(kind) == 0 || len(name) == 0 {
+		return ObjectReference{}, false
+	}
+	gv, err := schema.ParseGroupVersion(n.GetNestedString(append(fields, "apiVersion")...))
+	if err != nil {
+		return ObjectReference{}, false
+	}
+	ns := n.GetNestedString(append(fields, "namespace")...)
+	if len(ns) == 0 {
+		ns = n.Namespace
+	}
+	return ObjectReference{Group: gv.Group, Kind: kind, Name: name, Namespace: ns}, true
+}
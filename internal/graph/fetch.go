@@ -0,0 +1,819 @@
+package graph
+
+import (
+	"context"
+	"sync"
+
+	"github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn"
+	"golang.org/x/sync/errgroup"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	kubevirt "kubevirt.io/api/core"
+
+	"github.com/tohjustin/kube-lineage/internal/client"
+)
+
+// maxConcurrentFetches is the maximum number of objects or collections of
+// objects fetched concurrently. The requests made to the server are further
+// limited by the client.
+const maxConcurrentFetches = 32
+
+// ownedGroupKinds contains, for every GroupKind that is known to own other
+// objects, the GroupKinds of objects that it typically owns via owner
+// references. Objects owned by built-in GroupKinds without an entry aren't
+// looked up, while objects owned by third-party GroupKinds without an entry
+// are looked up among the ownedCandidateGroupKinds & the GroupKinds of the
+// owner's API group.
+var ownedGroupKinds = map[schema.GroupKind][]schema.GroupKind{
+	{Group: appsv1.GroupName, Kind: "DaemonSet"}: {
+		{Kind: "Pod"},
+		{Group: appsv1.GroupName, Kind: "ControllerRevision"},
+	},
+	{Group: appsv1.GroupName, Kind: "Deployment"}: {
+		{Group: appsv1.GroupName, Kind: "ReplicaSet"},
+	},
+	{Group: appsv1.GroupName, Kind: "ReplicaSet"}: {
+		{Kind: "Pod"},
+	},
+	{Group: appsv1.GroupName, Kind: "StatefulSet"}: {
+		{Kind: "Pod"},
+		{Group: appsv1.GroupName, Kind: "ControllerRevision"},
+	},
+	{Group: batchv1.GroupName, Kind: "CronJob"}: {
+		{Group: batchv1.GroupName, Kind: "Job"},
+	},
+	{Group: batchv1.GroupName, Kind: "Job"}: {
+		{Kind: "Pod"},
+	},
+	{Group: corev1.GroupName, Kind: "Node"}: {
+		{Kind: "Pod"},
+		{Group: coordinationv1.GroupName, Kind: "Lease"},
+	},
+	{Group: corev1.GroupName, Kind: "ReplicationController"}: {
+		{Kind: "Pod"},
+	},
+	{Group: corev1.GroupName, Kind: "Service"}: {
+		{Group: discoveryv1.GroupName, Kind: "EndpointSlice"},
+	},
+	{Group: kubevirt.GroupName, Kind: "VirtualMachine"}: {
+		{Kind: "Secret"},
+		{Group: appsv1.GroupName, Kind: "ControllerRevision"},
+		{Group: kubevirt.GroupName, Kind: "VirtualMachineInstance"},
+	},
+	{Group: kubevirt.GroupName, Kind: "VirtualMachineInstance"}: {
+		{Kind: "Pod"},
+		{Group: policyv1.GroupName, Kind: "PodDisruptionBudget"},
+	},
+	{Group: longhorn.GroupName, Kind: "Volume"}: {
+		{Group: longhorn.GroupName, Kind: "Engine"},
+		{Group: longhorn.GroupName, Kind: "Replica"},
+	},
+}
+
+// ownedCandidateGroupKinds contains the built-in GroupKinds of objects that
+// are typically owned by objects of third-party GroupKinds (eg. the objects
+// managed by operators).
+var ownedCandidateGroupKinds = []schema.GroupKind{
+	{Kind: "ConfigMap"},
+	{Kind: "PersistentVolumeClaim"},
+	{Kind: "Pod"},
+	{Kind: "Secret"},
+	{Kind: "Service"},
+	{Kind: "ServiceAccount"},
+	{Group: appsv1.GroupName, Kind: "DaemonSet"},
+	{Group: appsv1.GroupName, Kind: "Deployment"},
+	{Group: appsv1.GroupName, Kind: "StatefulSet"},
+	{Group: batchv1.GroupName, Kind: "CronJob"},
+	{Group: batchv1.GroupName, Kind: "Job"},
+	{Group: networkingv1.GroupName, Kind: "Ingress"},
+	{Group: policyv1.GroupName, Kind: "PodDisruptionBudget"},
+	{Group: rbacv1.GroupName, Kind: "Role"},
+	{Group: rbacv1.GroupName, Kind: "RoleBinding"},
+}
+
+// FetchOptions contains the options for fetching the objects that are
+// reachable from a root object.
+type FetchOptions struct {
+	APIResourcesToExclude []client.APIResource
+	APIResourcesToInclude []client.APIResource
+	Namespaces            []string
+
+	// CertManagerNamespace is the namespace which cert-manager is installed
+	// in. See ResolveOptions.
+	CertManagerNamespace string
+	// Depth is the maximum depth of the relationship tree to fetch objects
+	// for. If set to zero, objects are fetched until no new objects are found.
+	Depth uint
+	// DepsIsDependencies determines whether to fetch the dependencies instead
+	// of the dependents of the root object.
+	DepsIsDependencies bool
+	// FullObjectFn determines whether full objects are required for the
+	// provided API resource when listing it. See client.ListOptions.
+	FullObjectFn func(api client.APIResource) bool
+	// KEDANamespace is the namespace which KEDA is installed in. See
+	// ResolveOptions.
+	KEDANamespace string
+	// LonghornNamespace is the namespace which Longhorn is installed in. See
+	// ResolveOptions.
+	LonghornNamespace string
+}
+
+// resolveOptions returns the options for resolving relationships between the
+// fetched objects.
+func (o FetchOptions) resolveOptions() ResolveOptions {
+	return ResolveOptions{
+		CertManagerNamespace: o.CertManagerNamespace,
+		KEDANamespace:        o.KEDANamespace,
+		LonghornNamespace:    o.LonghornNamespace,
+	}
+}
+
+// FetchReachable fetches the provided root object along with all objects that
+// are reachable from it through known relationship types. Instead of listing
+// every API resource in the cluster, the relationship tree is expanded level
+// by level up to the provided depth & only the objects referenced by the
+// objects at each level (or the resource types which may reference them) are
+// fetched.
+func FetchReachable(ctx context.Context, c client.Interface, root *unstructuredv1.Unstructured, opts FetchOptions) ([]unstructuredv1.Unstructured, error) {
+	f := newFetcher(c, opts)
+	f.addObjects(*root)
+
+	mapper := c.GetMapper()
+	rootUID := root.GetUID()
+	expandedUIDSet := map[types.UID]struct{}{}
+	for level := 0; ; level++ {
+		nodeMap, err := resolveDeps(mapper, f.objects, []types.UID{rootUID}, opts.DepsIsDependencies, opts.resolveOptions())
+		if err != nil {
+			return nil, err
+		}
+
+		// Find nodes that have yet to be expanded within the requested depth
+		var nodes []*Node
+		for uid, node := range nodeMap {
+			if _, ok := expandedUIDSet[uid]; ok {
+				continue
+			}
+			if opts.Depth != 0 && node.Depth >= opts.Depth {
+				continue
+			}
+			expandedUIDSet[uid] = struct{}{}
+			nodes = append(nodes, node)
+		}
+		if len(nodes) == 0 {
+			break
+		}
+
+		klog.V(4).Infof("Fetching objects reachable from %d objects at level %d", len(nodes), level)
+		if err := f.fetchReachableFrom(ctx, nodes); err != nil {
+			return nil, err
+		}
+	}
+
+	klog.V(4).Infof("Fetched %d objects reachable from the root object", len(f.objects))
+	return f.objects, nil
+}
+
+type fetcher struct {
+	client client.Interface
+	opts   FetchOptions
+
+	isClusterScopeRequest bool
+	nsSet                 map[string]struct{}
+	excludeGKSet          map[schema.GroupKind]struct{}
+	includeGKSet          map[schema.GroupKind]struct{}
+
+	mu         sync.Mutex
+	objects    []unstructuredv1.Unstructured
+	uidSet     map[types.UID]struct{}
+	requestSet map[string]struct{}
+	kindGroups map[string][]string
+
+	// ownerCandidates contains the listings of objects which may be owned by
+	// objects of third-party GroupKinds, mapped by their request keys
+	ownerCandidates map[string]*ownerCandidateList
+}
+
+// ownerCandidateList is a listing of objects which may be owned by objects of
+// third-party GroupKinds.
+type ownerCandidateList struct {
+	once sync.Once
+	objs []unstructuredv1.Unstructured
+	err  error
+}
+
+func newFetcher(c client.Interface, opts FetchOptions) *fetcher {
+	f := &fetcher{
+		client:       c,
+		opts:         opts,
+		nsSet:        map[string]struct{}{},
+		excludeGKSet: client.ResourcesToGroupKindSet(opts.APIResourcesToExclude),
+		includeGKSet: client.ResourcesToGroupKindSet(opts.APIResourcesToInclude),
+		uidSet:       map[types.UID]struct{}{},
+		requestSet:   map[string]struct{}{},
+		kindGroups:   map[string][]string{},
+
+		ownerCandidates: map[string]*ownerCandidateList{},
+	}
+	if len(opts.Namespaces) == 0 {
+		f.isClusterScopeRequest = true
+	}
+	for _, ns := range opts.Namespaces {
+		if ns != "" {
+			f.nsSet[ns] = struct{}{}
+		} else {
+			f.isClusterScopeRequest = true
+		}
+	}
+	return f
+}
+
+// addObjects adds the provided objects to the list of fetched objects, while
+// skipping objects that were already fetched.
+func (f *fetcher) addObjects(objs ...unstructuredv1.Unstructured) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, o := range objs {
+		if _, ok := f.uidSet[o.GetUID()]; ok {
+			continue
+		}
+		f.uidSet[o.GetUID()] = struct{}{}
+		f.objects = append(f.objects, o)
+	}
+}
+
+// markRequested marks the provided request as requested. Returns false if the
+// request was already made.
+func (f *fetcher) markRequested(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.requestSet[key]; ok {
+		return false
+	}
+	f.requestSet[key] = struct{}{}
+	return true
+}
+
+// getKindGroups returns the API groups serving the provided kind if it isn't
+// served by the core group.
+func (f *fetcher) getKindGroups(kind string) []string {
+	f.mu.Lock()
+	groups, ok := f.kindGroups[kind]
+	f.mu.Unlock()
+	if ok {
+		return groups
+	}
+	groups = getKindGroups(f.client.GetMapper(), kind)
+	f.mu.Lock()
+	f.kindGroups[kind] = groups
+	f.mu.Unlock()
+	return groups
+}
+
+// isListed returns true if all objects of the provided GroupKind were already
+// requested.
+func (f *fetcher) isListed(gk schema.GroupKind) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.requestSet[listRequestKey(gk)]
+	return ok
+}
+
+// isInScope returns true if objects in the provided namespace are within the
+// namespaces to fetch objects from.
+func (f *fetcher) isInScope(ns string) bool {
+	if f.isClusterScopeRequest || ns == "" {
+		return true
+	}
+	_, ok := f.nsSet[ns]
+	return ok
+}
+
+// resolve resolves the provided GroupKind into an APIResource. Returns false if
+// the GroupKind isn't served by the cluster or is excluded from relationship
+// discovery.
+func (f *fetcher) resolve(gk schema.GroupKind) (*client.APIResource, bool) {
+	if _, ok := f.excludeGKSet[gk]; ok {
+		return nil, false
+	}
+	if _, ok := f.includeGKSet[gk]; len(f.includeGKSet) > 0 && !ok {
+		return nil, false
+	}
+	api, err := f.client.ResolveGroupKind(gk)
+	if err != nil {
+		klog.V(4).Infof("Skip fetching objects of kind \"%s\": %s", gk, err)
+		return nil, false
+	}
+	return api, true
+}
+
+// fetchReachableFrom fetches all objects that are reachable from the provided
+// nodes.
+func (f *fetcher) fetchReachableFrom(ctx context.Context, nodes []*Node) error {
+	var fetchFns []func(context.Context) error
+	for _, node := range nodes {
+		fetchFns = append(fetchFns, f.getFetchFns(node)...)
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(maxConcurrentFetches)
+	for i := range fetchFns {
+		fn := fetchFns[i]
+		eg.Go(func() error {
+			return fn(ctx)
+		})
+	}
+	return eg.Wait()
+}
+
+// getFetchFns returns the functions for fetching all objects that are
+// reachable from the provided node.
+//
+//nolint:gocognit
+func (f *fetcher) getFetchFns(node *Node) []func(context.Context) error {
+	var fetchFns []func(context.Context) error
+	gk := schema.GroupKind{Group: node.Group, Kind: node.Kind}
+
+	// Objects referenced by the node
+	rmap, err := getRelationships(node, f.opts.resolveOptions())
+	if err != nil {
+		klog.V(4).Infof("Failed to get relationships for %s named \"%s\": %s", gk, node.Name, err)
+	}
+	if rmap != nil {
+		refs, olsMap, osMap := rmap.DependentsByRef, rmap.DependentsByLabelSelector, rmap.DependentsBySelector
+		if f.opts.DepsIsDependencies {
+			refs, olsMap, osMap = rmap.DependenciesByRef, rmap.DependenciesByLabelSelector, rmap.DependenciesBySelector
+		}
+		for k := range refs {
+			fetchFns = append(fetchFns, f.createGetFn(k.ObjectReference()))
+		}
+		if f.opts.DepsIsDependencies {
+			for k := range rmap.DependenciesByGrantedRef {
+				fetchFns = append(fetchFns, f.createGetFn(k.ObjectReference()))
+			}
+			for k := range rmap.DependenciesByNamespaceSelector {
+				if ons, ok := rmap.ObjectNamespaceSelectors[k]; ok {
+					fetchFns = append(fetchFns, f.createListFn(schema.GroupKind{Group: ons.Group, Kind: ons.Kind}))
+				}
+			}
+			for k := range rmap.DependenciesByParamRef {
+				if opr, ok := rmap.ObjectParamReferences[k]; ok {
+					fetchFns = append(fetchFns, f.createListByParamRefFn(opr))
+				}
+			}
+			for k := range rmap.DependenciesByPolicyRule {
+				if ops, ok := rmap.ObjectPolicyRuleSelectors[k]; ok {
+					fetchFns = append(fetchFns, f.createListByPolicyRuleFn(ops))
+				}
+			}
+		}
+		for k := range olsMap {
+			if ols, ok := rmap.ObjectLabelSelectors[k]; ok {
+				fetchFns = append(fetchFns, f.createListByLabelSelectorFn(ols))
+			}
+		}
+		for k := range osMap {
+			os, ok := rmap.ObjectSelectors[k]
+			switch {
+			case !ok:
+			case len(os.Kind) > 0:
+				fetchFns = append(fetchFns, f.createListFn(schema.GroupKind{Group: os.Group, Kind: os.Kind}))
+			default:
+				fetchFns = append(fetchFns, f.createListByGroupFn(os.Group, os.Version))
+			}
+		}
+	}
+
+	// Objects required to resolve the relationships of the node
+	for _, rgk := range relationshipResolvers[gk].required {
+		fetchFns = append(fetchFns, f.createListFn(rgk))
+	}
+
+	// Objects related to the node via owner references
+	if f.opts.DepsIsDependencies {
+		for _, ref := range node.OwnerReferences {
+			gv, err := schema.ParseGroupVersion(ref.APIVersion)
+			if err != nil {
+				continue
+			}
+			oref := ObjectReference{Group: gv.Group, Kind: ref.Kind, Namespace: node.Namespace, Name: ref.Name}
+			fetchFns = append(fetchFns, f.createGetFn(oref))
+		}
+	} else {
+		selector := getOwnedSelector(node)
+		ogks, ok := ownedGroupKinds[gk]
+		for _, ogk := range ogks {
+			ols := ObjectLabelSelector{Group: ogk.Group, Kind: ogk.Kind, Namespace: node.Namespace, Selector: selector}
+			fetchFns = append(fetchFns, f.createListByLabelSelectorFn(ols))
+		}
+		if !ok && !isBuiltInGroup(gk.Group) {
+			fetchFns = append(fetchFns, f.createListOwnedFn(node))
+		}
+	}
+
+	// Objects with relationship resolvers that may reference the node as a
+	// dependency (or as a dependent when fetching dependencies), except for
+	// objects owned by the node which were already selected above
+	for rgk, r := range relationshipResolvers {
+		targets := r.dependencies
+		if f.opts.DepsIsDependencies {
+			targets = r.dependents
+		} else if containsGroupKind(ownedGroupKinds[gk], rgk) {
+			continue
+		}
+		for _, t := range targets {
+			if t == gk || t == anyGroupKind {
+				fetchFns = append(fetchFns, f.createListFn(rgk))
+				break
+			}
+		}
+	}
+
+	return fetchFns
+}
+
+// createGetFn returns a function that fetches the object matching the provided
+// reference.
+func (f *fetcher) createGetFn(ref ObjectReference) func(context.Context) error {
+	return func(ctx context.Context) error {
+		// References without an API group to objects of third-party kinds
+		if len(ref.Group) == 0 {
+			if groups := f.getKindGroups(ref.Kind); groups != nil {
+				for _, g := range groups {
+					gref := ref
+					gref.Group = g
+					if err := f.createGetFn(gref)(ctx); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		}
+
+		gk := schema.GroupKind{Group: ref.Group, Kind: ref.Kind}
+		if len(ref.Kind) == 0 || len(ref.Name) == 0 || f.isListed(gk) {
+			return nil
+		}
+		if !f.markRequested(string(ref.Key())) {
+			return nil
+		}
+		api, ok := f.resolve(gk)
+		if !ok {
+			return nil
+		}
+		if api.Namespaced && (len(ref.Namespace) == 0 || !f.isInScope(ref.Namespace)) {
+			return nil
+		}
+		obj, err := f.client.Get(ctx, ref.Name, client.GetOptions{
+			APIResource: *api,
+			Namespace:   ref.Namespace,
+		})
+		switch {
+		case apierrors.IsNotFound(err), apierrors.IsForbidden(err):
+			klog.V(4).Infof("Skip fetching %s named \"%s\" in namespace \"%s\": %s", gk, ref.Name, ref.Namespace, err)
+			return nil
+		case err != nil:
+			return err
+		}
+		f.addObjects(*obj)
+		return f.fetchRequired(ctx, gk)
+	}
+}
+
+// createListFn returns a function that fetches all objects of the provided
+// GroupKind.
+func (f *fetcher) createListFn(gk schema.GroupKind) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if !f.markRequested(listRequestKey(gk)) {
+			return nil
+		}
+		api, ok := f.resolve(gk)
+		if !ok {
+			return nil
+		}
+		objs, err := f.client.List(ctx, client.ListOptions{
+			APIResourcesToInclude: []client.APIResource{*api},
+			Namespaces:            f.opts.Namespaces,
+			FullObjectFn:          f.opts.FullObjectFn,
+		})
+		if err != nil {
+			return err
+		}
+		f.addObjects(objs.Items...)
+		return f.fetchRequired(ctx, gk)
+	}
+}
+
+// createListByGroupFn returns a function that fetches all objects of every
+// GroupKind in the provided API group. If a version is provided, only
+// GroupKinds served at that version are fetched.
+func (f *fetcher) createListByGroupFn(group, version string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if !f.markRequested("group\\" + group + "\\" + version) {
+			return nil
+		}
+		apis, err := f.client.GetAPIResources(ctx)
+		if err != nil {
+			return err
+		}
+		mapper := f.client.GetMapper()
+		for _, api := range apis {
+			if api.Group != group {
+				continue
+			}
+			if len(version) > 0 {
+				if _, err := mapper.RESTMapping(api.GroupKind(), version); err != nil {
+					continue
+				}
+			}
+			if err := f.createListFn(api.GroupKind())(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// createListByParamRefFn returns a function that fetches the
+// ValidatingAdmissionPolicy of the provided parameter reference, along with
+// all objects of the policy's parameter kind.
+func (f *fetcher) createListByParamRefFn(opr ObjectParamReference) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if !f.markRequested("paramref\\" + string(opr.Key())) {
+			return nil
+		}
+		gk := schema.GroupKind{Group: admissionregistrationv1.GroupName, Kind: "ValidatingAdmissionPolicy"}
+		api, ok := f.resolve(gk)
+		if !ok {
+			return nil
+		}
+		obj, err := f.client.Get(ctx, opr.PolicyName, client.GetOptions{APIResource: *api})
+		switch {
+		case apierrors.IsNotFound(err), apierrors.IsForbidden(err):
+			klog.V(4).Infof("Skip fetching %s named \"%s\": %s", gk, opr.PolicyName, err)
+			return nil
+		case err != nil:
+			return err
+		}
+		f.addObjects(*obj)
+		if err := f.fetchRequired(ctx, gk); err != nil {
+			return err
+		}
+		pgk, ok := getValidatingAdmissionPolicyParamKind(&Node{Unstructured: obj})
+		if !ok {
+			return nil
+		}
+		return f.createListFn(pgk)(ctx)
+	}
+}
+
+// createListByPolicyRuleFn returns a function that fetches the objects named
+// by the provided policy rule selector, for every GroupKind covered by its API
+// groups & resources. Objects of namespaced GroupKinds are listed if the
+// selector isn't restricted to a namespace.
+func (f *fetcher) createListByPolicyRuleFn(ops ObjectPolicyRuleSelector) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if !f.markRequested(string(ops.Key())) {
+			return nil
+		}
+		apis, err := f.client.GetAPIResources(ctx)
+		if err != nil {
+			return err
+		}
+		for _, api := range apis {
+			if !ops.APIGroups.HasAny(rbacv1.APIGroupAll, api.Group) || !ops.Resources.HasAny(rbacv1.ResourceAll, api.Name) {
+				continue
+			}
+			if api.Namespaced && len(ops.Namespace) == 0 {
+				if err := f.createListFn(api.GroupKind())(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, name := range ops.ResourceNames.List() {
+				ref := ObjectReference{Group: api.Group, Kind: api.Kind, Namespace: ops.Namespace, Name: name}
+				if err := f.createGetFn(ref)(ctx); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// createListOwnedFn returns a function that fetches the objects owned by the
+// provided node of a third-party GroupKind. Since owner references can't be
+// selected by the server, objects of every candidate GroupKind are listed in
+// the node's namespace & filtered by their owner references.
+func (f *fetcher) createListOwnedFn(node *Node) func(context.Context) error {
+	return func(ctx context.Context) error {
+		apis, err := f.client.GetAPIResources(ctx)
+		if err != nil {
+			return err
+		}
+		gks := append([]schema.GroupKind{}, ownedCandidateGroupKinds...)
+		for _, api := range apis {
+			if api.Group == node.Group {
+				gks = append(gks, api.GroupKind())
+			}
+		}
+		for _, gk := range gks {
+			objs, err := f.listOwnerCandidates(ctx, gk, node.Namespace)
+			if err != nil {
+				return err
+			}
+			var found bool
+			for _, o := range objs {
+				for _, ref := range o.GetOwnerReferences() {
+					if ref.UID == node.UID {
+						f.addObjects(o)
+						found = true
+						break
+					}
+				}
+			}
+			if !found {
+				continue
+			}
+			if err := f.fetchRequired(ctx, gk); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// listOwnerCandidates lists the objects of the provided GroupKind which may be
+// owned by objects in the provided namespace. Each listing is only requested
+// once & shared by every owner in the namespace.
+func (f *fetcher) listOwnerCandidates(ctx context.Context, gk schema.GroupKind, ns string) ([]unstructuredv1.Unstructured, error) {
+	key := "owned\\" + gk.String() + "\\" + ns
+	f.mu.Lock()
+	l, ok := f.ownerCandidates[key]
+	if !ok {
+		l = &ownerCandidateList{}
+		f.ownerCandidates[key] = l
+	}
+	f.mu.Unlock()
+
+	l.once.Do(func() {
+		api, ok := f.resolve(gk)
+		if !ok {
+			return
+		}
+		namespaces := f.opts.Namespaces
+		if len(ns) > 0 {
+			// Namespaced objects can't own cluster-scoped objects or objects in
+			// other namespaces
+			if !api.Namespaced || !f.isInScope(ns) {
+				return
+			}
+			namespaces = []string{ns}
+		}
+		var objs *unstructuredv1.UnstructuredList
+		objs, l.err = f.client.List(ctx, client.ListOptions{
+			APIResourcesToInclude: []client.APIResource{*api},
+			Namespaces:            namespaces,
+			FullObjectFn:          f.opts.FullObjectFn,
+		})
+		if l.err == nil {
+			l.objs = objs.Items
+		}
+	})
+	return l.objs, l.err
+}
+
+// createListByLabelSelectorFn returns a function that fetches all objects
+// matching the provided label selector.
+func (f *fetcher) createListByLabelSelectorFn(ols ObjectLabelSelector) func(context.Context) error {
+	return func(ctx context.Context) error {
+		gk := schema.GroupKind{Group: ols.Group, Kind: ols.Kind}
+		if f.isListed(gk) || !f.markRequested(string(ols.Key())) {
+			return nil
+		}
+		api, ok := f.resolve(gk)
+		if !ok {
+			return nil
+		}
+		namespaces := f.opts.Namespaces
+		if api.Namespaced && len(ols.Namespace) > 0 && !ols.AllNamespaces {
+			if !f.isInScope(ols.Namespace) {
+				return nil
+			}
+			namespaces = []string{ols.Namespace}
+		}
+		objs, err := f.client.List(ctx, client.ListOptions{
+			APIResourcesToInclude: []client.APIResource{*api},
+			Namespaces:            namespaces,
+			LabelSelector:         ols.Selector.String(),
+			FullObjectFn:          f.opts.FullObjectFn,
+		})
+		if err != nil {
+			return err
+		}
+		for _, o := range objs.Items {
+			if ols.MatchesName(o.GetName()) {
+				f.addObjects(o)
+			}
+		}
+		return f.fetchRequired(ctx, gk)
+	}
+}
+
+// fetchRequired fetches the objects which are required to resolve the
+// relationships of objects with the provided GroupKind.
+func (f *fetcher) fetchRequired(ctx context.Context, gk schema.GroupKind) error {
+	for _, rgk := range relationshipResolvers[gk].required {
+		if err := f.createListFn(rgk)(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// containsGroupKind returns true if the provided GroupKinds contain the
+// provided GroupKind.
+func containsGroupKind(gks []schema.GroupKind, gk schema.GroupKind) bool {
+	for _, g := range gks {
+		if g == gk {
+			return true
+		}
+	}
+	return false
+}
+
+// isBuiltInGroup returns true if the provided API group is served by
+// Kubernetes itself.
+func isBuiltInGroup(group string) bool {
+	switch group {
+	case apiextensionsv1.GroupName, apiregistrationv1.GroupName:
+		return true
+	}
+	return scheme.Scheme.IsGroupRegistered(group)
+}
+
+// listRequestKey returns the key of the request for listing all objects of the
+// provided GroupKind.
+func listRequestKey(gk schema.GroupKind) string {
+	return "list\\" + gk.String()
+}
+
+// getOwnedSelector returns the label selector matching the objects that the
+// provided node owns via owner references.
+func getOwnedSelector(n *Node) labels.Selector {
+	// EndpointSlices are labeled with the name of their Service instead of the
+	// labels selected by the Service
+	if n.Group == corev1.GroupName && n.Kind == "Service" {
+		return labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: n.Name})
+	}
+	return getWorkloadSelector(n)
+}
+
+// getWorkloadSelector returns the label selector that a workload object (eg.
+// Deployment, ReplicationController) uses to select the objects that it owns.
+// Returns a selector that matches everything if the object has no selector.
+func getWorkloadSelector(n *Node) labels.Selector {
+	if n.Unstructured == nil {
+		return labels.Everything()
+	}
+	s, found, err := unstructuredv1.NestedMap(n.UnstructuredContent(), "spec", "selector")
+	if !found || err != nil {
+		return labels.Everything()
+	}
+	_, hasMatchLabels := s["matchLabels"]
+	_, hasMatchExpressions := s["matchExpressions"]
+	if hasMatchLabels || hasMatchExpressions {
+		var ls metav1.LabelSelector
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(s, &ls); err != nil {
+			return labels.Everything()
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&ls)
+		if err != nil {
+			return labels.Everything()
+		}
+		return selector
+	}
+	// ReplicationControllers use a map of labels as their selector
+	set, found, err := unstructuredv1.NestedStringMap(n.UnstructuredContent(), "spec", "selector")
+	if !found || err != nil {
+		return labels.Everything()
+	}
+	return labels.SelectorFromSet(set)
+}
@@ -0,0 +1,342 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/tohjustin/kube-lineage/internal/client"
+)
+
+// fakeClient is a client.Interface serving the test RESTMapper's API
+// resources from a fake dynamic client.
+type fakeClient struct {
+	mapper  meta.RESTMapper
+	dynamic dynamic.Interface
+
+	// unfilteredLists counts the list requests made for every API resource.
+	unfilteredLists int32
+}
+
+func newFakeClient(objects []unstructuredv1.Unstructured) *fakeClient {
+	gvrToListKind := map[schema.GroupVersionResource]string{}
+	mapper := newTestRESTMapper()
+	for _, gvk := range testGroupVersionKinds {
+		m, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			panic(err)
+		}
+		gvrToListKind[m.Resource] = gvk.Kind + "List"
+	}
+	objs := make([]runtime.Object, 0, len(objects))
+	for ix := range objects {
+		objs = append(objs, objects[ix].DeepCopy())
+	}
+	return &fakeClient{
+		mapper:  mapper,
+		dynamic: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrToListKind, objs...),
+	}
+}
+
+func (c *fakeClient) GetMapper() meta.RESTMapper {
+	return c.mapper
+}
+
+func (c *fakeClient) IsReachable() error {
+	return nil
+}
+
+func (c *fakeClient) ResolveAPIResource(s string) (*client.APIResource, error) {
+	return nil, fmt.Errorf("resolving \"%s\" isn't supported", s)
+}
+
+func (c *fakeClient) ResolveGroupKind(gk schema.GroupKind) (*client.APIResource, error) {
+	m, err := c.mapper.RESTMapping(gk)
+	if err != nil {
+		return nil, err
+	}
+	return &client.APIResource{
+		Name:       m.Resource.Resource,
+		Namespaced: m.Scope.Name() == meta.RESTScopeNameNamespace,
+		Group:      m.GroupVersionKind.Group,
+		Version:    m.GroupVersionKind.Version,
+		Kind:       m.GroupVersionKind.Kind,
+	}, nil
+}
+
+func (c *fakeClient) Get(ctx context.Context, name string, opts client.GetOptions) (*unstructuredv1.Unstructured, error) {
+	ri := c.dynamic.Resource(opts.APIResource.GroupVersionResource())
+	if opts.APIResource.Namespaced {
+		return ri.Namespace(opts.Namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	return ri.Get(ctx, name, metav1.GetOptions{})
+}
+
+func (c *fakeClient) GetAPIResources(_ context.Context) ([]client.APIResource, error) {
+	apis := make([]client.APIResource, 0, len(testGroupVersionKinds))
+	for _, gvk := range testGroupVersionKinds {
+		api, err := c.ResolveGroupKind(gvk.GroupKind())
+		if err != nil {
+			return nil, err
+		}
+		apis = append(apis, *api)
+	}
+	return apis, nil
+}
+
+func (c *fakeClient) GetTable(_ context.Context, _ client.GetTableOptions) (*metav1.Table, error) {
+	return nil, fmt.Errorf("server-printed tables aren't supported")
+}
+
+func (c *fakeClient) List(ctx context.Context, opts client.ListOptions) (*unstructuredv1.UnstructuredList, error) {
+	apis, err := c.GetAPIResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.APIResourcesToInclude) == 0 {
+		atomic.AddInt32(&c.unfilteredLists, 1)
+	}
+	includeGKSet := client.ResourcesToGroupKindSet(opts.APIResourcesToInclude)
+	excludeGKSet := client.ResourcesToGroupKindSet(opts.APIResourcesToExclude)
+	namespaces := opts.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var items []unstructuredv1.Unstructured
+	for _, api := range apis {
+		if _, ok := includeGKSet[api.GroupKind()]; len(includeGKSet) > 0 && !ok {
+			continue
+		}
+		if _, ok := excludeGKSet[api.GroupKind()]; ok {
+			continue
+		}
+		for _, ns := range namespaces {
+			var ri dynamic.ResourceInterface = c.dynamic.Resource(api.GroupVersionResource())
+			if api.Namespaced && len(ns) > 0 {
+				ri = c.dynamic.Resource(api.GroupVersionResource()).Namespace(ns)
+			}
+			objs, err := ri.List(ctx, metav1.ListOptions{LabelSelector: opts.LabelSelector})
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, objs.Items...)
+		}
+	}
+	return &unstructuredv1.UnstructuredList{Items: items}, nil
+}
+
+func (c *fakeClient) SkippedResources() []client.SkippedResource {
+	return nil
+}
+
+// getSubtreeUIDs returns the sorted UIDs of the objects within the provided
+// depth of the relationship tree of the provided root.
+func getSubtreeUIDs(t *testing.T, objects []unstructuredv1.Unstructured, root types.UID, depth uint, depsIsDependencies bool) []string {
+	t.Helper()
+	nodeMap, err := resolveDeps(newTestRESTMapper(), objects, []types.UID{root}, depsIsDependencies, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	var uids []string
+	for uid, node := range nodeMap {
+		if depth == 0 || node.Depth <= depth {
+			uids = append(uids, string(uid))
+		}
+	}
+	sort.Strings(uids)
+	return uids
+}
+
+//nolint:funlen
+func TestFetchReachable(t *testing.T) {
+	t.Parallel()
+
+	webLabels := map[string]interface{}{"app": "web"}
+	newOwnerRefs := func(apiVersion, kind, name string, uid types.UID) []interface{} {
+		return []interface{}{
+			map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "name": name, "uid": string(uid)},
+		}
+	}
+	deploymentRules := []interface{}{
+		map[string]interface{}{
+			"apiGroups":   []interface{}{"apps"},
+			"apiVersions": []interface{}{"v1"},
+			"resources":   []interface{}{"deployments"},
+			"operations":  []interface{}{"CREATE"},
+		},
+	}
+	podSpec := map[string]interface{}{
+		"nodeName":           "node-1",
+		"serviceAccountName": "web",
+		"volumes": []interface{}{
+			map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "web-config"}},
+		},
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("deploy", "apps/v1", "Deployment", "default", "web", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": webLabels},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": webLabels},
+					"spec":     podSpec,
+				},
+			},
+		}),
+		newTestObject("rs", "apps/v1", "ReplicaSet", "default", "web-1", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels":          webLabels,
+				"ownerReferences": newOwnerRefs("apps/v1", "Deployment", "web", "deploy"),
+			},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": webLabels},
+			},
+		}),
+		newTestObject("pod", "v1", "Pod", "default", "web-1-a", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels":          webLabels,
+				"ownerReferences": newOwnerRefs("apps/v1", "ReplicaSet", "web-1", "rs"),
+			},
+			"spec": podSpec,
+		}),
+		newTestObject("svc", "v1", "Service", "default", "web", map[string]interface{}{
+			"spec": map[string]interface{}{"selector": webLabels},
+		}),
+		newTestObject("eps", "discovery.k8s.io/v1", "EndpointSlice", "default", "web-abcde", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels":          map[string]interface{}{"kubernetes.io/service-name": "web"},
+				"ownerReferences": newOwnerRefs("v1", "Service", "web", "svc"),
+			},
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"targetRef": map[string]interface{}{"kind": "Pod", "name": "web-1-a", "namespace": "default"},
+				},
+			},
+		}),
+		newTestObject("cm", "v1", "ConfigMap", "default", "web-config", nil),
+		newTestObject("sa", "v1", "ServiceAccount", "default", "web", nil),
+		newTestObject("node", "v1", "Node", "", "node-1", nil),
+		newTestObject("apisvc", "apiregistration.k8s.io/v1", "APIService", "", "v1.example.com", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{AutoManagedAPIServiceLabel: "true"},
+			},
+			"spec": map[string]interface{}{"group": "example.com", "version": "v1"},
+		}),
+		newTestObject("apisvc-v1beta1", "apiregistration.k8s.io/v1", "APIService", "", "v1beta1.example.com", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{AutoManagedAPIServiceLabel: "true"},
+			},
+			"spec": map[string]interface{}{"group": "example.com", "version": "v1beta1"},
+		}),
+		newTestObject("vol", "example.com/v1", "Volume", "default", "data", nil),
+		newTestObject("vol-cm", "v1", "ConfigMap", "default", "data-config", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"ownerReferences": newOwnerRefs("example.com/v1", "Volume", "data", "vol"),
+			},
+		}),
+		newTestObject("vol-secret", "v1", "Secret", "default", "data-creds", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"ownerReferences": newOwnerRefs("example.com/v1", "Volume", "data", "vol"),
+			},
+		}),
+		newTestObject("cr", "rbac.authorization.k8s.io/v1", "ClusterRole", "", "web-reader", map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"apiGroups":     []interface{}{""},
+					"resources":     []interface{}{"configmaps"},
+					"resourceNames": []interface{}{"web-config"},
+					"verbs":         []interface{}{"get"},
+				},
+				map[string]interface{}{
+					"apiGroups": []interface{}{"apps"},
+					"resources": []interface{}{"deployments/scale"},
+					"verbs":     []interface{}{"update"},
+				},
+			},
+		}),
+		newTestObject("vap", "admissionregistration.k8s.io/v1", "ValidatingAdmissionPolicy", "", "replicas", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"paramKind":        map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"},
+				"matchConstraints": map[string]interface{}{"resourceRules": deploymentRules},
+			},
+		}),
+		newTestObject("vapb", "admissionregistration.k8s.io/v1", "ValidatingAdmissionPolicyBinding", "", "replicas", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"policyName":     "replicas",
+				"paramRef":       map[string]interface{}{"name": "web-config", "namespace": "default"},
+				"matchResources": map[string]interface{}{"resourceRules": deploymentRules},
+			},
+		}),
+		newTestObject("other-cm", "v1", "ConfigMap", "default", "other", nil),
+		newTestObject("other-pod", "v1", "Pod", "default", "other", map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "other"}},
+		}),
+	}
+
+	tests := []struct {
+		name                string
+		root                types.UID
+		depsIsDependencies  bool
+		allowUnfilteredList bool
+	}{
+		{name: "DeploymentDependents", root: "deploy"},
+		{name: "DeploymentDependencies", root: "deploy", depsIsDependencies: true},
+		{name: "PodDependents", root: "pod"},
+		{name: "PodDependencies", root: "pod", depsIsDependencies: true},
+		{name: "ServiceDependents", root: "svc"},
+		{name: "ConfigMapDependents", root: "cm"},
+		{name: "NodeDependents", root: "node"},
+		{name: "ThirdPartyOwnerDependents", root: "vol"},
+		{name: "OwnedByThirdPartyDependencies", root: "vol-cm", depsIsDependencies: true},
+		{name: "ClusterRoleDependencies", root: "cr", depsIsDependencies: true},
+		{name: "AdmissionPolicyDependents", root: "vap"},
+		{name: "AdmissionPolicyBindingDependents", root: "vapb"},
+		{name: "AdmissionPolicyBindingDependencies", root: "vapb", depsIsDependencies: true},
+		{name: "APIServiceDependents", root: "apisvc", allowUnfilteredList: true},
+		{name: "UnservedAPIServiceDependents", root: "apisvc-v1beta1"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		for _, depth := range []uint{0, 1, 2} {
+			depth := depth
+			t.Run(fmt.Sprintf("%s/Depth%d", tt.name, depth), func(t *testing.T) {
+				t.Parallel()
+				c := newFakeClient(objects)
+				var root *unstructuredv1.Unstructured
+				for ix := range objects {
+					if objects[ix].GetUID() == tt.root {
+						root = objects[ix].DeepCopy()
+					}
+				}
+				fetched, err := FetchReachable(context.Background(), c, root, FetchOptions{
+					Depth:              depth,
+					DepsIsDependencies: tt.depsIsDependencies,
+				})
+				if err != nil {
+					t.Fatalf("failed to fetch reachable objects: %v", err)
+				}
+
+				expected := getSubtreeUIDs(t, objects, tt.root, depth, tt.depsIsDependencies)
+				got := getSubtreeUIDs(t, fetched, tt.root, depth, tt.depsIsDependencies)
+				if !reflect.DeepEqual(expected, got) {
+					t.Fatalf("expected objects %v got %v", expected, got)
+				}
+				if n := atomic.LoadInt32(&c.unfilteredLists); n > 0 && !tt.allowUnfilteredList {
+					t.Fatalf("expected no list requests for every API resource, got %d", n)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package graph
+
+import (
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Gateway API group.
+const GatewayAPIGroupName = "gateway.networking.k8s.io"
+
+const (
+	// Gateway API GatewayClass relationships.
+	RelationshipGatewayAPIGatewayClassParameters Relationship = "GatewayAPIGatewayClassParameters"
+
+	// Gateway API Gateway relationships.
+	RelationshipGatewayAPIGatewayClass               Relationship = "GatewayAPIGatewayClass"
+	RelationshipGatewayAPIGatewayListenerCertificate Relationship = "GatewayAPIGatewayListenerCertificate"
+
+	// Gateway API ReferenceGrant relationships.
+	RelationshipGatewayAPIReferenceGrantTo Relationship = "GatewayAPIReferenceGrantTo"
+
+	// Gateway API HTTPRoute, GRPCRoute, TLSRoute, TCPRoute & UDPRoute relationships.
+	RelationshipGatewayAPIRouteBackend Relationship = "GatewayAPIRouteBackend"
+	RelationshipGatewayAPIRouteParent  Relationship = "GatewayAPIRouteParent"
+)
+
+// getGatewayAPIGatewayClassRelationships returns a map of relationships that
+// this GatewayClass has with other objects, based on what was referenced in
+// its manifest.
+func getGatewayAPIGatewayClassRelationships(n *Node) (*RelationshipMap, error) {
+	result := newRelationshipMap()
+
+	// RelationshipGatewayAPIGatewayClassParameters
+	if params, found, _ := unstructuredv1.NestedMap(n.UnstructuredContent(), "spec", "parametersRef"); found {
+		if ref, ok := getGatewayAPIReference(params, "", "", ""); ok {
+			result.AddDependencyByKey(ref.Key(), RelationshipGatewayAPIGatewayClassParameters)
+		}
+	}
+
+	return &result, nil
+}
+
+// getGatewayAPIGatewayRelationships returns a map of relationships that this
+// Gateway has with other objects, based on what was referenced in its
+// manifest.
+func getGatewayAPIGatewayRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipGatewayAPIGatewayClass
+	if name := n.GetNestedString("spec", "gatewayClassName"); len(name) > 0 {
+		ref = ObjectReference{Group: GatewayAPIGroupName, Kind: "GatewayClass", Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipGatewayAPIGatewayClass)
+	}
+
+	// RelationshipGatewayAPIGatewayListenerCertificate
+	listeners, _, _ := unstructuredv1.NestedSlice(n.UnstructuredContent(), "spec", "listeners")
+	for _, l := range listeners {
+		listener, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		certRefs, _, _ := unstructuredv1.NestedSlice(listener, "tls", "certificateRefs")
+		for _, c := range certRefs {
+			certRef, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if ref, ok := getGatewayAPIReference(certRef, "", "Secret", n.Namespace); ok {
+				result.AddDependencyByGrantedKey(ref.Key(), RelationshipGatewayAPIGatewayListenerCertificate)
+			}
+		}
+	}
+
+	// RelationshipCertManagerIssuerAnnotation
+	if ref, ok := getCertManagerIssuerAnnotationReference(n); ok {
+		result.AddDependencyByKey(ref.Key(), RelationshipCertManagerIssuerAnnotation)
+	}
+
+	return &result, nil
+}
+
+// getGatewayAPIReferenceGrantRelationships returns a map of relationships that
+// this ReferenceGrant has with other objects, based on what was referenced in
+// its manifest.
+func getGatewayAPIReferenceGrantRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipGatewayAPIReferenceGrantTo
+	to, _, _ := unstructuredv1.NestedSlice(n.UnstructuredContent(), "spec", "to")
+	for _, t := range to {
+		target, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, _, _ := unstructuredv1.NestedString(target, "group")
+		kind, _, _ := unstructuredv1.NestedString(target, "kind")
+		name, _, _ := unstructuredv1.NestedString(target, "name")
+		switch {
+		case len(kind) == 0:
+			continue
+		case len(name) > 0:
+			ref = ObjectReference{Group: group, Kind: kind, Name: name, Namespace: n.Namespace}
+			result.AddDependencyByKey(ref.Key(), RelationshipGatewayAPIReferenceGrantTo)
+		default:
+			os := ObjectSelector{Group: group, Kind: kind, Namespaces: sets.NewString(n.Namespace)}
+			result.AddDependencyBySelector(os, RelationshipGatewayAPIReferenceGrantTo)
+		}
+	}
+
+	return &result, nil
+}
+
+// getGatewayAPIRouteRelationships returns a map of relationships that this
+// Gateway API route (eg. HTTPRoute, TCPRoute) has with other objects, based on
+// what was referenced in its manifest.
+func getGatewayAPIRouteRelationships(n *Node) (*RelationshipMap, error) {
+	result := newRelationshipMap()
+
+	// RelationshipGatewayAPIRouteParent
+	parentRefs, _, _ := unstructuredv1.NestedSlice(n.UnstructuredContent(), "spec", "parentRefs")
+	for _, p := range parentRefs {
+		parentRef, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ref, ok := getGatewayAPIReference(parentRef, GatewayAPIGroupName, "Gateway", n.Namespace); ok {
+			result.AddDependencyByKey(ref.Key(), RelationshipGatewayAPIRouteParent)
+		}
+	}
+
+	// RelationshipGatewayAPIRouteBackend
+	rules, _, _ := unstructuredv1.NestedSlice(n.UnstructuredContent(), "spec", "rules")
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		backendRefs, _, _ := unstructuredv1.NestedSlice(rule, "backendRefs")
+		for _, b := range backendRefs {
+			backendRef, ok := b.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if ref, ok := getGatewayAPIReference(backendRef, "", "Service", n.Namespace); ok {
+				result.AddDependencyByGrantedKey(ref.Key(), RelationshipGatewayAPIRouteBackend)
+			}
+		}
+	}
+
+	return &result, nil
+}
+
+// getGatewayAPIReference returns the object reference (with optional "group",
+// "kind" & "namespace" fields) in the provided Gateway API reference, using
+// the provided defaults for any field that isn't set.
+func getGatewayAPIReference(ref map[string]interface{}, defaultGroup, defaultKind, defaultNamespace string) (ObjectReference, bool) {
+	name, _, _ := unstructuredv1.NestedString(ref, "name")
+	if len(name) == 0 {
+		return ObjectReference{}, false
+	}
+	group, found, _ := unstructuredv1.NestedString(ref, "group")
+	if !found {
+		group = defaultGroup
+	}
+	kind, _, _ := unstructuredv1.NestedString(ref, "kind")
+	if len(kind) == 0 {
+		kind = defaultKind
+	}
+	if len(kind) == 0 {
+		return ObjectReference{}, false
+	}
+	ns, _, _ := unstructuredv1.NestedString(ref, "namespace")
+	if len(ns) == 0 {
+		ns = defaultNamespace
+	}
+	return ObjectReference{Group: group, Kind: kind, Name: name, Namespace: ns}, true
+}
+
+// referenceGrantAllows returns true if the provided ReferenceGrant allows the
+// provided object to reference the other provided object.
+func referenceGrantAllows(grant, from, to *Node) bool {
+	fromAllowed := false
+	fromList, _, _ := unstructuredv1.NestedSlice(grant.UnstructuredContent(), "spec", "from")
+	for _, f := range fromList {
+		peer, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, _, _ := unstructuredv1.NestedString(peer, "group")
+		kind, _, _ := unstructuredv1.NestedString(peer, "kind")
+		ns, _, _ := unstructuredv1.NestedString(peer, "namespace")
+		if group == from.Group && kind == from.Kind && ns == from.Namespace {
+			fromAllowed = true
+			break
+		}
+	}
+	if !fromAllowed {
+		return false
+	}
+
+	toList, _, _ := unstructuredv1.NestedSlice(grant.UnstructuredContent(), "spec", "to")
+	for _, t := range toList {
+		target, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, _, _ := unstructuredv1.NestedString(target, "group")
+		kind, _, _ := unstructuredv1.NestedString(target, "kind")
+		name, _, _ := unstructuredv1.NestedString(target, "name")
+		if group == to.Group && kind == to.Kind && (len(name) == 0 || name == to.Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,11 +2,17 @@ package graph
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	eventsv1 "k8s.io/api/events/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -14,12 +20,15 @@ import (
 	policyv1 "k8s.io/api/policy/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
@@ -37,31 +46,264 @@ type ObjectLabelSelector struct {
 	Kind      string
 	Namespace string
 	Selector  labels.Selector
+	// NamePattern further restricts the collection to objects with names
+	// matching the pattern. Matches any name if not set.
+	NamePattern *regexp.Regexp
+	// ExcludeNames further restricts the collection to objects with names not
+	// in the set.
+	ExcludeNames sets.String
+	// AllNamespaces extends the collection to objects in every namespace,
+	// ignoring Namespace.
+	AllNamespaces bool
 }
 
 // Key converts the ObjectLabelSelector into a ObjectLabelSelectorKey.
 func (o *ObjectLabelSelector) Key() ObjectLabelSelectorKey {
-	k := fmt.Sprintf("%s\\%s\\%s\\%s", o.Group, o.Kind, o.Namespace, o.Selector)
+	ns := o.Namespace
+	if o.AllNamespaces {
+		ns = "*"
+	}
+	k := fmt.Sprintf("%s\\%s\\%s\\%s", o.Group, o.Kind, ns, o.Selector)
+	if o.NamePattern != nil {
+		k += "\\" + o.NamePattern.String()
+	}
+	if len(o.ExcludeNames) > 0 {
+		k += "\\!" + strings.Join(o.ExcludeNames.List(), ",")
+	}
 	return ObjectLabelSelectorKey(k)
 }
 
+// MatchesName returns true if the provided object name is allowed by the
+// NamePattern & ExcludeNames of the ObjectLabelSelector.
+func (o *ObjectLabelSelector) MatchesName(name string) bool {
+	if o.NamePattern != nil && !o.NamePattern.MatchString(name) {
+		return false
+	}
+	return !o.ExcludeNames.Has(name)
+}
+
+// Matches returns true if the provided node is in the collection of objects
+// referenced by the ObjectLabelSelector.
+func (o *ObjectLabelSelector) Matches(n *Node) bool {
+	if n.Group != o.Group || n.Kind != o.Kind {
+		return false
+	}
+	if !o.AllNamespaces && n.Namespace != o.Namespace {
+		return false
+	}
+	if !o.MatchesName(n.Name) {
+		return false
+	}
+	return o.Selector.Matches(labels.Set(n.GetLabels()))
+}
+
 // ObjectSelectorKey is a compact representation of an ObjectSelector.
 // Typically used as key types for maps.
 type ObjectSelectorKey string
 
 // ObjectSelector is a reference to a collection of Kubernetes objects.
 type ObjectSelector struct {
-	Group      string
+	Group string
+	// Version of the API group, matches objects of every kind served at that
+	// version if set.
+	Version string
+	// Kind of the objects, matches objects of every kind in the group if not
+	// set.
 	Kind       string
 	Namespaces sets.String
 }
 
 // Key converts the ObjectSelector into a ObjectSelectorKey.
 func (o *ObjectSelector) Key() ObjectSelectorKey {
-	k := fmt.Sprintf("%s\\%s\\%s", o.Group, o.Kind, o.Namespaces)
+	k := fmt.Sprintf("%s\\%s\\%s\\%s", o.Group, o.Version, o.Kind, o.Namespaces)
 	return ObjectSelectorKey(k)
 }
 
+// ObjectAdmissionSelectorKey is a compact representation of an
+// ObjectAdmissionSelector. Typically used as key types for maps.
+type ObjectAdmissionSelectorKey string
+
+// ObjectAdmissionRule is a rule of an admission webhook or policy, matching
+// objects by their API group, resource, name & scope.
+type ObjectAdmissionRule struct {
+	APIGroups sets.String
+	// Resources of the objects, where "*" & "*/*" matches every resource.
+	// Subresources (eg. "pods/status") never match any object.
+	Resources sets.String
+	// ResourceNames restricts the rule to objects with the provided names.
+	// Matches any name if not set.
+	ResourceNames sets.String
+	// Scope restricts the rule to either "Cluster" or "Namespaced" objects.
+	// Matches objects of both scopes if not set or "*".
+	Scope string
+}
+
+// Matches returns true if the provided node matches the ObjectAdmissionRule.
+func (r *ObjectAdmissionRule) Matches(n *Node) bool {
+	if !r.APIGroups.HasAny("*", n.Group) {
+		return false
+	}
+	if !r.Resources.HasAny("*", "*/*", n.Resource) {
+		return false
+	}
+	if len(r.ResourceNames) > 0 && !r.ResourceNames.Has(n.Name) {
+		return false
+	}
+	switch r.Scope {
+	case "Cluster":
+		return len(n.Namespace) == 0
+	case "Namespaced":
+		return len(n.Namespace) > 0
+	}
+	return true
+}
+
+// ObjectAdmissionSelector is a reference to a collection of Kubernetes objects
+// intercepted by an admission webhook or policy.
+type ObjectAdmissionSelector struct {
+	// Rules matches objects matching any of the rules.
+	Rules []ObjectAdmissionRule
+	// ExcludeRules excludes objects matching any of the rules.
+	ExcludeRules []ObjectAdmissionRule
+	// NamespaceSelector restricts the collection to namespaced objects in
+	// namespaces with labels matching the selector (or Namespaces with labels
+	// matching the selector), ignoring other cluster-scoped objects. Matches any
+	// namespace if not set.
+	NamespaceSelector labels.Selector
+	// Selector restricts the collection to objects with labels matching the
+	// selector. Matches any object if not set.
+	Selector labels.Selector
+	// PolicyName restricts the collection to objects also matched by the
+	// ValidatingAdmissionPolicy with the name. Ignored if not set.
+	PolicyName string
+}
+
+// Key converts the ObjectAdmissionSelector into a ObjectAdmissionSelectorKey.
+func (o *ObjectAdmissionSelector) Key() ObjectAdmissionSelectorKey {
+	k := fmt.Sprintf("%v\\%v\\%s\\%s\\%s", o.Rules, o.ExcludeRules, o.NamespaceSelector, o.Selector, o.PolicyName)
+	return ObjectAdmissionSelectorKey(k)
+}
+
+// Matches returns true if the provided node is in the collection of objects
+// referenced by the ObjectAdmissionSelector, using the provided labels of the
+// node's namespace (nil if the namespace is unknown).
+func (o *ObjectAdmissionSelector) Matches(n *Node, namespaceLabels labels.Set) bool {
+	matchesRule := func(rules []ObjectAdmissionRule) bool {
+		for ix := range rules {
+			if rules[ix].Matches(n) {
+				return true
+			}
+		}
+		return false
+	}
+	if !matchesRule(o.Rules) || matchesRule(o.ExcludeRules) {
+		return false
+	}
+	if o.NamespaceSelector != nil && !o.NamespaceSelector.Empty() {
+		switch {
+		case n.Group == corev1.GroupName && n.Kind == "Namespace":
+			if !o.NamespaceSelector.Matches(labels.Set(n.GetLabels())) {
+				return false
+			}
+		case len(n.Namespace) > 0:
+			if namespaceLabels == nil || !o.NamespaceSelector.Matches(namespaceLabels) {
+				return false
+			}
+		}
+	}
+	return o.Selector == nil || o.Selector.Matches(labels.Set(n.GetLabels()))
+}
+
+// ObjectNamespaceSelectorKey is a compact representation of an
+// ObjectNamespaceSelector. Typically used as key types for maps.
+type ObjectNamespaceSelectorKey string
+
+// ObjectNamespaceSelector is a reference to a collection of Kubernetes objects
+// matching a label selector, across all namespaces with labels matching a
+// namespace label selector.
+type ObjectNamespaceSelector struct {
+	Group             string
+	Kind              string
+	Selector          labels.Selector
+	NamespaceSelector labels.Selector
+}
+
+// Key converts the ObjectNamespaceSelector into a ObjectNamespaceSelectorKey.
+func (o *ObjectNamespaceSelector) Key() ObjectNamespaceSelectorKey {
+	k := fmt.Sprintf("%s\\%s\\%s\\%s", o.Group, o.Kind, o.NamespaceSelector, o.Selector)
+	return ObjectNamespaceSelectorKey(k)
+}
+
+// ObjectPolicyRuleSelectorKey is a compact representation of an
+// ObjectPolicyRuleSelector. Typically used as key types for maps.
+type ObjectPolicyRuleSelectorKey string
+
+// ObjectPolicyRuleSelector is a reference to a collection of Kubernetes objects
+// covered by the API groups, resources & resource names of a RBAC policy rule.
+type ObjectPolicyRuleSelector struct {
+	APIGroups sets.String
+	Resources sets.String
+	// ResourceNames restricts the collection to objects with the provided
+	// names. Matches any name if not set.
+	ResourceNames sets.String
+	// Namespace restricts the collection to objects in the namespace. Matches
+	// objects in every namespace & cluster-scoped objects if not set.
+	Namespace string
+}
+
+// Key converts the ObjectPolicyRuleSelector into a ObjectPolicyRuleSelectorKey.
+func (o *ObjectPolicyRuleSelector) Key() ObjectPolicyRuleSelectorKey {
+	k := fmt.Sprintf("%s\\%s\\%s\\%s", o.APIGroups.List(), o.Resources.List(), o.Namespace, o.ResourceNames.List())
+	return ObjectPolicyRuleSelectorKey(k)
+}
+
+// Matches returns true if the provided node is in the collection of objects
+// referenced by the ObjectPolicyRuleSelector.
+func (o *ObjectPolicyRuleSelector) Matches(n *Node) bool {
+	if len(o.Namespace) > 0 && n.Namespace != o.Namespace {
+		return false
+	}
+	if len(o.ResourceNames) > 0 && !o.ResourceNames.Has(n.Name) {
+		return false
+	}
+	return o.APIGroups.HasAny(rbacv1.APIGroupAll, n.Group) && o.Resources.HasAny(rbacv1.ResourceAll, n.Resource)
+}
+
+// ObjectParamReferenceKey is a compact representation of an
+// ObjectParamReference. Typically used as key types for maps.
+type ObjectParamReferenceKey string
+
+// ObjectParamReference is a reference to the parameter objects of a
+// ValidatingAdmissionPolicy, which are of the policy's parameter kind.
+type ObjectParamReference struct {
+	PolicyName string
+	// Namespace restricts the references to objects in the namespace. Matches
+	// objects in any namespace if not set.
+	Namespace string
+	// Name restricts the references to the object with the name, otherwise
+	// objects are referenced by Selector.
+	Name     string
+	Selector labels.Selector
+}
+
+// Key converts the ObjectParamReference into a ObjectParamReferenceKey.
+func (o *ObjectParamReference) Key() ObjectParamReferenceKey {
+	k := fmt.Sprintf("%s\\%s\\%s\\%s", o.PolicyName, o.Namespace, o.Name, o.Selector)
+	return ObjectParamReferenceKey(k)
+}
+
+// Matches returns true if the provided node, which must be of the policy's
+// parameter kind, is referenced by the ObjectParamReference.
+func (o *ObjectParamReference) Matches(n *Node) bool {
+	if len(o.Namespace) > 0 && n.Namespace != o.Namespace {
+		return false
+	}
+	if len(o.Name) > 0 {
+		return n.Name == o.Name
+	}
+	return o.Selector != nil && o.Selector.Matches(labels.Set(n.GetLabels()))
+}
+
 // ObjectReferenceKey is a compact representation of an ObjectReference.
 // Typically used as key types for maps.
 type ObjectReferenceKey string
@@ -80,6 +322,16 @@ func (o *ObjectReference) Key() ObjectReferenceKey {
 	return ObjectReferenceKey(k)
 }
 
+// ObjectReference converts the ObjectReferenceKey back into an ObjectReference.
+func (k ObjectReferenceKey) ObjectReference() ObjectReference {
+	var ref ObjectReference
+	tokens := strings.SplitN(string(k), "\\", 4)
+	if len(tokens) == 4 {
+		ref = ObjectReference{Group: tokens[0], Kind: tokens[1], Namespace: tokens[2], Name: tokens[3]}
+	}
+	return ref
+}
+
 type sortableStringSlice []string
 
 func (s sortableStringSlice) Len() int           { return len(s) }
@@ -89,6 +341,23 @@ func (s sortableStringSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 // Relationship represents a relationship type between two Kubernetes objects.
 type Relationship string
 
+// withEdgeMetadata returns the provided relationship with the provided edge
+// metadata appended to it (eg. "RolePolicyRule(get,list)"), for relationships
+// whose edges need to be told apart from one another.
+func withEdgeMetadata(r Relationship, s string) Relationship {
+	return Relationship(fmt.Sprintf("%s(%s)", r, s))
+}
+
+// edgeRelationship returns the provided relationship without any edge
+// metadata appended to it by withEdgeMetadata.
+func edgeRelationship(r Relationship) Relationship {
+	s := string(r)
+	if i := strings.IndexByte(s, '('); i >= 0 && strings.HasSuffix(s, ")") {
+		return Relationship(s[:i])
+	}
+	return r
+}
+
 // RelationshipSet contains a set of relationships.
 type RelationshipSet map[Relationship]struct{}
 
@@ -105,33 +374,73 @@ func (s RelationshipSet) List() []string {
 // RelationshipMap contains a map of relationships a Kubernetes object has with
 // other objects in the cluster.
 type RelationshipMap struct {
+	// DependenciesByGrantedRef contains references to objects which are only
+	// resolved if they're in the same namespace as the object, or if a Gateway
+	// API ReferenceGrant in their namespace allows the reference.
+	DependenciesByGrantedRef    map[ObjectReferenceKey]RelationshipSet
 	DependenciesByLabelSelector map[ObjectLabelSelectorKey]RelationshipSet
-	DependenciesByRef           map[ObjectReferenceKey]RelationshipSet
-	DependenciesBySelector      map[ObjectSelectorKey]RelationshipSet
-	DependenciesByUID           map[types.UID]RelationshipSet
-	DependentsByLabelSelector   map[ObjectLabelSelectorKey]RelationshipSet
-	DependentsByRef             map[ObjectReferenceKey]RelationshipSet
-	DependentsBySelector        map[ObjectSelectorKey]RelationshipSet
-	DependentsByUID             map[types.UID]RelationshipSet
-	ObjectLabelSelectors        map[ObjectLabelSelectorKey]ObjectLabelSelector
-	ObjectSelectors             map[ObjectSelectorKey]ObjectSelector
+	// DependenciesByNamespaceSelector contains selectors of objects which are
+	// only resolved if their namespace's labels match the selector.
+	DependenciesByNamespaceSelector map[ObjectNamespaceSelectorKey]RelationshipSet
+	// DependenciesByParamRef contains references to the parameter objects of
+	// ValidatingAdmissionPolicies, which are only resolved to objects of the
+	// parameter kind of the referenced policy.
+	DependenciesByParamRef map[ObjectParamReferenceKey]RelationshipSet
+	// DependenciesByPolicyRule contains selectors of objects covered by RBAC
+	// policy rules.
+	DependenciesByPolicyRule map[ObjectPolicyRuleSelectorKey]RelationshipSet
+	DependenciesByRef        map[ObjectReferenceKey]RelationshipSet
+	DependenciesBySelector   map[ObjectSelectorKey]RelationshipSet
+	DependenciesByUID        map[types.UID]RelationshipSet
+	// DependentsByAdmissionSelector contains selectors of objects intercepted
+	// by admission webhooks & ValidatingAdmissionPolicies. The intercepted
+	// objects depend on the webhooks & policies, but aren't listed as their
+	// dependents so that their dependents don't include every intercepted object
+	// in the cluster.
+	DependentsByAdmissionSelector map[ObjectAdmissionSelectorKey]RelationshipSet
+	DependentsByLabelSelector     map[ObjectLabelSelectorKey]RelationshipSet
+	DependentsByRef               map[ObjectReferenceKey]RelationshipSet
+	DependentsBySelector          map[ObjectSelectorKey]RelationshipSet
+	DependentsByUID               map[types.UID]RelationshipSet
+	ObjectAdmissionSelectors      map[ObjectAdmissionSelectorKey]ObjectAdmissionSelector
+	ObjectLabelSelectors          map[ObjectLabelSelectorKey]ObjectLabelSelector
+	ObjectNamespaceSelectors      map[ObjectNamespaceSelectorKey]ObjectNamespaceSelector
+	ObjectParamReferences         map[ObjectParamReferenceKey]ObjectParamReference
+	ObjectPolicyRuleSelectors     map[ObjectPolicyRuleSelectorKey]ObjectPolicyRuleSelector
+	ObjectSelectors               map[ObjectSelectorKey]ObjectSelector
 }
 
 func newRelationshipMap() RelationshipMap {
 	return RelationshipMap{
-		DependenciesByLabelSelector: map[ObjectLabelSelectorKey]RelationshipSet{},
-		DependenciesByRef:           map[ObjectReferenceKey]RelationshipSet{},
-		DependenciesBySelector:      map[ObjectSelectorKey]RelationshipSet{},
-		DependenciesByUID:           map[types.UID]RelationshipSet{},
-		DependentsByLabelSelector:   map[ObjectLabelSelectorKey]RelationshipSet{},
-		DependentsByRef:             map[ObjectReferenceKey]RelationshipSet{},
-		DependentsBySelector:        map[ObjectSelectorKey]RelationshipSet{},
-		DependentsByUID:             map[types.UID]RelationshipSet{},
-		ObjectLabelSelectors:        map[ObjectLabelSelectorKey]ObjectLabelSelector{},
-		ObjectSelectors:             map[ObjectSelectorKey]ObjectSelector{},
+		DependenciesByGrantedRef:        map[ObjectReferenceKey]RelationshipSet{},
+		DependenciesByLabelSelector:     map[ObjectLabelSelectorKey]RelationshipSet{},
+		DependenciesByNamespaceSelector: map[ObjectNamespaceSelectorKey]RelationshipSet{},
+		DependenciesByParamRef:          map[ObjectParamReferenceKey]RelationshipSet{},
+		DependenciesByPolicyRule:        map[ObjectPolicyRuleSelectorKey]RelationshipSet{},
+		DependenciesByRef:               map[ObjectReferenceKey]RelationshipSet{},
+		DependenciesBySelector:          map[ObjectSelectorKey]RelationshipSet{},
+		DependenciesByUID:               map[types.UID]RelationshipSet{},
+		DependentsByAdmissionSelector:   map[ObjectAdmissionSelectorKey]RelationshipSet{},
+		DependentsByLabelSelector:       map[ObjectLabelSelectorKey]RelationshipSet{},
+		DependentsByRef:                 map[ObjectReferenceKey]RelationshipSet{},
+		DependentsBySelector:            map[ObjectSelectorKey]RelationshipSet{},
+		DependentsByUID:                 map[types.UID]RelationshipSet{},
+		ObjectAdmissionSelectors:        map[ObjectAdmissionSelectorKey]ObjectAdmissionSelector{},
+		ObjectLabelSelectors:            map[ObjectLabelSelectorKey]ObjectLabelSelector{},
+		ObjectNamespaceSelectors:        map[ObjectNamespaceSelectorKey]ObjectNamespaceSelector{},
+		ObjectParamReferences:           map[ObjectParamReferenceKey]ObjectParamReference{},
+		ObjectPolicyRuleSelectors:       map[ObjectPolicyRuleSelectorKey]ObjectPolicyRuleSelector{},
+		ObjectSelectors:                 map[ObjectSelectorKey]ObjectSelector{},
 	}
 }
 
+func (m *RelationshipMap) AddDependencyByGrantedKey(k ObjectReferenceKey, r Relationship) {
+	if _, ok := m.DependenciesByGrantedRef[k]; !ok {
+		m.DependenciesByGrantedRef[k] = RelationshipSet{}
+	}
+	m.DependenciesByGrantedRef[k][r] = struct{}{}
+}
+
 func (m *RelationshipMap) AddDependencyByKey(k ObjectReferenceKey, r Relationship) {
 	if _, ok := m.DependenciesByRef[k]; !ok {
 		m.DependenciesByRef[k] = RelationshipSet{}
@@ -148,6 +457,33 @@ func (m *RelationshipMap) AddDependencyByLabelSelector(o ObjectLabelSelector, r
 	m.ObjectLabelSelectors[k] = o
 }
 
+func (m *RelationshipMap) AddDependencyByNamespaceSelector(o ObjectNamespaceSelector, r Relationship) {
+	k := o.Key()
+	if _, ok := m.DependenciesByNamespaceSelector[k]; !ok {
+		m.DependenciesByNamespaceSelector[k] = RelationshipSet{}
+	}
+	m.DependenciesByNamespaceSelector[k][r] = struct{}{}
+	m.ObjectNamespaceSelectors[k] = o
+}
+
+func (m *RelationshipMap) AddDependencyByParamRef(o ObjectParamReference, r Relationship) {
+	k := o.Key()
+	if _, ok := m.DependenciesByParamRef[k]; !ok {
+		m.DependenciesByParamRef[k] = RelationshipSet{}
+	}
+	m.DependenciesByParamRef[k][r] = struct{}{}
+	m.ObjectParamReferences[k] = o
+}
+
+func (m *RelationshipMap) AddDependencyByPolicyRule(o ObjectPolicyRuleSelector, r Relationship) {
+	k := o.Key()
+	if _, ok := m.DependenciesByPolicyRule[k]; !ok {
+		m.DependenciesByPolicyRule[k] = RelationshipSet{}
+	}
+	m.DependenciesByPolicyRule[k][r] = struct{}{}
+	m.ObjectPolicyRuleSelectors[k] = o
+}
+
 func (m *RelationshipMap) AddDependencyBySelector(o ObjectSelector, r Relationship) {
 	k := o.Key()
 	if _, ok := m.DependenciesBySelector[k]; !ok {
@@ -164,6 +500,15 @@ func (m *RelationshipMap) AddDependencyByUID(uid types.UID, r Relationship) {
 	m.DependenciesByUID[uid][r] = struct{}{}
 }
 
+func (m *RelationshipMap) AddDependentByAdmissionSelector(o ObjectAdmissionSelector, r Relationship) {
+	k := o.Key()
+	if _, ok := m.DependentsByAdmissionSelector[k]; !ok {
+		m.DependentsByAdmissionSelector[k] = RelationshipSet{}
+	}
+	m.DependentsByAdmissionSelector[k][r] = struct{}{}
+	m.ObjectAdmissionSelectors[k] = o
+}
+
 func (m *RelationshipMap) AddDependentByKey(k ObjectReferenceKey, r Relationship) {
 	if _, ok := m.DependentsByRef[k]; !ok {
 		m.DependentsByRef[k] = RelationshipSet{}
@@ -199,6 +544,9 @@ func (m *RelationshipMap) AddDependentByUID(uid types.UID, r Relationship) {
 // Node represents a Kubernetes object in an relationship tree.
 type Node struct {
 	*unstructuredv1.Unstructured
+	// Cluster is the name of the cluster that the object belongs to. Only set
+	// when resolving relationships across multiple clusters.
+	Cluster         string
 	UID             types.UID
 	Group           string
 	Version         string
@@ -293,26 +641,89 @@ func (n NodeList) Swap(i, j int) {
 // NodeMap contains a relationship tree stored as a map of nodes.
 type NodeMap map[types.UID]*Node
 
+// RequiresFullObject returns true if resolving the relationships of objects
+// with the provided GroupKind requires more than their ObjectMeta (i.e. the
+// GroupKind has a registered relationship resolver which reads their fields).
+// Objects of all other GroupKinds are only related through owner references,
+// labels & names, so they can be fetched as metadata-only objects.
+func RequiresFullObject(gk schema.GroupKind) bool {
+	r, ok := relationshipResolvers[gk]
+	return ok && !r.metadataOnly
+}
+
+// ResolveOptions contains the options for resolving the relationships between
+// objects.
+type ResolveOptions struct {
+	// CertManagerNamespace is the namespace which cert-manager is installed
+	// in. Uses DefaultCertManagerNamespace if not set.
+	CertManagerNamespace string
+	// KEDANamespace is the namespace which KEDA is installed in. Uses
+	// DefaultKEDANamespace if not set.
+	KEDANamespace string
+	// LonghornNamespace is the namespace which Longhorn is installed in. Uses
+	// DefaultLonghornNamespace if not set.
+	LonghornNamespace string
+	// NamespaceContents determines whether to relate every namespaced object
+	// with the Namespace containing it.
+	NamespaceContents bool
+}
+
+// certManagerNamespace returns the namespace which cert-manager is
+// installed in.
+func (o ResolveOptions) certManagerNamespace() string {
+	if len(o.CertManagerNamespace) > 0 {
+		return o.CertManagerNamespace
+	}
+	return DefaultCertManagerNamespace
+}
+
+// kedaNamespace returns the namespace which KEDA is installed in.
+func (o ResolveOptions) kedaNamespace() string {
+	if len(o.KEDANamespace) > 0 {
+		return o.KEDANamespace
+	}
+	return DefaultKEDANamespace
+}
+
+// longhornNamespace returns the namespace which Longhorn is installed in.
+func (o ResolveOptions) longhornNamespace() string {
+	if len(o.LonghornNamespace) > 0 {
+		return o.LonghornNamespace
+	}
+	return DefaultLonghornNamespace
+}
+
 // ResolveDependencies resolves all dependencies of the provided objects and
 // returns a relationship tree.
-func ResolveDependencies(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID) (NodeMap, error) {
-	return resolveDeps(m, objects, uids, true)
+func ResolveDependencies(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID, opts ResolveOptions) (NodeMap, error) {
+	return resolveDeps(m, objects, uids, true, opts)
 }
 
 // ResolveDependents resolves all dependents of the provided objects and returns
 // a relationship tree.
-func ResolveDependents(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID) (NodeMap, error) {
-	return resolveDeps(m, objects, uids, false)
+func ResolveDependents(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID, opts ResolveOptions) (NodeMap, error) {
+	return resolveDeps(m, objects, uids, false, opts)
 }
 
 // resolveDeps resolves all dependencies or dependents of the provided objects
 // and returns a relationship tree.
-//
-//nolint:funlen,gocognit,gocyclo
-func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID, depsIsDependencies bool) (NodeMap, error) {
+func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids []types.UID, depsIsDependencies bool, opts ResolveOptions) (NodeMap, error) {
 	if len(uids) == 0 {
 		return NodeMap{}, nil
 	}
+	globalMapByUID, err := resolveRelationships(m, objects, opts)
+	if err != nil {
+		return nil, err
+	}
+	return getSubtree(globalMapByUID, uids, depsIsDependencies), nil
+}
+
+// resolveRelationships creates nodes for all of the provided objects & resolves
+// the relationships between them. Returns a map of all nodes mapped by their
+// UIDs.
+//
+//nolint:funlen,gocognit,gocyclo
+func resolveRelationships(m meta.RESTMapper, objects []unstructuredv1.Unstructured, opts ResolveOptions) (map[types.UID]*Node, error) {
 	// Create global node maps of all objects, one mapped by node UIDs & the other
 	// mapped by node keys. This step also helps deduplicate the list of provided
 	// objects
@@ -361,21 +772,157 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 		}
 	}
 
+	referenceGrantsByNamespace := map[string][]*Node{}
+	for uid, n := range globalMapByUID {
+		if uid == n.UID && n.Group == GatewayAPIGroupName && n.Kind == "ReferenceGrant" {
+			referenceGrantsByNamespace[n.Namespace] = append(referenceGrantsByNamespace[n.Namespace], n)
+		}
+	}
+	isReferenceGranted := func(from, to *Node) bool {
+		if from.Namespace == to.Namespace {
+			return true
+		}
+		for _, grant := range referenceGrantsByNamespace[to.Namespace] {
+			if referenceGrantAllows(grant, from, to) {
+				return true
+			}
+		}
+		return false
+	}
+	// References without an API group to objects of kinds not served by the
+	// core group (ie. third-party kinds) are resolved to objects of the kind in
+	// the group found by the RESTMapper, or to objects of the kind in any group
+	// if the group is ambiguous
+	globalMapByKindKey := map[ObjectReferenceKey][]*Node{}
+	for uid, n := range globalMapByUID {
+		if uid == n.UID && len(n.Group) > 0 {
+			ref := ObjectReference{Kind: n.Kind, Namespace: n.Namespace, Name: n.Name}
+			globalMapByKindKey[ref.Key()] = append(globalMapByKindKey[ref.Key()], n)
+		}
+	}
+	kindGroups := map[string][]string{}
+	resolveReferenceToNodes := func(k ObjectReferenceKey) []*Node {
+		if n, ok := globalMapByKey[k]; ok {
+			return []*Node{n}
+		}
+		ref := k.ObjectReference()
+		if len(ref.Group) > 0 {
+			return nil
+		}
+		groups, ok := kindGroups[ref.Kind]
+		if !ok {
+			groups = getKindGroups(m, ref.Kind)
+			kindGroups[ref.Kind] = groups
+		}
+		switch {
+		case groups == nil:
+			return nil
+		case len(groups) == 1:
+			ref.Group = groups[0]
+			if n, ok := globalMapByKey[ref.Key()]; ok {
+				return []*Node{n}
+			}
+			return nil
+		}
+		return globalMapByKindKey[k]
+	}
+	namespaceLabels := map[string]labels.Set{}
+	admissionPolicyParamKinds := map[string]schema.GroupKind{}
+	admissionPolicyMatches := map[string]ObjectAdmissionSelector{}
+	for uid, n := range globalMapByUID {
+		switch {
+		case uid != n.UID:
+			continue
+		case n.Group == corev1.GroupName && n.Kind == "Namespace":
+			namespaceLabels[n.Name] = labels.Set{}
+			for k, v := range n.GetLabels() {
+				namespaceLabels[n.Name][k] = v
+			}
+		case n.Group == admissionregistrationv1.GroupName && n.Kind == "ValidatingAdmissionPolicy":
+			if gk, ok := getValidatingAdmissionPolicyParamKind(n); ok {
+				admissionPolicyParamKinds[n.Name] = gk
+			}
+			if oas, ok, err := getAdmissionPolicyMatchResources(n, "spec", "matchConstraints"); err != nil {
+				klog.V(4).Infof("Failed to get match constraints for ValidatingAdmissionPolicy named \"%s\": %s", n.Name, err)
+			} else if ok {
+				admissionPolicyMatches[n.Name] = oas
+			}
+		}
+	}
+	resolveAdmissionSelectorToNodes := func(o ObjectAdmissionSelector) []*Node {
+		var result []*Node
+		for uid, n := range globalMapByUID {
+			if uid != n.UID {
+				continue
+			}
+			if !o.Matches(n, namespaceLabels[n.Namespace]) {
+				continue
+			}
+			if len(o.PolicyName) > 0 {
+				policy, ok := admissionPolicyMatches[o.PolicyName]
+				if !ok || !policy.Matches(n, namespaceLabels[n.Namespace]) {
+					continue
+				}
+			}
+			result = append(result, n)
+		}
+		return result
+	}
+	resolveParamRefToNodes := func(o ObjectParamReference) []*Node {
+		gk, ok := admissionPolicyParamKinds[o.PolicyName]
+		if !ok {
+			return nil
+		}
+		var result []*Node
+		for uid, n := range globalMapByUID {
+			if uid != n.UID || n.Group != gk.Group || n.Kind != gk.Kind {
+				continue
+			}
+			if o.Matches(n) {
+				result = append(result, n)
+			}
+		}
+		return result
+	}
 	resolveLabelSelectorToNodes := func(o ObjectLabelSelector) []*Node {
 		var result []*Node
 		for _, n := range globalMapByUID {
-			if n.Group == o.Group && n.Kind == o.Kind && n.Namespace == o.Namespace {
-				if ok := o.Selector.Matches(labels.Set(n.GetLabels())); ok {
-					result = append(result, n)
+			if o.Matches(n) {
+				result = append(result, n)
+			}
+		}
+		return result
+	}
+	resolveNamespaceSelectorToNodes := func(o ObjectNamespaceSelector) []*Node {
+		nsSet := map[string]struct{}{}
+		for uid, n := range globalMapByUID {
+			if uid == n.UID && n.Group == corev1.GroupName && n.Kind == "Namespace" {
+				if o.NamespaceSelector.Matches(labels.Set(n.GetLabels())) {
+					nsSet[n.Name] = struct{}{}
 				}
 			}
 		}
+		var result []*Node
+		for _, n := range globalMapByUID {
+			if _, ok := nsSet[n.Namespace]; !ok {
+				continue
+			}
+			if n.Group == o.Group && n.Kind == o.Kind && o.Selector.Matches(labels.Set(n.GetLabels())) {
+				result = append(result, n)
+			}
+		}
 		return result
 	}
 	resolveSelectorToNodes := func(o ObjectSelector) []*Node {
 		var result []*Node
 		for _, n := range globalMapByUID {
-			if n.Group == o.Group && n.Kind == o.Kind {
+			if n.Group == o.Group && (len(o.Kind) == 0 || n.Kind == o.Kind) {
+				if len(o.Version) > 0 {
+					gk := schema.GroupKind{Group: n.Group, Kind: n.Kind}
+					if _, err := m.RESTMapping(gk, o.Version); err != nil {
+						continue
+					}
+				}
 				if len(o.Namespaces) == 0 || o.Namespaces.Has(n.Namespace) {
 					result = append(result, n)
 				}
@@ -383,9 +930,29 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 		}
 		return result
 	}
+	resolvePolicyRuleToNodes := func(o ObjectPolicyRuleSelector) []*Node {
+		var result []*Node
+		for _, n := range globalMapByUID {
+			if o.Matches(n) {
+				result = append(result, n)
+			}
+		}
+		return result
+	}
 	updateRelationships := func(node *Node, rmap *RelationshipMap) {
+		for k, rset := range rmap.DependenciesByGrantedRef {
+			for _, n := range resolveReferenceToNodes(k) {
+				if !isReferenceGranted(node, n) {
+					continue
+				}
+				for r := range rset {
+					node.AddDependency(n.UID, r)
+					n.AddDependent(node.UID, r)
+				}
+			}
+		}
 		for k, rset := range rmap.DependenciesByRef {
-			if n, ok := globalMapByKey[k]; ok {
+			for _, n := range resolveReferenceToNodes(k) {
 				for r := range rset {
 					node.AddDependency(n.UID, r)
 					n.AddDependent(node.UID, r)
@@ -393,7 +960,7 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 			}
 		}
 		for k, rset := range rmap.DependentsByRef {
-			if n, ok := globalMapByKey[k]; ok {
+			for _, n := range resolveReferenceToNodes(k) {
 				for r := range rset {
 					n.AddDependency(node.UID, r)
 					node.AddDependent(n.UID, r)
@@ -410,6 +977,15 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 				}
 			}
 		}
+		for k, rset := range rmap.DependentsByAdmissionSelector {
+			if oas, ok := rmap.ObjectAdmissionSelectors[k]; ok {
+				for _, n := range resolveAdmissionSelectorToNodes(oas) {
+					for r := range rset {
+						n.AddDependency(node.UID, r)
+					}
+				}
+			}
+		}
 		for k, rset := range rmap.DependentsByLabelSelector {
 			if ols, ok := rmap.ObjectLabelSelectors[k]; ok {
 				for _, n := range resolveLabelSelectorToNodes(ols) {
@@ -420,6 +996,36 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 				}
 			}
 		}
+		for k, rset := range rmap.DependenciesByNamespaceSelector {
+			if ons, ok := rmap.ObjectNamespaceSelectors[k]; ok {
+				for _, n := range resolveNamespaceSelectorToNodes(ons) {
+					for r := range rset {
+						node.AddDependency(n.UID, r)
+						n.AddDependent(node.UID, r)
+					}
+				}
+			}
+		}
+		for k, rset := range rmap.DependenciesByParamRef {
+			if opr, ok := rmap.ObjectParamReferences[k]; ok {
+				for _, n := range resolveParamRefToNodes(opr) {
+					for r := range rset {
+						node.AddDependency(n.UID, r)
+						n.AddDependent(node.UID, r)
+					}
+				}
+			}
+		}
+		for k, rset := range rmap.DependenciesByPolicyRule {
+			if ops, ok := rmap.ObjectPolicyRuleSelectors[k]; ok {
+				for _, n := range resolvePolicyRuleToNodes(ops) {
+					for r := range rset {
+						node.AddDependency(n.UID, r)
+						n.AddDependent(node.UID, r)
+					}
+				}
+			}
+		}
 		for k, rset := range rmap.DependenciesBySelector {
 			if os, ok := rmap.ObjectSelectors[k]; ok {
 				for _, n := range resolveSelectorToNodes(os) {
@@ -472,207 +1078,69 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 		}
 	}
 
-	var rmap *RelationshipMap
-	var err error
-	for _, node := range globalMapByUID {
-		switch {
-		// Populate dependencies & dependents based on PersistentVolume relationships
-		case node.Group == corev1.GroupName && node.Kind == "PersistentVolume":
-			rmap, err = getPersistentVolumeRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for persistentvolume named \"%s\": %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on PersistentVolumeClaim relationships
-		case node.Group == corev1.GroupName && node.Kind == "PersistentVolumeClaim":
-			rmap, err = getPersistentVolumeClaimRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for persistentvolumeclaim named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
+	// Populate dependencies & dependents based on Namespace-Content relationships
+	if opts.NamespaceContents {
+		for _, node := range globalMapByUID {
+			if !node.Namespaced {
 				continue
 			}
-			// Populate dependencies & dependents based on Volume relationships
-		case node.Group == longhorn.GroupName && node.Kind == "Volume":
-			rmap, err = getLonghornVolumeRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for longhorn volume named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
-				continue
-			}
-			// Populate dependencies & dependents based on Replicas relationships
-		case node.Group == longhorn.GroupName && node.Kind == "Replica":
-			rmap, err = getLonghornReplicaRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for longhorn replica named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
-				continue
-			}
-			// Populate dependencies & dependents based on VM relationships
-		case node.Group == kubevirt.GroupName && node.Kind == "VirtualMachine":
-			rmap, err = getVMRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for vm named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
-				continue
-			}
-			// Populate dependencies & dependents based on VMI relationships
-		case node.Group == kubevirt.GroupName && node.Kind == "VirtualMachineInstance":
-			rmap, err = getVMIRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for vmi named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
-				continue
-			}
-		// Populate dependencies & dependents based on Pod relationships
-		case node.Group == corev1.GroupName && node.Kind == "Pod":
-			rmap, err = getPodRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for pod named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
-				continue
-			}
-		// Populate dependencies & dependents based on Service relationships
-		case node.Group == corev1.GroupName && node.Kind == "Service":
-			rmap, err = getServiceRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for service named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
-				continue
-			}
-		// Populate dependencies & dependents based on ServiceAccount relationships
-		case node.Group == corev1.GroupName && node.Kind == "ServiceAccount":
-			rmap, err = getServiceAccountRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for serviceaccount named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
-				continue
-			}
-		// Populate dependencies & dependents based on PodSecurityPolicy relationships
-		case node.Group == policyv1beta1.GroupName && node.Kind == "PodSecurityPolicy":
-			rmap, err = getPodSecurityPolicyRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for podsecuritypolicy named \"%s\": %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on PodDisruptionBudget relationships
-		case node.Group == policyv1.GroupName && node.Kind == "PodDisruptionBudget":
-			rmap, err = getPodDisruptionBudgetRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for poddisruptionbudget named \"%s\": %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on MutatingWebhookConfiguration relationships
-		case node.Group == admissionregistrationv1.GroupName && node.Kind == "MutatingWebhookConfiguration":
-			rmap, err = getMutatingWebhookConfigurationRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for mutatingwebhookconfiguration named \"%s\": %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on ValidatingWebhookConfiguration relationships
-		case node.Group == admissionregistrationv1.GroupName && node.Kind == "ValidatingWebhookConfiguration":
-			rmap, err = getValidatingWebhookConfigurationRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for validatingwebhookconfiguration named \"%s\": %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on APIService relationships
-		case node.Group == apiregistrationv1.GroupName && node.Kind == "APIService":
-			rmap, err = getAPIServiceRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for apiservice named \"%s\": %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on Event relationships
-		case (node.Group == eventsv1.GroupName || node.Group == corev1.GroupName) && node.Kind == "Event":
-			rmap, err = getEventRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for event named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
-				continue
-			}
-		// Populate dependencies & dependents based on Ingress relationships
-		case (node.Group == networkingv1.GroupName || node.Group == extensionsv1beta1.GroupName) && node.Kind == "Ingress":
-			rmap, err = getIngressRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for ingress named \"%s\" in namespace \"%s\": %s", node.Name, node.Namespace, err)
-				continue
-			}
-		// Populate dependencies & dependents based on IngressClass relationships
-		case node.Group == networkingv1.GroupName && node.Kind == "IngressClass":
-			rmap, err = getIngressClassRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for ingressclass named \"%s\": %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on NetworkPolicy relationships
-		case node.Group == networkingv1.GroupName && node.Kind == "NetworkPolicy":
-			rmap, err = getNetworkPolicyRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for networkpolicy named \"%s\": %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on RuntimeClass relationships
-		case node.Group == nodev1.GroupName && node.Kind == "RuntimeClass":
-			rmap, err = getRuntimeClassRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for runtimeclass named \"%s\": %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on ClusterRole relationships
-		case node.Group == rbacv1.GroupName && node.Kind == "ClusterRole":
-			rmap, err = getClusterRoleRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for clusterrole named \"%s\": %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on ClusterRoleBinding relationships
-		case node.Group == rbacv1.GroupName && node.Kind == "ClusterRoleBinding":
-			rmap, err = getClusterRoleBindingRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for clusterrolebinding named \"%s\": %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on Role relationships
-		case node.Group == rbacv1.GroupName && node.Kind == "Role":
-			rmap, err = getRoleRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for role named \"%s\" in namespace \"%s\": %s: %s", node.Name, node.Namespace, err)
-				continue
-			}
-		// Populate dependencies & dependents based on RoleBinding relationships
-		case node.Group == rbacv1.GroupName && node.Kind == "RoleBinding":
-			rmap, err = getRoleBindingRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for rolebinding named \"%s\" in namespace \"%s\": %s: %s", node.Name, node.Namespace, err)
-				continue
+			ref := ObjectReference{Kind: "Namespace", Name: node.Namespace}
+			if n, ok := globalMapByKey[ref.Key()]; ok {
+				node.AddDependency(n.UID, RelationshipNamespaceContent)
+				n.AddDependent(node.UID, RelationshipNamespaceContent)
 			}
-		// Populate dependencies & dependents based on CSIStorageCapacity relationships
-		case node.Group == storagev1beta1.GroupName && node.Kind == "CSIStorageCapacity":
-			rmap, err = getCSIStorageCapacityRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for csistoragecapacity named \"%s\": %s: %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on CSINode relationships
-		case node.Group == storagev1.GroupName && node.Kind == "CSINode":
-			rmap, err = getCSINodeRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for csinode named \"%s\": %s: %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on StorageClass relationships
-		case node.Group == storagev1.GroupName && node.Kind == "StorageClass":
-			rmap, err = getStorageClassRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for storageclass named \"%s\": %s: %s", node.Name, err)
-				continue
-			}
-		// Populate dependencies & dependents based on VolumeAttachment relationships
-		case node.Group == storagev1.GroupName && node.Kind == "VolumeAttachment":
-			rmap, err = getVolumeAttachmentRelationships(node)
-			if err != nil {
-				klog.V(4).Infof("Failed to get relationships for volumeattachment named \"%s\": %s: %s", node.Name, err)
-				continue
+		}
+	}
+
+	for _, node := range globalMapByUID {
+		rmap, err := getRelationships(node, opts)
+		if err != nil {
+			if node.Namespaced {
+				klog.V(4).Infof("Failed to get relationships for %s named \"%s\" in namespace \"%s\": %s", strings.ToLower(node.Kind), node.Name, node.Namespace, err)
+			} else {
+				klog.V(4).Infof("Failed to get relationships for %s named \"%s\": %s", strings.ToLower(node.Kind), node.Name, err)
 			}
-		default:
 			continue
 		}
-		updateRelationships(node, rmap)
+		if rmap != nil {
+			updateRelationships(node, rmap)
+		}
 	}
 
-	// Create submap containing the provided objects & either their dependencies
-	// or dependents from the global map
+	return globalMapByUID, nil
+}
+
+// getKindGroups returns the API groups serving the provided kind, as known by
+// the provided RESTMapper. Returns nil if the kind is served by the core group
+// (ie. references to objects of the kind without an API group can only refer to
+// core objects), or an empty list if the kind is unknown.
+func getKindGroups(m meta.RESTMapper, kind string) []string {
+	if len(kind) == 0 {
+		return nil
+	}
+	if _, err := m.RESTMapping(schema.GroupKind{Kind: kind}); err == nil {
+		return nil
+	}
+	gvr, _ := meta.UnsafeGuessKindToResource(schema.GroupVersionKind{Kind: kind})
+	gvks, err := m.KindsFor(schema.GroupVersionResource{Resource: gvr.Resource})
+	if err != nil {
+		klog.V(4).Infof("Failed to find groups of kind \"%s\": %s", kind, err)
+	}
+	groups := sets.NewString()
+	for _, gvk := range gvks {
+		if gvk.Kind == kind {
+			groups.Insert(gvk.Group)
+		}
+	}
+	if groups.Has(corev1.GroupName) {
+		return nil
+	}
+	return groups.List()
+}
+
+// getSubtree creates a submap containing the provided objects & either their
+// dependencies or dependents from the provided global map.
+func getSubtree(globalMapByUID map[types.UID]*Node, uids []types.UID, depsIsDependencies bool) NodeMap {
 	var depth uint
 	nodeMap, uidQueue, uidSet := NodeMap{}, []types.UID{}, map[types.UID]struct{}{}
 	for _, uid := range uids {
@@ -719,5 +1187,670 @@ func resolveDeps(m meta.RESTMapper, objects []unstructuredv1.Unstructured, uids
 	}
 
 	klog.V(4).Infof("Resolved %d deps for %d objects", len(nodeMap)-1, len(uids))
-	return nodeMap, nil
+	return nodeMap
+}
+
+// anyGroupKind matches objects of every GroupKind when declared as a target of
+// a relationshipResolver.
+var anyGroupKind = schema.GroupKind{Kind: "*"}
+
+// relationshipResolver resolves the relationships of objects of a GroupKind.
+type relationshipResolver struct {
+	resolve func(n *Node, opts ResolveOptions) (*RelationshipMap, error)
+	// dependencies & dependents contain the GroupKinds of objects which the
+	// resolver may reference as dependencies & dependents of the object. The
+	// fetcher relies on them to find the objects that may reference a node.
+	dependencies []schema.GroupKind
+	dependents   []schema.GroupKind
+	// required contains the GroupKinds of objects which must be fetched to
+	// resolve the relationships (eg. Namespaces for namespace selectors, or
+	// ValidatingAdmissionPolicies for the parameter references of bindings).
+	required []schema.GroupKind
+	// metadataOnly is set if the resolver only reads the object's ObjectMeta.
+	metadataOnly bool
+}
+
+// withoutOptions adapts a relationship resolver function which doesn't
+// depend on any ResolveOptions.
+func withoutOptions(fn func(n *Node) (*RelationshipMap, error)) func(*Node, ResolveOptions) (*RelationshipMap, error) {
+	return func(n *Node, _ ResolveOptions) (*RelationshipMap, error) {
+		return fn(n)
+	}
+}
+
+// podSpecDependencies contains the GroupKinds of objects referenced by pod
+// specs & pod templates.
+var podSpecDependencies = []schema.GroupKind{
+	{Kind: "ConfigMap"},
+	{Kind: "PersistentVolumeClaim"},
+	{Kind: "Secret"},
+	{Kind: "ServiceAccount"},
+	{Group: nodev1.GroupName, Kind: "RuntimeClass"},
+	{Group: schedulingv1.GroupName, Kind: "PriorityClass"},
+	{Group: storagev1.GroupName, Kind: "CSIDriver"},
+}
+
+// vmiSpecDependents contains the GroupKinds of objects referenced by
+// KubeVirt VirtualMachineInstance specs & templates.
+var vmiSpecDependents = []schema.GroupKind{
+	{Kind: "ConfigMap"},
+	{Kind: "PersistentVolumeClaim"},
+	{Kind: "Secret"},
+	{Kind: "ServiceAccount"},
+	{Group: KubeVirtCDIGroupName, Kind: "DataVolume"},
+	{Group: MultusGroupName, Kind: "NetworkAttachmentDefinition"},
+}
+
+// admissionWebhookResolver returns the relationship resolver of admission
+// webhook configurations using the provided resolver function.
+func admissionWebhookResolver(fn func(n *Node) (*RelationshipMap, error)) relationshipResolver {
+	return relationshipResolver{
+		resolve:      withoutOptions(fn),
+		dependencies: []schema.GroupKind{{Kind: "Service"}},
+		dependents:   []schema.GroupKind{anyGroupKind},
+		required:     []schema.GroupKind{{Kind: "Namespace"}},
+	}
+}
+
+// prometheusOperatorMonitorResolver returns the relationship resolver of
+// Prometheus Operator monitors selecting objects of the provided kind.
+func prometheusOperatorMonitorResolver(kind string) relationshipResolver {
+	return relationshipResolver{
+		resolve:      withoutOptions(getPrometheusOperatorMonitorRelationships),
+		dependencies: []schema.GroupKind{{Kind: "ConfigMap"}, {Kind: kind}, {Kind: "Secret"}},
+	}
+}
+
+// workloadTemplateResolver returns the relationship resolver of workload
+// controllers with a pod template at the provided fields.
+func workloadTemplateResolver(fields ...string) relationshipResolver {
+	return relationshipResolver{
+		resolve: func(n *Node, _ ResolveOptions) (*RelationshipMap, error) {
+			return getWorkloadTemplateRelationships(n, fields...)
+		},
+		dependencies: podSpecDependencies,
+	}
+}
+
+var (
+	certManagerIssuerResolver = relationshipResolver{
+		resolve: func(n *Node, opts ResolveOptions) (*RelationshipMap, error) {
+			return getCertManagerIssuerRelationships(n, opts.certManagerNamespace())
+		},
+		dependencies: []schema.GroupKind{{Kind: "Secret"}},
+	}
+	certManagerIssuerReferenceResolver = relationshipResolver{
+		resolve:      withoutOptions(getCertManagerIssuerReferenceRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	}
+	eventResolver = relationshipResolver{
+		resolve:      withoutOptions(getEventRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	}
+	gatewayAPIRouteResolver = relationshipResolver{
+		resolve:      withoutOptions(getGatewayAPIRouteRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	}
+	ingressResolver = relationshipResolver{
+		resolve:      withoutOptions(getIngressRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	}
+	kedaTriggerAuthenticationResolver = relationshipResolver{
+		resolve: func(n *Node, opts ResolveOptions) (*RelationshipMap, error) {
+			return getKEDATriggerAuthenticationRelationships(n, opts.kedaNamespace())
+		},
+		dependencies: []schema.GroupKind{{Kind: "ConfigMap"}, {Kind: "Secret"}},
+	}
+)
+
+// relationshipResolvers contains the relationship resolver of every GroupKind
+// whose objects reference other objects in their manifests.
+var relationshipResolvers = map[schema.GroupKind]relationshipResolver{
+	{Group: admissionregistrationv1.GroupName, Kind: "MutatingWebhookConfiguration"}: admissionWebhookResolver(getMutatingWebhookConfigurationRelationships),
+	{Group: admissionregistrationv1.GroupName, Kind: "ValidatingAdmissionPolicy"}: {
+		resolve:    withoutOptions(getValidatingAdmissionPolicyRelationships),
+		dependents: []schema.GroupKind{anyGroupKind},
+		required:   []schema.GroupKind{{Kind: "Namespace"}},
+	},
+	{Group: admissionregistrationv1.GroupName, Kind: "ValidatingAdmissionPolicyBinding"}: {
+		resolve:      withoutOptions(getValidatingAdmissionPolicyBindingRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+		dependents:   []schema.GroupKind{anyGroupKind},
+		required: []schema.GroupKind{
+			{Kind: "Namespace"},
+			{Group: admissionregistrationv1.GroupName, Kind: "ValidatingAdmissionPolicy"},
+		},
+	},
+	{Group: admissionregistrationv1.GroupName, Kind: "ValidatingWebhookConfiguration"}: admissionWebhookResolver(getValidatingWebhookConfigurationRelationships),
+	{Group: apiextensionsv1.GroupName, Kind: "CustomResourceDefinition"}: {
+		resolve:      withoutOptions(getCustomResourceDefinitionRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Service"}},
+		dependents:   []schema.GroupKind{anyGroupKind},
+	},
+	{Group: apiregistrationv1.GroupName, Kind: "APIService"}: {
+		resolve:      withoutOptions(getAPIServiceRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Service"}},
+		dependents:   []schema.GroupKind{anyGroupKind},
+	},
+	{Group: appsv1.GroupName, Kind: "DaemonSet"}:  workloadTemplateResolver("spec", "template"),
+	{Group: appsv1.GroupName, Kind: "Deployment"}: workloadTemplateResolver("spec", "template"),
+	{Group: appsv1.GroupName, Kind: "ReplicaSet"}: workloadTemplateResolver("spec", "template"),
+	{Group: appsv1.GroupName, Kind: "StatefulSet"}: {
+		resolve: withoutOptions(getStatefulSetRelationships),
+		dependencies: []schema.GroupKind{
+			{Kind: "ConfigMap"},
+			{Kind: "PersistentVolumeClaim"},
+			{Kind: "Secret"},
+			{Kind: "Service"},
+			{Kind: "ServiceAccount"},
+			{Group: nodev1.GroupName, Kind: "RuntimeClass"},
+			{Group: schedulingv1.GroupName, Kind: "PriorityClass"},
+			{Group: storagev1.GroupName, Kind: "CSIDriver"},
+		},
+		dependents: []schema.GroupKind{{Kind: "PersistentVolumeClaim"}},
+	},
+	{Group: autoscalingv2.GroupName, Kind: "HorizontalPodAutoscaler"}: {
+		resolve:      withoutOptions(getHorizontalPodAutoscalerRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	},
+	{Group: batchv1.GroupName, Kind: "CronJob"}:          workloadTemplateResolver("spec", "jobTemplate", "spec", "template"),
+	{Group: batchv1.GroupName, Kind: "Job"}:              workloadTemplateResolver("spec", "template"),
+	{Group: CertManagerACMEGroupName, Kind: "Challenge"}: certManagerIssuerReferenceResolver,
+	{Group: CertManagerACMEGroupName, Kind: "Order"}:     certManagerIssuerReferenceResolver,
+	{Group: CertManagerGroupName, Kind: "Certificate"}: {
+		resolve:      withoutOptions(getCertManagerCertificateRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+		dependents:   []schema.GroupKind{{Kind: "Secret"}},
+	},
+	{Group: CertManagerGroupName, Kind: "CertificateRequest"}: certManagerIssuerReferenceResolver,
+	{Group: CertManagerGroupName, Kind: "ClusterIssuer"}:      certManagerIssuerResolver,
+	{Group: CertManagerGroupName, Kind: "Issuer"}:             certManagerIssuerResolver,
+	{Group: ClusterAPIGroupName, Kind: "Cluster"}: {
+		resolve:    withoutOptions(getClusterAPIClusterRelationships),
+		dependents: []schema.GroupKind{anyGroupKind},
+	},
+	{Group: ClusterAPIGroupName, Kind: "Machine"}: {
+		resolve:      withoutOptions(getClusterAPIMachineRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Secret"}, {Group: ClusterAPIGroupName, Kind: "Cluster"}},
+		dependents:   []schema.GroupKind{anyGroupKind},
+	},
+	{Group: corev1.GroupName, Kind: "Endpoints"}: {
+		resolve:      withoutOptions(getEndpointsRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Service"}},
+		dependents:   []schema.GroupKind{{Kind: "Pod"}, {Group: kubevirt.GroupName, Kind: "VirtualMachineInstance"}},
+	},
+	{Group: corev1.GroupName, Kind: "Event"}: eventResolver,
+	{Group: corev1.GroupName, Kind: "Namespace"}: {
+		resolve: withoutOptions(getNamespaceRelationships),
+		dependents: []schema.GroupKind{
+			{Kind: "LimitRange"},
+			{Kind: "ResourceQuota"},
+			{Kind: "ServiceAccount"},
+			{Group: rbacv1.GroupName, Kind: "RoleBinding"},
+		},
+	},
+	{Group: corev1.GroupName, Kind: "PersistentVolume"}: {
+		resolve: func(n *Node, opts ResolveOptions) (*RelationshipMap, error) {
+			return getPersistentVolumeRelationships(n, opts.longhornNamespace())
+		},
+		dependencies: []schema.GroupKind{
+			{Kind: "PersistentVolumeClaim"},
+			{Kind: "Secret"},
+			{Group: storagev1.GroupName, Kind: "CSIDriver"},
+			{Group: storagev1.GroupName, Kind: "StorageClass"},
+		},
+		dependents: []schema.GroupKind{{Group: longhorn.GroupName, Kind: "Volume"}},
+	},
+	{Group: corev1.GroupName, Kind: "PersistentVolumeClaim"}: {
+		resolve: func(n *Node, opts ResolveOptions) (*RelationshipMap, error) {
+			return getPersistentVolumeClaimRelationships(n, opts.longhornNamespace())
+		},
+		dependencies: []schema.GroupKind{anyGroupKind},
+		dependents: []schema.GroupKind{
+			{Group: HarvesterGroupName, Kind: "VirtualMachineImage"},
+			{Group: longhorn.GroupName, Kind: "Volume"},
+		},
+	},
+	{Group: corev1.GroupName, Kind: "Pod"}: {
+		resolve: withoutOptions(getPodRelationships),
+		dependencies: []schema.GroupKind{
+			{Kind: "ConfigMap"},
+			{Kind: "Node"},
+			{Kind: "PersistentVolumeClaim"},
+			{Kind: "Secret"},
+			{Kind: "ServiceAccount"},
+			{Group: nodev1.GroupName, Kind: "RuntimeClass"},
+			{Group: policyv1beta1.GroupName, Kind: "PodSecurityPolicy"},
+			{Group: schedulingv1.GroupName, Kind: "PriorityClass"},
+			{Group: storagev1.GroupName, Kind: "CSIDriver"},
+			{Group: MultusGroupName, Kind: "NetworkAttachmentDefinition"},
+		},
+	},
+	{Group: corev1.GroupName, Kind: "ReplicationController"}: workloadTemplateResolver("spec", "template"),
+	{Group: corev1.GroupName, Kind: "Service"}: {
+		resolve:      withoutOptions(getServiceRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Pod"}},
+	},
+	{Group: corev1.GroupName, Kind: "ServiceAccount"}: {
+		resolve:      withoutOptions(getServiceAccountRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Secret"}},
+		dependents:   []schema.GroupKind{{Kind: "Secret"}},
+	},
+	{Group: discoveryv1.GroupName, Kind: "EndpointSlice"}: {
+		resolve:      withoutOptions(getEndpointSliceRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Service"}},
+		dependents:   []schema.GroupKind{{Kind: "Pod"}, {Group: kubevirt.GroupName, Kind: "VirtualMachineInstance"}},
+	},
+	{Group: eventsv1.GroupName, Kind: "Event"}:            eventResolver,
+	{Group: extensionsv1beta1.GroupName, Kind: "Ingress"}: ingressResolver,
+	{Group: GatewayAPIGroupName, Kind: "Gateway"}: {
+		resolve: withoutOptions(getGatewayAPIGatewayRelationships),
+		dependencies: []schema.GroupKind{
+			{Kind: "Secret"},
+			{Group: CertManagerGroupName, Kind: "ClusterIssuer"},
+			{Group: CertManagerGroupName, Kind: "Issuer"},
+			{Group: GatewayAPIGroupName, Kind: "GatewayClass"},
+		},
+	},
+	{Group: GatewayAPIGroupName, Kind: "GatewayClass"}: {
+		resolve:      withoutOptions(getGatewayAPIGatewayClassRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	},
+	{Group: GatewayAPIGroupName, Kind: "GRPCRoute"}: gatewayAPIRouteResolver,
+	{Group: GatewayAPIGroupName, Kind: "HTTPRoute"}: gatewayAPIRouteResolver,
+	{Group: GatewayAPIGroupName, Kind: "ReferenceGrant"}: {
+		resolve:      withoutOptions(getGatewayAPIReferenceGrantRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	},
+	{Group: GatewayAPIGroupName, Kind: "TCPRoute"}: gatewayAPIRouteResolver,
+	{Group: GatewayAPIGroupName, Kind: "TLSRoute"}: gatewayAPIRouteResolver,
+	{Group: GatewayAPIGroupName, Kind: "UDPRoute"}: gatewayAPIRouteResolver,
+	{Group: HarvesterGroupName, Kind: "Upgrade"}: {
+		resolve: withoutOptions(getHarvesterUpgradeRelationships),
+		dependencies: []schema.GroupKind{
+			{Group: HarvesterGroupName, Kind: "Version"},
+			{Group: HarvesterGroupName, Kind: "VirtualMachineImage"},
+		},
+		dependents: []schema.GroupKind{{Group: batchv1.GroupName, Kind: "Job"}},
+	},
+	{Group: HarvesterGroupName, Kind: "UpgradeLog"}: {
+		resolve:      withoutOptions(getHarvesterUpgradeLogRelationships),
+		dependencies: []schema.GroupKind{{Group: HarvesterGroupName, Kind: "Upgrade"}},
+	},
+	{Group: HarvesterGroupName, Kind: "VirtualMachineBackup"}: {
+		resolve: func(n *Node, opts ResolveOptions) (*RelationshipMap, error) {
+			return getHarvesterVMBackupRelationships(n, opts.longhornNamespace())
+		},
+		dependencies: []schema.GroupKind{anyGroupKind},
+		dependents: []schema.GroupKind{
+			{Group: longhorn.GroupName, Kind: "Backup"},
+			{Group: SnapshotGroupName, Kind: "VolumeSnapshot"},
+		},
+	},
+	{Group: HarvesterGroupName, Kind: "VirtualMachineImage"}: {
+		resolve:      withoutOptions(getHarvesterVMImageRelationships),
+		dependencies: []schema.GroupKind{{Kind: "PersistentVolumeClaim"}},
+		dependents:   []schema.GroupKind{{Group: storagev1.GroupName, Kind: "StorageClass"}},
+	},
+	{Group: HarvesterGroupName, Kind: "VirtualMachineRestore"}: {
+		resolve:      withoutOptions(getHarvesterVMRestoreRelationships),
+		dependencies: []schema.GroupKind{{Group: HarvesterGroupName, Kind: "VirtualMachineBackup"}},
+		dependents:   []schema.GroupKind{anyGroupKind},
+	},
+	{Group: HarvesterGroupName, Kind: "VirtualMachineTemplate"}: {
+		resolve:      withoutOptions(getHarvesterVMTemplateRelationships),
+		dependencies: []schema.GroupKind{{Group: HarvesterGroupName, Kind: "VirtualMachineTemplateVersion"}},
+	},
+	{Group: HarvesterGroupName, Kind: "VirtualMachineTemplateVersion"}: {
+		resolve: withoutOptions(getHarvesterVMTemplateVersionRelationships),
+		dependencies: []schema.GroupKind{
+			{Group: HarvesterGroupName, Kind: "KeyPair"},
+			{Group: HarvesterGroupName, Kind: "VirtualMachineImage"},
+			{Group: HarvesterGroupName, Kind: "VirtualMachineTemplate"},
+		},
+	},
+	{Group: HarvesterNetworkGroupName, Kind: "ClusterNetwork"}: {
+		resolve:      withoutOptions(getHarvesterClusterNetworkRelationships),
+		dependents:   []schema.GroupKind{{Group: MultusGroupName, Kind: "NetworkAttachmentDefinition"}},
+		metadataOnly: true,
+	},
+	{Group: HarvesterNetworkGroupName, Kind: "VlanConfig"}: {
+		resolve:      withoutOptions(getHarvesterVlanConfigRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Node"}, {Group: HarvesterNetworkGroupName, Kind: "ClusterNetwork"}},
+	},
+	{Group: KEDAGroupName, Kind: "ClusterTriggerAuthentication"}: kedaTriggerAuthenticationResolver,
+	{Group: KEDAGroupName, Kind: "ScaledJob"}: {
+		resolve: withoutOptions(getKEDAScaledJobRelationships),
+		dependencies: []schema.GroupKind{
+			{Kind: "ConfigMap"},
+			{Kind: "PersistentVolumeClaim"},
+			{Kind: "Secret"},
+			{Kind: "ServiceAccount"},
+			{Group: nodev1.GroupName, Kind: "RuntimeClass"},
+			{Group: schedulingv1.GroupName, Kind: "PriorityClass"},
+			{Group: storagev1.GroupName, Kind: "CSIDriver"},
+			{Group: KEDAGroupName, Kind: "ClusterTriggerAuthentication"},
+			{Group: KEDAGroupName, Kind: "TriggerAuthentication"},
+		},
+	},
+	{Group: KEDAGroupName, Kind: "ScaledObject"}: {
+		resolve:      withoutOptions(getKEDAScaledObjectRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	},
+	{Group: KEDAGroupName, Kind: "TriggerAuthentication"}: kedaTriggerAuthenticationResolver,
+	{Group: kubevirt.GroupName, Kind: "VirtualMachine"}: {
+		resolve: withoutOptions(getVMRelationships),
+		dependencies: []schema.GroupKind{
+			{Group: HarvesterGroupName, Kind: "KeyPair"},
+			{Group: KubeVirtInstancetypeGroupName, Kind: "VirtualMachineClusterInstancetype"},
+			{Group: KubeVirtInstancetypeGroupName, Kind: "VirtualMachineClusterPreference"},
+			{Group: KubeVirtInstancetypeGroupName, Kind: "VirtualMachineInstancetype"},
+			{Group: KubeVirtInstancetypeGroupName, Kind: "VirtualMachinePreference"},
+		},
+		dependents: vmiSpecDependents,
+	},
+	{Group: kubevirt.GroupName, Kind: "VirtualMachineInstance"}: {
+		resolve:      withoutOptions(getVMIRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Node"}},
+		dependents: []schema.GroupKind{
+			{Kind: "ConfigMap"},
+			{Kind: "PersistentVolumeClaim"},
+			{Kind: "Pod"},
+			{Kind: "Secret"},
+			{Kind: "ServiceAccount"},
+			{Group: KubeVirtCDIGroupName, Kind: "DataVolume"},
+			{Group: MultusGroupName, Kind: "NetworkAttachmentDefinition"},
+		},
+	},
+	{Group: kubevirt.GroupName, Kind: "VirtualMachineInstanceMigration"}: {
+		resolve:      withoutOptions(getVMIMigrationRelationships),
+		dependencies: []schema.GroupKind{{Group: kubevirt.GroupName, Kind: "VirtualMachineInstance"}},
+	},
+	{Group: KubeVirtSnapshotGroupName, Kind: "VirtualMachineRestore"}: {
+		resolve:      withoutOptions(getVMRestoreRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	},
+	{Group: KubeVirtSnapshotGroupName, Kind: "VirtualMachineSnapshot"}: {
+		resolve:      withoutOptions(getVMSnapshotRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	},
+	{Group: KubeVirtSnapshotGroupName, Kind: "VirtualMachineSnapshotContent"}: {
+		resolve:      withoutOptions(getVMSnapshotContentRelationships),
+		dependencies: []schema.GroupKind{{Group: KubeVirtSnapshotGroupName, Kind: "VirtualMachineSnapshot"}},
+		dependents:   []schema.GroupKind{{Group: SnapshotGroupName, Kind: "VolumeSnapshot"}},
+	},
+	{Group: longhorn.GroupName, Kind: "BackingImage"}: {
+		resolve:      withoutOptions(getLonghornBackingImageRelationships),
+		dependencies: []schema.GroupKind{{Group: longhorn.GroupName, Kind: "Volume"}},
+	},
+	{Group: longhorn.GroupName, Kind: "Backup"}: {
+		resolve: withoutOptions(getLonghornBackupRelationships),
+		dependencies: []schema.GroupKind{
+			{Group: longhorn.GroupName, Kind: "BackupVolume"},
+			{Group: longhorn.GroupName, Kind: "Volume"},
+		},
+	},
+	{Group: longhorn.GroupName, Kind: "BackupTarget"}: {
+		resolve:      withoutOptions(getLonghornBackupTargetRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Secret"}},
+	},
+	{Group: longhorn.GroupName, Kind: "BackupVolume"}: {
+		resolve: withoutOptions(getLonghornBackupVolumeRelationships),
+		dependencies: []schema.GroupKind{
+			{Group: longhorn.GroupName, Kind: "BackingImage"},
+			{Group: longhorn.GroupName, Kind: "Volume"},
+		},
+	},
+	{Group: longhorn.GroupName, Kind: "Engine"}: {
+		resolve: withoutOptions(getLonghornEngineRelationships),
+		dependencies: []schema.GroupKind{
+			{Kind: "Node"},
+			{Group: longhorn.GroupName, Kind: "InstanceManager"},
+			{Group: longhorn.GroupName, Kind: "Volume"},
+		},
+	},
+	{Group: longhorn.GroupName, Kind: "InstanceManager"}: {
+		resolve:      withoutOptions(getLonghornInstanceManagerRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Node"}},
+	},
+	{Group: longhorn.GroupName, Kind: "Node"}: {
+		resolve:      withoutOptions(getLonghornNodeRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Node"}, {Group: longhorn.GroupName, Kind: "Replica"}},
+	},
+	{Group: longhorn.GroupName, Kind: "RecurringJob"}: {
+		resolve:    withoutOptions(getLonghornRecurringJobRelationships),
+		dependents: []schema.GroupKind{{Group: longhorn.GroupName, Kind: "Volume"}},
+	},
+	{Group: longhorn.GroupName, Kind: "Replica"}: {
+		resolve: withoutOptions(getLonghornReplicaRelationships),
+		dependencies: []schema.GroupKind{
+			{Kind: "Node"},
+			{Group: longhorn.GroupName, Kind: "BackingImage"},
+			{Group: longhorn.GroupName, Kind: "InstanceManager"},
+			{Group: longhorn.GroupName, Kind: "Volume"},
+		},
+	},
+	{Group: longhorn.GroupName, Kind: "ShareManager"}: {
+		resolve:      withoutOptions(getLonghornShareManagerRelationships),
+		dependencies: []schema.GroupKind{{Group: longhorn.GroupName, Kind: "Volume"}},
+	},
+	{Group: longhorn.GroupName, Kind: "Volume"}: {
+		resolve: withoutOptions(getLonghornVolumeRelationships),
+		dependencies: []schema.GroupKind{
+			{Kind: "PersistentVolume"},
+			{Kind: "PersistentVolumeClaim"},
+			{Group: longhorn.GroupName, Kind: "BackingImage"},
+			{Group: longhorn.GroupName, Kind: "Backup"},
+			{Group: longhorn.GroupName, Kind: "RecurringJob"},
+			{Group: longhorn.GroupName, Kind: "Volume"},
+		},
+	},
+	{Group: networkingv1.GroupName, Kind: "Ingress"}: ingressResolver,
+	{Group: networkingv1.GroupName, Kind: "IngressClass"}: {
+		resolve:      withoutOptions(getIngressClassRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	},
+	{Group: networkingv1.GroupName, Kind: "NetworkPolicy"}: {
+		resolve:      withoutOptions(getNetworkPolicyRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Pod"}},
+		required:     []schema.GroupKind{{Kind: "Namespace"}},
+	},
+	{Group: nodev1.GroupName, Kind: "RuntimeClass"}: {
+		resolve:      withoutOptions(getRuntimeClassRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Node"}},
+	},
+	{Group: policyv1.GroupName, Kind: "PodDisruptionBudget"}: {
+		resolve:      withoutOptions(getPodDisruptionBudgetRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Pod"}},
+	},
+	{Group: policyv1beta1.GroupName, Kind: "PodSecurityPolicy"}: {
+		resolve: withoutOptions(getPodSecurityPolicyRelationships),
+		dependencies: []schema.GroupKind{
+			{Group: nodev1.GroupName, Kind: "RuntimeClass"},
+			{Group: storagev1.GroupName, Kind: "CSIDriver"},
+		},
+	},
+	{Group: PrometheusOperatorGroupName, Kind: "Alertmanager"}: {
+		resolve: withoutOptions(getPrometheusOperatorAlertmanagerRelationships),
+		dependencies: []schema.GroupKind{
+			{Kind: "ConfigMap"},
+			{Kind: "Secret"},
+			{Kind: "ServiceAccount"},
+			{Group: PrometheusOperatorGroupName, Kind: "AlertmanagerConfig"},
+		},
+		required: []schema.GroupKind{{Kind: "Namespace"}},
+	},
+	{Group: PrometheusOperatorGroupName, Kind: "PodMonitor"}: prometheusOperatorMonitorResolver("Pod"),
+	{Group: PrometheusOperatorGroupName, Kind: "Prometheus"}: {
+		resolve: withoutOptions(getPrometheusOperatorPrometheusRelationships),
+		dependencies: []schema.GroupKind{
+			{Kind: "ConfigMap"},
+			{Kind: "Secret"},
+			{Kind: "Service"},
+			{Kind: "ServiceAccount"},
+			{Group: PrometheusOperatorGroupName, Kind: "PodMonitor"},
+			{Group: PrometheusOperatorGroupName, Kind: "Probe"},
+			{Group: PrometheusOperatorGroupName, Kind: "PrometheusRule"},
+			{Group: PrometheusOperatorGroupName, Kind: "ServiceMonitor"},
+		},
+		required: []schema.GroupKind{{Kind: "Namespace"}},
+	},
+	{Group: PrometheusOperatorGroupName, Kind: "ServiceMonitor"}: prometheusOperatorMonitorResolver("Service"),
+	{Group: rbacv1.GroupName, Kind: "ClusterRole"}: {
+		resolve:      withoutOptions(getClusterRoleRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	},
+	{Group: rbacv1.GroupName, Kind: "ClusterRoleBinding"}: {
+		resolve:      withoutOptions(getClusterRoleBindingRelationships),
+		dependencies: []schema.GroupKind{{Group: rbacv1.GroupName, Kind: "ClusterRole"}},
+		dependents:   []schema.GroupKind{{Kind: "ServiceAccount"}},
+	},
+	{Group: rbacv1.GroupName, Kind: "Role"}: {
+		resolve:      withoutOptions(getRoleRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	},
+	{Group: rbacv1.GroupName, Kind: "RoleBinding"}: {
+		resolve: withoutOptions(getRoleBindingRelationships),
+		dependencies: []schema.GroupKind{
+			{Group: rbacv1.GroupName, Kind: "ClusterRole"},
+			{Group: rbacv1.GroupName, Kind: "Role"},
+		},
+		dependents: []schema.GroupKind{{Kind: "ServiceAccount"}},
+	},
+	{Group: SnapshotGroupName, Kind: "VolumeSnapshot"}: {
+		resolve: withoutOptions(getVolumeSnapshotRelationships),
+		dependencies: []schema.GroupKind{
+			{Kind: "PersistentVolumeClaim"},
+			{Group: SnapshotGroupName, Kind: "VolumeSnapshotClass"},
+			{Group: SnapshotGroupName, Kind: "VolumeSnapshotContent"},
+		},
+	},
+	{Group: SnapshotGroupName, Kind: "VolumeSnapshotClass"}: {
+		resolve:      withoutOptions(getVolumeSnapshotClassRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Secret"}, {Group: storagev1.GroupName, Kind: "CSIDriver"}},
+	},
+	{Group: SnapshotGroupName, Kind: "VolumeSnapshotContent"}: {
+		resolve: withoutOptions(getVolumeSnapshotContentRelationships),
+		dependencies: []schema.GroupKind{
+			{Kind: "Secret"},
+			{Group: storagev1.GroupName, Kind: "CSIDriver"},
+			{Group: SnapshotGroupName, Kind: "VolumeSnapshot"},
+			{Group: SnapshotGroupName, Kind: "VolumeSnapshotClass"},
+		},
+	},
+	{Group: storagev1.GroupName, Kind: "CSINode"}: {
+		resolve:    withoutOptions(getCSINodeRelationships),
+		dependents: []schema.GroupKind{{Group: storagev1.GroupName, Kind: "CSIDriver"}},
+	},
+	{Group: storagev1.GroupName, Kind: "StorageClass"}: {
+		resolve:      withoutOptions(getStorageClassRelationships),
+		dependencies: []schema.GroupKind{{Group: storagev1.GroupName, Kind: "CSIDriver"}},
+	},
+	{Group: storagev1.GroupName, Kind: "VolumeAttachment"}: {
+		resolve:      withoutOptions(getVolumeAttachmentRelationships),
+		dependencies: []schema.GroupKind{{Kind: "Node"}, {Group: storagev1.GroupName, Kind: "CSIDriver"}},
+		dependents: []schema.GroupKind{
+			{Kind: "PersistentVolume"},
+			{Kind: "PersistentVolumeClaim"},
+			{Kind: "Secret"},
+			{Group: storagev1.GroupName, Kind: "CSIDriver"},
+			{Group: storagev1.GroupName, Kind: "StorageClass"},
+		},
+	},
+	{Group: storagev1beta1.GroupName, Kind: "CSIStorageCapacity"}: {
+		resolve:      withoutOptions(getCSIStorageCapacityRelationships),
+		dependencies: []schema.GroupKind{{Group: storagev1.GroupName, Kind: "StorageClass"}},
+	},
+	{Group: VerticalPodAutoscalerGroupName, Kind: "VerticalPodAutoscaler"}: {
+		resolve:      withoutOptions(getVerticalPodAutoscalerRelationships),
+		dependencies: []schema.GroupKind{anyGroupKind},
+	},
+}
+
+// reportUndeclaredTargets is called with the GroupKinds of objects that a
+// relationship resolver referenced without declaring them as targets.
+var reportUndeclaredTargets = func(gk schema.GroupKind, targets []schema.GroupKind) {
+	klog.V(4).Infof("Relationship resolver of %s references undeclared kinds %v", gk, targets)
+}
+
+// getRelationships returns a map of relationships that the provided node has
+// with other objects, based on what was referenced in its manifest. Returns a
+// nil map if there's no relationship resolver for the node's GroupKind.
+func getRelationships(node *Node, opts ResolveOptions) (*RelationshipMap, error) {
+	gk := schema.GroupKind{Group: node.Group, Kind: node.Kind}
+	r, ok := relationshipResolvers[gk]
+	if !ok {
+		return nil, nil
+	}
+	rmap, err := r.resolve(node, opts)
+	if rmap != nil {
+		if targets := r.undeclaredTargets(rmap); len(targets) > 0 {
+			reportUndeclaredTargets(gk, targets)
+		}
+	}
+	return rmap, err
+}
+
+// undeclaredTargets returns the GroupKinds of objects referenced by the
+// provided relationships which the resolver doesn't declare as targets.
+// References without an API group match declared GroupKinds of any group.
+//
+//nolint:gocognit
+func (r relationshipResolver) undeclaredTargets(rmap *RelationshipMap) []schema.GroupKind {
+	var result []schema.GroupKind
+	check := func(declared []schema.GroupKind, gk schema.GroupKind) {
+		for _, d := range declared {
+			if d == anyGroupKind || d.Kind == gk.Kind && (d.Group == gk.Group || len(gk.Group) == 0) {
+				return
+			}
+		}
+		result = append(result, gk)
+	}
+
+	for k := range rmap.DependenciesByRef {
+		ref := k.ObjectReference()
+		check(r.dependencies, schema.GroupKind{Group: ref.Group, Kind: ref.Kind})
+	}
+	for k := range rmap.DependenciesByGrantedRef {
+		ref := k.ObjectReference()
+		check(r.dependencies, schema.GroupKind{Group: ref.Group, Kind: ref.Kind})
+	}
+	for k := range rmap.DependenciesByLabelSelector {
+		ols := rmap.ObjectLabelSelectors[k]
+		check(r.dependencies, schema.GroupKind{Group: ols.Group, Kind: ols.Kind})
+	}
+	for k := range rmap.DependenciesByNamespaceSelector {
+		ons := rmap.ObjectNamespaceSelectors[k]
+		check(r.dependencies, schema.GroupKind{Group: ons.Group, Kind: ons.Kind})
+	}
+	for k := range rmap.DependenciesBySelector {
+		if os := rmap.ObjectSelectors[k]; len(os.Kind) > 0 {
+			check(r.dependencies, schema.GroupKind{Group: os.Group, Kind: os.Kind})
+		} else {
+			check(r.dependencies, anyGroupKind)
+		}
+	}
+	if len(rmap.DependenciesByParamRef) > 0 || len(rmap.DependenciesByPolicyRule) > 0 || len(rmap.DependenciesByUID) > 0 {
+		check(r.dependencies, anyGroupKind)
+	}
+	for k := range rmap.DependentsByRef {
+		ref := k.ObjectReference()
+		check(r.dependents, schema.GroupKind{Group: ref.Group, Kind: ref.Kind})
+	}
+	for k := range rmap.DependentsByLabelSelector {
+		ols := rmap.ObjectLabelSelectors[k]
+		check(r.dependents, schema.GroupKind{Group: ols.Group, Kind: ols.Kind})
+	}
+	for k := range rmap.DependentsBySelector {
+		if os := rmap.ObjectSelectors[k]; len(os.Kind) > 0 {
+			check(r.dependents, schema.GroupKind{Group: os.Group, Kind: os.Kind})
+		} else {
+			check(r.dependents, anyGroupKind)
+		}
+	}
+	if len(rmap.DependentsByAdmissionSelector) > 0 || len(rmap.DependentsByUID) > 0 {
+		check(r.dependents, anyGroupKind)
+	}
+
+	return result
 }
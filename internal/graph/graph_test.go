@@ -0,0 +1,3122 @@
+package graph
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// TestMain fails the tests if a relationship resolver references objects of
+// GroupKinds it doesn't declare, since the fetcher relies on the declared
+// GroupKinds to find the objects that may reference a node.
+func TestMain(m *testing.M) {
+	var mu sync.Mutex
+	undeclared := map[string]struct{}{}
+	reportUndeclaredTargets = func(gk schema.GroupKind, targets []schema.GroupKind) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, t := range targets {
+			undeclared[fmt.Sprintf("%s references %s", gk, t)] = struct{}{}
+		}
+	}
+
+	code := m.Run()
+	for k := range undeclared {
+		fmt.Fprintf(os.Stderr, "undeclared relationship target: %s\n", k)
+		code = 1
+	}
+	os.Exit(code)
+}
+
+// testGroupVersionKinds contains the GroupVersionKinds served by the test
+// RESTMapper.
+var testGroupVersionKinds = []schema.GroupVersionKind{
+	{Version: "v1", Kind: "ConfigMap"},
+	{Version: "v1", Kind: "Endpoints"},
+	{Version: "v1", Kind: "LimitRange"},
+	{Version: "v1", Kind: "Namespace"},
+	{Version: "v1", Kind: "Node"},
+	{Version: "v1", Kind: "PersistentVolume"},
+	{Version: "v1", Kind: "PersistentVolumeClaim"},
+	{Version: "v1", Kind: "Pod"},
+	{Version: "v1", Kind: "ResourceQuota"},
+	{Version: "v1", Kind: "Secret"},
+	{Version: "v1", Kind: "Service"},
+	{Version: "v1", Kind: "ServiceAccount"},
+	{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingAdmissionPolicy"},
+	{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingAdmissionPolicyBinding"},
+	{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingWebhookConfiguration"},
+	{Group: "apiregistration.k8s.io", Version: "v1", Kind: "APIService"},
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "discovery.k8s.io", Version: "v1", Kind: "EndpointSlice"},
+	{Group: "apps", Version: "v1", Kind: "ReplicaSet"},
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"},
+	{Group: CertManagerACMEGroupName, Version: "v1", Kind: "Challenge"},
+	{Group: CertManagerACMEGroupName, Version: "v1", Kind: "Order"},
+	{Group: CertManagerGroupName, Version: "v1", Kind: "Certificate"},
+	{Group: CertManagerGroupName, Version: "v1", Kind: "CertificateRequest"},
+	{Group: CertManagerGroupName, Version: "v1", Kind: "ClusterIssuer"},
+	{Group: CertManagerGroupName, Version: "v1", Kind: "Issuer"},
+	{Group: ClusterAPIGroupName, Version: "v1beta1", Kind: "Cluster"},
+	{Group: ClusterAPIGroupName, Version: "v1beta1", Kind: "Machine"},
+	{Group: KEDAGroupName, Version: "v1alpha1", Kind: "ClusterTriggerAuthentication"},
+	{Group: PrometheusOperatorGroupName, Version: "v1", Kind: "PodMonitor"},
+	{Group: PrometheusOperatorGroupName, Version: "v1", Kind: "Prometheus"},
+	{Group: PrometheusOperatorGroupName, Version: "v1", Kind: "ServiceMonitor"},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"},
+	{Group: "policy", Version: "v1beta1", Kind: "PodSecurityPolicy"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"},
+	{Group: GatewayAPIGroupName, Version: "v1", Kind: "HTTPRoute"},
+	{Group: GatewayAPIGroupName, Version: "v1beta1", Kind: "ReferenceGrant"},
+	{Group: "example.com", Version: "v1", Kind: "Volume"},
+	{Group: "kubevirt.io", Version: "v1", Kind: "VirtualMachine"},
+	{Group: "kubevirt.io", Version: "v1", Kind: "VirtualMachineInstance"},
+	{Group: "kubevirt.io", Version: "v1", Kind: "VirtualMachineInstanceMigration"},
+	{Group: KubeVirtCDIGroupName, Version: "v1beta1", Kind: "DataVolume"},
+	{Group: KubeVirtInstancetypeGroupName, Version: "v1beta1", Kind: "VirtualMachineClusterInstancetype"},
+	{Group: KubeVirtInstancetypeGroupName, Version: "v1beta1", Kind: "VirtualMachinePreference"},
+	{Group: "longhorn.io", Version: "v1beta2", Kind: "BackingImage"},
+	{Group: "longhorn.io", Version: "v1beta2", Kind: "Backup"},
+	{Group: "longhorn.io", Version: "v1beta2", Kind: "BackupVolume"},
+	{Group: "longhorn.io", Version: "v1beta2", Kind: "Engine"},
+	{Group: "longhorn.io", Version: "v1beta2", Kind: "InstanceManager"},
+	{Group: "longhorn.io", Version: "v1beta2", Kind: "Node"},
+	{Group: "longhorn.io", Version: "v1beta2", Kind: "RecurringJob"},
+	{Group: "longhorn.io", Version: "v1beta2", Kind: "Replica"},
+	{Group: "longhorn.io", Version: "v1beta2", Kind: "ShareManager"},
+	{Group: "longhorn.io", Version: "v1beta2", Kind: "Volume"},
+	{Group: "batch", Version: "v1", Kind: "CronJob"},
+	{Group: "batch", Version: "v1", Kind: "Job"},
+	{Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"},
+	{Group: "storage.k8s.io", Version: "v1", Kind: "CSIDriver"},
+	{Group: "storage.k8s.io", Version: "v1", Kind: "StorageClass"},
+	{Group: HarvesterGroupName, Version: "v1beta1", Kind: "KeyPair"},
+	{Group: HarvesterGroupName, Version: "v1beta1", Kind: "Upgrade"},
+	{Group: HarvesterGroupName, Version: "v1beta1", Kind: "UpgradeLog"},
+	{Group: HarvesterGroupName, Version: "v1beta1", Kind: "Version"},
+	{Group: HarvesterGroupName, Version: "v1beta1", Kind: "VirtualMachineBackup"},
+	{Group: HarvesterGroupName, Version: "v1beta1", Kind: "VirtualMachineImage"},
+	{Group: HarvesterGroupName, Version: "v1beta1", Kind: "VirtualMachineRestore"},
+	{Group: HarvesterGroupName, Version: "v1beta1", Kind: "VirtualMachineTemplate"},
+	{Group: HarvesterGroupName, Version: "v1beta1", Kind: "VirtualMachineTemplateVersion"},
+	{Group: HarvesterNetworkGroupName, Version: "v1beta1", Kind: "ClusterNetwork"},
+	{Group: HarvesterNetworkGroupName, Version: "v1beta1", Kind: "VlanConfig"},
+	{Group: MultusGroupName, Version: "v1", Kind: "NetworkAttachmentDefinition"},
+	{Group: SnapshotGroupName, Version: "v1", Kind: "VolumeSnapshot"},
+	{Group: SnapshotGroupName, Version: "v1", Kind: "VolumeSnapshotClass"},
+	{Group: SnapshotGroupName, Version: "v1", Kind: "VolumeSnapshotContent"},
+}
+
+// testRootScopedGroupKinds contains the cluster-scoped GroupKinds served by the
+// test RESTMapper.
+var testRootScopedGroupKinds = map[schema.GroupKind]struct{}{
+	{Kind: "Namespace"}:        {},
+	{Kind: "Node"}:             {},
+	{Kind: "PersistentVolume"}: {},
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicy"}:        {},
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicyBinding"}: {},
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}:   {},
+	{Group: "apiregistration.k8s.io", Kind: "APIService"}:                             {},
+	{Group: CertManagerGroupName, Kind: "ClusterIssuer"}:                              {},
+	{Group: KEDAGroupName, Kind: "ClusterTriggerAuthentication"}:                      {},
+	{Group: KubeVirtInstancetypeGroupName, Kind: "VirtualMachineClusterInstancetype"}: {},
+	{Group: "policy", Kind: "PodSecurityPolicy"}:                                      {},
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:                         {},
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:                  {},
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:                               {},
+	{Group: "storage.k8s.io", Kind: "CSIDriver"}:                                      {},
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:                                   {},
+	{Group: SnapshotGroupName, Kind: "VolumeSnapshotClass"}:                           {},
+	{Group: SnapshotGroupName, Kind: "VolumeSnapshotContent"}:                         {},
+	{Group: HarvesterNetworkGroupName, Kind: "ClusterNetwork"}:                        {},
+	{Group: HarvesterNetworkGroupName, Kind: "VlanConfig"}:                            {},
+}
+
+func newTestRESTMapper() meta.RESTMapper {
+	var gvs []schema.GroupVersion
+	for _, gvk := range testGroupVersionKinds {
+		gvs = append(gvs, gvk.GroupVersion())
+	}
+	m := meta.NewDefaultRESTMapper(gvs)
+	for _, gvk := range testGroupVersionKinds {
+		scope := meta.RESTScopeNamespace
+		if _, ok := testRootScopedGroupKinds[gvk.GroupKind()]; ok {
+			scope = meta.RESTScopeRoot
+		}
+		m.Add(gvk, scope)
+	}
+	return m
+}
+
+func newTestObject(uid types.UID, apiVersion, kind, ns, name string, fields map[string]interface{}) unstructuredv1.Unstructured {
+	metadata := map[string]interface{}{"name": name, "uid": string(uid)}
+	if len(ns) > 0 {
+		metadata["namespace"] = ns
+	}
+	obj := map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "metadata": metadata}
+	for k, v := range fields {
+		if k == "metadata" {
+			for mk, mv := range v.(map[string]interface{}) {
+				metadata[mk] = mv
+			}
+			continue
+		}
+		obj[k] = v
+	}
+	return unstructuredv1.Unstructured{Object: obj}
+}
+
+func TestGetKindGroups(t *testing.T) {
+	t.Parallel()
+
+	m := newTestRESTMapper()
+	tests := []struct {
+		kind     string
+		expected []string
+	}{
+		{kind: "PersistentVolumeClaim", expected: nil},
+		{kind: "VolumeSnapshot", expected: []string{SnapshotGroupName}},
+		{kind: "Volume", expected: []string{"example.com", "longhorn.io"}},
+		{kind: "Unknown", expected: []string{}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.kind, func(t *testing.T) {
+			t.Parallel()
+			if got := getKindGroups(m, tt.kind); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected %#v got %#v", tt.expected, got)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestEdgeRelationship(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		r        Relationship
+		expected Relationship
+	}{
+		{name: "Plain", r: RelationshipClusterRolePolicyRule, expected: RelationshipClusterRolePolicyRule},
+		{name: "SingleValue", r: withEdgeMetadata(RelationshipPodNetworkAttachmentDefinition, "eth1"), expected: RelationshipPodNetworkAttachmentDefinition},
+		{name: "MultipleValues", r: withEdgeMetadata(RelationshipRolePolicyRule, "get,list"), expected: RelationshipRolePolicyRule},
+		{name: "DottedValue", r: withEdgeMetadata(RelationshipWebhookConfigurationRule, "a.example.com"), expected: RelationshipWebhookConfigurationRule},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := edgeRelationship(tt.r); got != tt.expected {
+				t.Fatalf("expected \"%s\" got \"%s\"", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRequiresFullObject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		gk       schema.GroupKind
+		expected bool
+	}{
+		{name: "ResolverReadingSpec", gk: schema.GroupKind{Group: "apps", Kind: "Deployment"}, expected: true},
+		{name: "ResolverReadingMetadataOnly", gk: schema.GroupKind{Group: HarvesterNetworkGroupName, Kind: "ClusterNetwork"}, expected: false},
+		{name: "NoResolver", gk: schema.GroupKind{Kind: "ConfigMap"}, expected: false},
+		{name: "UnknownGroupKind", gk: schema.GroupKind{Group: "example.com", Kind: "Volume"}, expected: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := RequiresFullObject(tt.gk); got != tt.expected {
+				t.Fatalf("expected %t got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResolveThirdPartyRelationships(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("node", "v1", "Node", "", "node-1", nil),
+		newTestObject("pv", "v1", "PersistentVolume", "", "pvc-1", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{"pv.kubernetes.io/provisioned-by": "driver.longhorn.io"},
+			},
+		}),
+		newTestObject("pvc-disk", "v1", "PersistentVolumeClaim", "default", "disk", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{HarvesterImageIDAnnotation: "default/image"},
+			},
+		}),
+		newTestObject("pvc-from-snapshot", "v1", "PersistentVolumeClaim", "default", "from-snapshot", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"dataSource": map[string]interface{}{"kind": "VolumeSnapshot", "name": "snapshot"},
+			},
+		}),
+		newTestObject("pvc-from-volume", "v1", "PersistentVolumeClaim", "default", "from-volume", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"dataSource": map[string]interface{}{"kind": "Volume", "name": "volume"},
+			},
+		}),
+		newTestObject("example-volume", "example.com/v1", "Volume", "default", "volume", nil),
+		newTestObject("longhorn-volume", "longhorn.io/v1beta2", "Volume", "default", "volume", nil),
+		newTestObject("longhorn-volume-pv", "longhorn.io/v1beta2", "Volume", "longhorn-system", "pvc-1", nil),
+		newTestObject("longhorn-replica", "longhorn.io/v1beta2", "Replica", "longhorn-system", "pvc-1-r-1", map[string]interface{}{
+			"spec": map[string]interface{}{"nodeID": "node-1", "volumeName": "pvc-1"},
+		}),
+		newTestObject("vm-image", "harvesterhci.io/v1beta1", "VirtualMachineImage", "default", "image", nil),
+		newTestObject("nad", "k8s.cni.cncf.io/v1", "NetworkAttachmentDefinition", "default", "vlan-1", nil),
+		newTestObject("vm", "kubevirt.io/v1", "VirtualMachine", "default", "vm", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"domain":   map[string]interface{}{"devices": map[string]interface{}{}},
+						"networks": []interface{}{map[string]interface{}{"name": "nic-1", "multus": map[string]interface{}{"networkName": "vlan-1"}}},
+					},
+				},
+			},
+		}),
+		newTestObject("snapshot", "snapshot.storage.k8s.io/v1", "VolumeSnapshot", "default", "snapshot", nil),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+	}{
+		{
+			name:         "LonghornVolumePersistentVolume",
+			from:         "longhorn-volume-pv",
+			to:           "pv",
+			relationship: RelationshipLonghornVolumePersistentVolume,
+		},
+		{
+			name:         "LonghornReplicaVolume",
+			from:         "longhorn-replica",
+			to:           "longhorn-volume-pv",
+			relationship: RelationshipLonghornReplicaVolume,
+		},
+		{
+			name:         "PersistentVolumeClaimVMImage",
+			from:         "vm-image",
+			to:           "pvc-disk",
+			relationship: RelationshipPersistentVolumeClaimVMImage,
+		},
+		{
+			name:         "VMNetworkAttachmentDefinition",
+			from:         "nad",
+			to:           "vm",
+			relationship: RelationshipVMNetworkAttachmentDefinition,
+		},
+		{
+			name:         "ReferenceWithoutGroup",
+			from:         "pvc-from-snapshot",
+			to:           "snapshot",
+			relationship: RelationshipPersistentVolumeClaimDataSource,
+		},
+		{
+			name:         "ReferenceWithAmbiguousGroup/example.com",
+			from:         "pvc-from-volume",
+			to:           "example-volume",
+			relationship: RelationshipPersistentVolumeClaimDataSource,
+		},
+		{
+			name:         "ReferenceWithAmbiguousGroup/longhorn.io",
+			from:         "pvc-from-volume",
+			to:           "longhorn-volume",
+			relationship: RelationshipPersistentVolumeClaimDataSource,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; !ok {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\", got %v", tt.relationship, tt.from, tt.to, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolveLonghornRelationships(t *testing.T) {
+	t.Parallel()
+
+	ns := "storage"
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("node", "v1", "Node", "", "node-1", nil),
+		newTestObject("pv", "v1", "PersistentVolume", "", "pv-1", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"csi": map[string]interface{}{"driver": LonghornDriverName, "volumeHandle": "volume"},
+			},
+		}),
+		newTestObject("volume", "longhorn.io/v1beta2", "Volume", ns, "volume", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{
+					"recurring-job.longhorn.io/snapshot":    "enabled",
+					"recurring-job-group.longhorn.io/daily": "enabled",
+				},
+			},
+			"spec": map[string]interface{}{"backingImage": "image", "size": "1073741824"},
+		}),
+		newTestObject("restored-volume", "longhorn.io/v1beta2", "Volume", ns, "restored", map[string]interface{}{
+			"spec": map[string]interface{}{"fromBackup": "s3://bucket@us-east-1/?backup=backup-1&volume=volume"},
+		}),
+		newTestObject("backing-image", "longhorn.io/v1beta2", "BackingImage", ns, "image", nil),
+		newTestObject("backup", "longhorn.io/v1beta2", "Backup", ns, "backup-1", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{"backup-volume": "volume"},
+			},
+			"status": map[string]interface{}{"volumeName": "volume"},
+		}),
+		newTestObject("backup-volume", "longhorn.io/v1beta2", "BackupVolume", ns, "volume", nil),
+		newTestObject("engine", "longhorn.io/v1beta2", "Engine", ns, "volume-e-1", map[string]interface{}{
+			"spec":   map[string]interface{}{"nodeID": "node-1", "volumeName": "volume"},
+			"status": map[string]interface{}{"instanceManagerName": "instance-manager-1"},
+		}),
+		newTestObject("instance-manager", "longhorn.io/v1beta2", "InstanceManager", ns, "instance-manager-1", map[string]interface{}{
+			"spec": map[string]interface{}{"nodeID": "node-1"},
+		}),
+		newTestObject("longhorn-node", "longhorn.io/v1beta2", "Node", ns, "node-1", map[string]interface{}{
+			"status": map[string]interface{}{
+				"diskStatus": map[string]interface{}{
+					"default-disk": map[string]interface{}{
+						"scheduledReplica": map[string]interface{}{"volume-r-1": int64(1073741824)},
+					},
+				},
+			},
+		}),
+		newTestObject("recurring-job", "longhorn.io/v1beta2", "RecurringJob", ns, "snapshot", nil),
+		newTestObject("recurring-job-group", "longhorn.io/v1beta2", "RecurringJob", ns, "backup", map[string]interface{}{
+			"spec": map[string]interface{}{"groups": []interface{}{"daily"}},
+		}),
+		newTestObject("replica", "longhorn.io/v1beta2", "Replica", ns, "volume-r-1", map[string]interface{}{
+			"spec":   map[string]interface{}{"nodeID": "node-1", "volumeName": "volume", "backingImage": "image"},
+			"status": map[string]interface{}{"instanceManagerName": "instance-manager-1"},
+		}),
+		newTestObject("share-manager", "longhorn.io/v1beta2", "ShareManager", ns, "volume", nil),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{LonghornNamespace: ns})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+	}{
+		{
+			name:         "BackupBackupVolume",
+			from:         "backup",
+			to:           "backup-volume",
+			relationship: RelationshipLonghornBackupBackupVolume,
+		},
+		{
+			name:         "BackupVolume",
+			from:         "backup",
+			to:           "volume",
+			relationship: RelationshipLonghornBackupVolume,
+		},
+		{
+			name:         "BackupVolumeVolume",
+			from:         "backup-volume",
+			to:           "volume",
+			relationship: RelationshipLonghornBackupVolumeVolume,
+		},
+		{
+			name:         "EngineInstanceManager",
+			from:         "engine",
+			to:           "instance-manager",
+			relationship: RelationshipLonghornEngineInstanceManager,
+		},
+		{
+			name:         "EngineVolume",
+			from:         "engine",
+			to:           "volume",
+			relationship: RelationshipLonghornEngineVolume,
+		},
+		{
+			name:         "InstanceManagerNode",
+			from:         "instance-manager",
+			to:           "node",
+			relationship: RelationshipLonghornInstanceManagerNode,
+		},
+		{
+			name:         "NodeDisk",
+			from:         "longhorn-node",
+			to:           "replica",
+			relationship: withEdgeMetadata(RelationshipLonghornNodeDisk, "default-disk"),
+		},
+		{
+			name:         "NodeNode",
+			from:         "longhorn-node",
+			to:           "node",
+			relationship: RelationshipLonghornNodeNode,
+		},
+		{
+			name:         "RecurringJobGroup",
+			from:         "volume",
+			to:           "recurring-job-group",
+			relationship: RelationshipLonghornRecurringJobGroup,
+		},
+		{
+			name:         "ReplicaBackingImage",
+			from:         "replica",
+			to:           "backing-image",
+			relationship: RelationshipLonghornReplicaBackingImage,
+		},
+		{
+			name:         "ReplicaInstanceManager",
+			from:         "replica",
+			to:           "instance-manager",
+			relationship: RelationshipLonghornReplicaInstanceManager,
+		},
+		{
+			name:         "ReplicaVolume",
+			from:         "replica",
+			to:           "volume",
+			relationship: RelationshipLonghornReplicaVolume,
+		},
+		{
+			name:         "ShareManagerVolume",
+			from:         "share-manager",
+			to:           "volume",
+			relationship: RelationshipLonghornShareManagerVolume,
+		},
+		{
+			name:         "VolumeBackingImage",
+			from:         "volume",
+			to:           "backing-image",
+			relationship: RelationshipLonghornVolumeBackingImage,
+		},
+		{
+			name:         "VolumeFromBackup",
+			from:         "restored-volume",
+			to:           "backup",
+			relationship: RelationshipLonghornVolumeFromBackup,
+		},
+		{
+			name:         "VolumePersistentVolume",
+			from:         "volume",
+			to:           "pv",
+			relationship: RelationshipLonghornVolumePersistentVolume,
+		},
+		{
+			name:         "VolumeRecurringJob",
+			from:         "volume",
+			to:           "recurring-job",
+			relationship: RelationshipLonghornVolumeRecurringJob,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; !ok {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\", got %v", tt.relationship, tt.from, tt.to, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolveHarvesterRelationships(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("node", "v1", "Node", "", "node-1", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{"network.harvesterhci.io/mgmt": "true"},
+			},
+		}),
+		newTestObject("pvc", "v1", "PersistentVolumeClaim", "default", "vm-disk-0", nil),
+		newTestObject("restored-pvc", "v1", "PersistentVolumeClaim", "default", "restore-vm-disk-0", nil),
+		newTestObject("storage-class", "storage.k8s.io/v1", "StorageClass", "", "longhorn-image", nil),
+		newTestObject("job", "batch/v1", "Job", "harvester-system", "apply-manifests", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{"harvesterhci.io/upgrade": "upgrade"},
+			},
+		}),
+		newTestObject("key-pair", "harvesterhci.io/v1beta1", "KeyPair", "default", "key", nil),
+		newTestObject("image", "harvesterhci.io/v1beta1", "VirtualMachineImage", "default", "image", map[string]interface{}{
+			"spec":   map[string]interface{}{"pvcName": "vm-disk-0", "pvcNamespace": "default"},
+			"status": map[string]interface{}{"storageClassName": "longhorn-image"},
+		}),
+		newTestObject("vm", "kubevirt.io/v1", "VirtualMachine", "default", "vm", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{HarvesterSSHNamesAnnotation: `["default/key"]`},
+			},
+		}),
+		newTestObject("backup", "harvesterhci.io/v1beta1", "VirtualMachineBackup", "default", "backup", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"source": map[string]interface{}{"apiGroup": "kubevirt.io", "kind": "VirtualMachine", "name": "vm"},
+			},
+			"status": map[string]interface{}{
+				"volumeBackups": []interface{}{
+					map[string]interface{}{
+						"persistentVolumeClaim": map[string]interface{}{
+							"metadata": map[string]interface{}{"name": "vm-disk-0", "namespace": "default"},
+						},
+					},
+				},
+			},
+		}),
+		newTestObject("restore", "harvesterhci.io/v1beta1", "VirtualMachineRestore", "default", "restore", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"target":                        map[string]interface{}{"apiGroup": "kubevirt.io", "kind": "VirtualMachine", "name": "vm"},
+				"virtualMachineBackupName":      "backup",
+				"virtualMachineBackupNamespace": "default",
+			},
+			"status": map[string]interface{}{
+				"restores": []interface{}{
+					map[string]interface{}{
+						"persistentVolumeClaim": map[string]interface{}{
+							"metadata": map[string]interface{}{"name": "restore-vm-disk-0"},
+						},
+					},
+				},
+			},
+		}),
+		newTestObject("template", "harvesterhci.io/v1beta1", "VirtualMachineTemplate", "default", "template", map[string]interface{}{
+			"spec": map[string]interface{}{"defaultVersionId": "default/template-v1"},
+		}),
+		newTestObject("template-version", "harvesterhci.io/v1beta1", "VirtualMachineTemplateVersion", "default", "template-v1", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"imageId":    "default/image",
+				"keyPairIds": []interface{}{"default/key"},
+				"templateId": "default/template",
+			},
+		}),
+		newTestObject("cluster-network", "network.harvesterhci.io/v1beta1", "ClusterNetwork", "", "mgmt", nil),
+		newTestObject("vlan-config", "network.harvesterhci.io/v1beta1", "VlanConfig", "", "vlan", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"clusterNetwork": "mgmt",
+				"nodeSelector":   map[string]interface{}{"network.harvesterhci.io/mgmt": "true"},
+			},
+		}),
+		newTestObject("nad", "k8s.cni.cncf.io/v1", "NetworkAttachmentDefinition", "default", "vlan-1", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{"network.harvesterhci.io/clusternetwork": "mgmt"},
+			},
+		}),
+		newTestObject("version", "harvesterhci.io/v1beta1", "Version", "harvester-system", "v1.2.0", nil),
+		newTestObject("upgrade", "harvesterhci.io/v1beta1", "Upgrade", "harvester-system", "upgrade", map[string]interface{}{
+			"spec":   map[string]interface{}{"version": "v1.2.0"},
+			"status": map[string]interface{}{"imageID": "default/image"},
+		}),
+		newTestObject("upgrade-log", "harvesterhci.io/v1beta1", "UpgradeLog", "harvester-system", "upgrade-log", map[string]interface{}{
+			"spec": map[string]interface{}{"upgradeName": "upgrade"},
+		}),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+	}{
+		{
+			name:         "ClusterNetworkNetworkAttachmentDefinition",
+			from:         "nad",
+			to:           "cluster-network",
+			relationship: RelationshipHarvesterClusterNetworkNetworkAttachmentDefinition,
+		},
+		{
+			name:         "UpgradeImage",
+			from:         "upgrade",
+			to:           "image",
+			relationship: RelationshipHarvesterUpgradeImage,
+		},
+		{
+			name:         "UpgradeJob",
+			from:         "job",
+			to:           "upgrade",
+			relationship: RelationshipHarvesterUpgradeJob,
+		},
+		{
+			name:         "UpgradeLogUpgrade",
+			from:         "upgrade-log",
+			to:           "upgrade",
+			relationship: RelationshipHarvesterUpgradeLogUpgrade,
+		},
+		{
+			name:         "UpgradeVersion",
+			from:         "upgrade",
+			to:           "version",
+			relationship: RelationshipHarvesterUpgradeVersion,
+		},
+		{
+			name:         "VMBackupPersistentVolumeClaim",
+			from:         "backup",
+			to:           "pvc",
+			relationship: RelationshipHarvesterVMBackupPersistentVolumeClaim,
+		},
+		{
+			name:         "VMBackupSource",
+			from:         "backup",
+			to:           "vm",
+			relationship: RelationshipHarvesterVMBackupSource,
+		},
+		{
+			name:         "VMImagePersistentVolumeClaim",
+			from:         "image",
+			to:           "pvc",
+			relationship: RelationshipHarvesterVMImagePersistentVolumeClaim,
+		},
+		{
+			name:         "VMImageStorageClass",
+			from:         "storage-class",
+			to:           "image",
+			relationship: RelationshipHarvesterVMImageStorageClass,
+		},
+		{
+			name:         "VMKeyPair",
+			from:         "vm",
+			to:           "key-pair",
+			relationship: RelationshipHarvesterVMKeyPair,
+		},
+		{
+			name:         "VMRestoreBackup",
+			from:         "restore",
+			to:           "backup",
+			relationship: RelationshipHarvesterVMRestoreBackup,
+		},
+		{
+			name:         "VMRestorePersistentVolumeClaim",
+			from:         "restored-pvc",
+			to:           "restore",
+			relationship: RelationshipHarvesterVMRestorePersistentVolumeClaim,
+		},
+		{
+			name:         "VMRestoreTarget",
+			from:         "vm",
+			to:           "restore",
+			relationship: RelationshipHarvesterVMRestoreTarget,
+		},
+		{
+			name:         "VMTemplateDefaultVersion",
+			from:         "template",
+			to:           "template-version",
+			relationship: RelationshipHarvesterVMTemplateDefaultVersion,
+		},
+		{
+			name:         "VMTemplateVersionImage",
+			from:         "template-version",
+			to:           "image",
+			relationship: RelationshipHarvesterVMTemplateVersionImage,
+		},
+		{
+			name:         "VMTemplateVersionKeyPair",
+			from:         "template-version",
+			to:           "key-pair",
+			relationship: RelationshipHarvesterVMTemplateVersionKeyPair,
+		},
+		{
+			name:         "VMTemplateVersionTemplate",
+			from:         "template-version",
+			to:           "template",
+			relationship: RelationshipHarvesterVMTemplateVersionTemplate,
+		},
+		{
+			name:         "VlanConfigClusterNetwork",
+			from:         "vlan-config",
+			to:           "cluster-network",
+			relationship: RelationshipHarvesterVlanConfigClusterNetwork,
+		},
+		{
+			name:         "VlanConfigNode",
+			from:         "vlan-config",
+			to:           "node",
+			relationship: RelationshipHarvesterVlanConfigNode,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; !ok {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\", got %v", tt.relationship, tt.from, tt.to, from.Dependencies)
+			}
+		})
+	}
+}
+
+func TestResolveMultusRelationships(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("nad-a", "k8s.cni.cncf.io/v1", "NetworkAttachmentDefinition", "default", "net-a", nil),
+		newTestObject("nad-b", "k8s.cni.cncf.io/v1", "NetworkAttachmentDefinition", "kube-system", "net-b", nil),
+		newTestObject("nad-c", "k8s.cni.cncf.io/v1", "NetworkAttachmentDefinition", "default", "net-c", nil),
+		newTestObject("pod-json", "v1", "Pod", "default", "pod-json", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					MultusNetworksAnnotation: `[{"name":"net-a","interface":"eth1"},{"name":"net-b","namespace":"kube-system"}]`,
+				},
+			},
+		}),
+		newTestObject("pod-list", "v1", "Pod", "default", "pod-list", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					MultusNetworksAnnotation: "net-a, kube-system/net-b@net2",
+				},
+			},
+		}),
+		newTestObject("pod-status", "v1", "Pod", "default", "pod-status", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					MultusNetworkStatusAnnotation: `[{"name":"cbr0","interface":"eth0"},{"name":"default/net-c","interface":"net1"}]`,
+				},
+			},
+		}),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+	}{
+		{
+			name:         "JSONWithInterface",
+			from:         "pod-json",
+			to:           "nad-a",
+			relationship: withEdgeMetadata(RelationshipPodNetworkAttachmentDefinition, "eth1"),
+		},
+		{
+			name:         "JSONWithNamespace",
+			from:         "pod-json",
+			to:           "nad-b",
+			relationship: RelationshipPodNetworkAttachmentDefinition,
+		},
+		{
+			name:         "ListWithoutNamespace",
+			from:         "pod-list",
+			to:           "nad-a",
+			relationship: RelationshipPodNetworkAttachmentDefinition,
+		},
+		{
+			name:         "ListWithNamespaceAndInterface",
+			from:         "pod-list",
+			to:           "nad-b",
+			relationship: withEdgeMetadata(RelationshipPodNetworkAttachmentDefinition, "net2"),
+		},
+		{
+			name:         "NetworkStatus",
+			from:         "pod-status",
+			to:           "nad-c",
+			relationship: withEdgeMetadata(RelationshipPodNetworkAttachmentDefinition, "net1"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; !ok {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\", got %v", tt.relationship, tt.from, tt.to, from.Dependencies)
+			}
+		})
+	}
+}
+
+func TestReferenceGrantAllows(t *testing.T) {
+	t.Parallel()
+
+	newGrant := func(to map[string]interface{}) *Node {
+		obj := newTestObject("grant", "gateway.networking.k8s.io/v1beta1", "ReferenceGrant", "backend", "grant", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"from": []interface{}{
+					map[string]interface{}{"group": GatewayAPIGroupName, "kind": "HTTPRoute", "namespace": "frontend"},
+				},
+				"to": []interface{}{to},
+			},
+		})
+		return &Node{Unstructured: &obj}
+	}
+	route := &Node{Group: GatewayAPIGroupName, Kind: "HTTPRoute", Namespace: "frontend", Name: "route"}
+	otherRoute := &Node{Group: GatewayAPIGroupName, Kind: "HTTPRoute", Namespace: "other", Name: "route"}
+	gateway := &Node{Group: GatewayAPIGroupName, Kind: "Gateway", Namespace: "frontend", Name: "gateway"}
+	svc := &Node{Kind: "Service", Namespace: "backend", Name: "svc"}
+	secret := &Node{Kind: "Secret", Namespace: "backend", Name: "cert"}
+
+	tests := []struct {
+		name     string
+		grant    *Node
+		from     *Node
+		to       *Node
+		expected bool
+	}{
+		{name: "AnyName", grant: newGrant(map[string]interface{}{"group": "", "kind": "Service"}), from: route, to: svc, expected: true},
+		{name: "MatchingName", grant: newGrant(map[string]interface{}{"group": "", "kind": "Service", "name": "svc"}), from: route, to: svc, expected: true},
+		{name: "OtherName", grant: newGrant(map[string]interface{}{"group": "", "kind": "Service", "name": "other"}), from: route, to: svc, expected: false},
+		{name: "OtherToKind", grant: newGrant(map[string]interface{}{"group": "", "kind": "Service"}), from: route, to: secret, expected: false},
+		{name: "OtherFromKind", grant: newGrant(map[string]interface{}{"group": "", "kind": "Service"}), from: gateway, to: svc, expected: false},
+		{name: "OtherFromNamespace", grant: newGrant(map[string]interface{}{"group": "", "kind": "Service"}), from: otherRoute, to: svc, expected: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := referenceGrantAllows(tt.grant, tt.from, tt.to); got != tt.expected {
+				t.Fatalf("expected %t got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResolveGatewayAPIReferenceGrants(t *testing.T) {
+	t.Parallel()
+
+	newRoute := func(uid types.UID, ns string) unstructuredv1.Unstructured {
+		return newTestObject(uid, "gateway.networking.k8s.io/v1", "HTTPRoute", ns, "route", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{
+						"backendRefs": []interface{}{
+							map[string]interface{}{"name": "svc", "namespace": "backend"},
+						},
+					},
+				},
+			},
+		})
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("svc", "v1", "Service", "backend", "svc", nil),
+		newTestObject("local-route", "gateway.networking.k8s.io/v1", "HTTPRoute", "backend", "local", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{
+						"backendRefs": []interface{}{map[string]interface{}{"name": "svc"}},
+					},
+				},
+			},
+		}),
+		newRoute("granted-route", "frontend"),
+		newRoute("ungranted-route", "other"),
+		newTestObject("grant", "gateway.networking.k8s.io/v1beta1", "ReferenceGrant", "backend", "grant", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"from": []interface{}{
+					map[string]interface{}{"group": GatewayAPIGroupName, "kind": "HTTPRoute", "namespace": "frontend"},
+				},
+				"to": []interface{}{map[string]interface{}{"group": "", "kind": "Service"}},
+			},
+		}),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		from     types.UID
+		expected bool
+	}{
+		{name: "SameNamespace", from: "local-route", expected: true},
+		{name: "GrantedNamespace", from: "granted-route", expected: true},
+		{name: "UngrantedNamespace", from: "ungranted-route", expected: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies["svc"][RelationshipGatewayAPIRouteBackend]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"%s\" to \"svc\" to be %t, got %v", RelationshipGatewayAPIRouteBackend, tt.from, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolveNetworkPolicyPeers(t *testing.T) {
+	t.Parallel()
+
+	webLabels := map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web"}},
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("ns-frontend", "v1", "Namespace", "", "frontend", map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"team": "web"}},
+		}),
+		newTestObject("ns-other", "v1", "Namespace", "", "other", nil),
+		newTestObject("ns-backend", "v1", "Namespace", "", "backend", nil),
+		newTestObject("pod-backend", "v1", "Pod", "backend", "web", webLabels),
+		newTestObject("pod-frontend", "v1", "Pod", "frontend", "web", webLabels),
+		newTestObject("pod-other", "v1", "Pod", "other", "web", webLabels),
+		newTestObject("pod-db", "v1", "Pod", "backend", "db", map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "db"}},
+		}),
+		newTestObject("netpol", "networking.k8s.io/v1", "NetworkPolicy", "backend", "netpol", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"podSelector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{"app": "db"},
+				},
+				"ingress": []interface{}{
+					map[string]interface{}{
+						"from": []interface{}{
+							map[string]interface{}{
+								"namespaceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"team": "web"}},
+								"podSelector":       map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+							},
+							map[string]interface{}{
+								"podSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+							},
+						},
+					},
+				},
+				"egress": []interface{}{
+					map[string]interface{}{
+						"to": []interface{}{
+							map[string]interface{}{"namespaceSelector": map[string]interface{}{}},
+							map[string]interface{}{"ipBlock": map[string]interface{}{"cidr": "10.0.0.0/8"}},
+						},
+					},
+				},
+			},
+		}),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		to           types.UID
+		relationship Relationship
+		expected     bool
+	}{
+		{
+			name:         "SelectedPod",
+			to:           "pod-db",
+			relationship: RelationshipNetworkPolicy,
+			expected:     true,
+		},
+		{
+			name:         "UnselectedPod",
+			to:           "pod-backend",
+			relationship: RelationshipNetworkPolicy,
+			expected:     false,
+		},
+		{
+			name:         "IngressPeerInSameNamespace",
+			to:           "pod-backend",
+			relationship: RelationshipNetworkPolicyIngressPeerOf,
+			expected:     true,
+		},
+		{
+			name:         "IngressPeerInSelectedNamespace",
+			to:           "pod-frontend",
+			relationship: RelationshipNetworkPolicyIngressPeerOf,
+			expected:     true,
+		},
+		{
+			name:         "IngressPeerInUnselectedNamespace",
+			to:           "pod-other",
+			relationship: RelationshipNetworkPolicyIngressPeerOf,
+			expected:     false,
+		},
+		{
+			name:         "EgressPeerInAnyNamespace",
+			to:           "pod-other",
+			relationship: RelationshipNetworkPolicyEgressPeerOf,
+			expected:     true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from := nodeMap["netpol"]
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"netpol\" to \"%s\" to be %t, got %v", tt.relationship, tt.to, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolveAccess(t *testing.T) {
+	t.Parallel()
+
+	saSubject := []interface{}{
+		map[string]interface{}{"kind": "ServiceAccount", "name": "app", "namespace": "default"},
+	}
+	newRules := func(resource, verb, resourceName string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"apiGroups":     []interface{}{""},
+				"resources":     []interface{}{resource},
+				"resourceNames": []interface{}{resourceName},
+				"verbs":         []interface{}{verb},
+			},
+		}
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("sa", "v1", "ServiceAccount", "default", "app", nil),
+		newTestObject("rb", "rbac.authorization.k8s.io/v1", "RoleBinding", "default", "pod-reader", map[string]interface{}{
+			"subjects": saSubject,
+			"roleRef":  map[string]interface{}{"apiGroup": "rbac.authorization.k8s.io", "kind": "ClusterRole", "name": "pod-reader"},
+		}),
+		newTestObject("crb", "rbac.authorization.k8s.io/v1", "ClusterRoleBinding", "", "aggregate", map[string]interface{}{
+			"subjects": saSubject,
+			"roleRef":  map[string]interface{}{"apiGroup": "rbac.authorization.k8s.io", "kind": "ClusterRole", "name": "aggregate"},
+		}),
+		newTestObject("cr-pod-reader", "rbac.authorization.k8s.io/v1", "ClusterRole", "", "pod-reader", map[string]interface{}{
+			"rules": newRules("pods", "get", "pod"),
+		}),
+		newTestObject("cr-aggregate", "rbac.authorization.k8s.io/v1", "ClusterRole", "", "aggregate", map[string]interface{}{
+			"aggregationRule": map[string]interface{}{
+				"clusterRoleSelectors": []interface{}{
+					map[string]interface{}{"matchLabels": map[string]interface{}{"aggregate": "true"}},
+				},
+			},
+			"rules": newRules("secrets", "list", "secret"),
+		}),
+		newTestObject("cr-part", "rbac.authorization.k8s.io/v1", "ClusterRole", "", "part", map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"aggregate": "true"}},
+			"rules":    newRules("configmaps", "get", "cm"),
+		}),
+		newTestObject("cr-unbound", "rbac.authorization.k8s.io/v1", "ClusterRole", "", "unbound", map[string]interface{}{
+			"rules": newRules("pods", "delete", "pod"),
+		}),
+		newTestObject("pod-default", "v1", "Pod", "default", "pod", nil),
+		newTestObject("pod-other", "v1", "Pod", "other", "pod", nil),
+		newTestObject("secret-other", "v1", "Secret", "other", "secret", nil),
+		newTestObject("cm-other", "v1", "ConfigMap", "other", "cm", nil),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+	result, rules, err := ResolveAccess(nodeMap, "sa")
+	if err != nil {
+		t.Fatalf("failed to resolve access: %v", err)
+	}
+
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules got %d: %v", len(rules), rules)
+	}
+	for _, r := range rules {
+		expectedNS := ""
+		if r.Binding.UID == "rb" {
+			expectedNS = "default"
+		}
+		if r.Namespace != expectedNS {
+			t.Fatalf("expected rule %s of %s to apply to namespace \"%s\" got \"%s\"", r.PolicyRule.String(), r.Binding.Name, expectedNS, r.Namespace)
+		}
+	}
+
+	for _, uid := range []types.UID{"pod-other", "cr-unbound"} {
+		if _, ok := result[uid]; ok {
+			t.Fatalf("unexpected object with UID \"%s\" in result", uid)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+	}{
+		{
+			name:         "RoleBindingSubject",
+			from:         "sa",
+			to:           "rb",
+			relationship: RelationshipRoleBindingSubject,
+		},
+		{
+			name:         "ClusterRoleBindingSubject",
+			from:         "sa",
+			to:           "crb",
+			relationship: RelationshipClusterRoleBindingSubject,
+		},
+		{
+			name:         "RoleBindingRole",
+			from:         "rb",
+			to:           "cr-pod-reader",
+			relationship: RelationshipRoleBindingRole,
+		},
+		{
+			name:         "AggregationRule",
+			from:         "cr-aggregate",
+			to:           "cr-part",
+			relationship: RelationshipClusterRoleAggregationRule,
+		},
+		{
+			name:         "PolicyRuleInBindingNamespace",
+			from:         "cr-pod-reader",
+			to:           "pod-default",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRule, "get"),
+		},
+		{
+			name:         "ClusterWidePolicyRule",
+			from:         "cr-aggregate",
+			to:           "secret-other",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRule, "list"),
+		},
+		{
+			name:         "AggregatedPolicyRule",
+			from:         "cr-part",
+			to:           "cm-other",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRule, "get"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := result[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; !ok {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\", got %v", tt.relationship, tt.from, tt.to, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolveAdmissionWebhookRules(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("vwc", "admissionregistration.k8s.io/v1", "ValidatingWebhookConfiguration", "", "pods", map[string]interface{}{
+			"webhooks": []interface{}{
+				map[string]interface{}{
+					"name": "pods.example.com",
+					"clientConfig": map[string]interface{}{
+						"service": map[string]interface{}{"namespace": "default", "name": "webhook"},
+					},
+					"namespaceSelector": map[string]interface{}{
+						"matchLabels": map[string]interface{}{"env": "prod"},
+					},
+					"rules": []interface{}{
+						map[string]interface{}{
+							"apiGroups":   []interface{}{""},
+							"apiVersions": []interface{}{"v1"},
+							"resources":   []interface{}{"pods"},
+							"operations":  []interface{}{"CREATE"},
+						},
+					},
+				},
+			},
+		}),
+		newTestObject("svc", "v1", "Service", "default", "webhook", nil),
+		newTestObject("ns-prod", "v1", "Namespace", "", "prod", map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"env": "prod"}},
+		}),
+		newTestObject("ns-dev", "v1", "Namespace", "", "dev", map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"env": "dev"}},
+		}),
+		newTestObject("pod-prod", "v1", "Pod", "prod", "web", nil),
+		newTestObject("pod-dev", "v1", "Pod", "dev", "web", nil),
+		newTestObject("cm-prod", "v1", "ConfigMap", "prod", "web", nil),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	rule := withEdgeMetadata(RelationshipWebhookConfigurationRule, "pods.example.com")
+	tests := []struct {
+		name     string
+		from     types.UID
+		to       types.UID
+		expected bool
+	}{
+		{name: "InterceptedObject", from: "pod-prod", to: "vwc", expected: true},
+		{name: "UnselectedNamespace", from: "pod-dev", to: "vwc", expected: false},
+		{name: "UnmatchedResource", from: "cm-prod", to: "vwc", expected: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][rule]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\" to be %t, got %v", rule, tt.from, tt.to, tt.expected, from.Dependencies)
+			}
+		})
+	}
+	t.Run("WebhookDependents", func(t *testing.T) {
+		t.Parallel()
+		if deps := nodeMap["vwc"].Dependents; len(deps) > 0 {
+			t.Fatalf("expected no dependents of the webhook configuration, got %v", deps)
+		}
+	})
+	t.Run("ServiceDependents", func(t *testing.T) {
+		t.Parallel()
+		subtree := getSubtree(nodeMap, []types.UID{"svc"}, false)
+		if _, ok := subtree["pod-prod"]; ok {
+			t.Fatalf("expected intercepted pod to be excluded from the dependents of the webhook service, got %v", subtree)
+		}
+	})
+}
+
+//nolint:funlen
+func TestObjectAdmissionSelectorMatches(t *testing.T) {
+	t.Parallel()
+
+	newNode := func(group, resource, kind, ns, name string, objLabels map[string]interface{}) *Node {
+		obj := newTestObject("", "v1", kind, ns, name, map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": objLabels},
+		})
+		return &Node{Unstructured: &obj, Group: group, Resource: resource, Kind: kind, Namespace: ns, Name: name}
+	}
+	anyRule := ObjectAdmissionRule{APIGroups: sets.NewString("*"), Resources: sets.NewString("*")}
+	podRule := ObjectAdmissionRule{APIGroups: sets.NewString(""), Resources: sets.NewString("pods")}
+	envSelector := labels.SelectorFromSet(labels.Set{"env": "prod"})
+
+	pod := newNode("", "pods", "Pod", "default", "web", map[string]interface{}{"app": "web"})
+	node := newNode("", "nodes", "Node", "", "node-1", nil)
+	prodNamespace := newNode("", "namespaces", "Namespace", "", "prod", map[string]interface{}{"env": "prod"})
+	devNamespace := newNode("", "namespaces", "Namespace", "", "dev", map[string]interface{}{"env": "dev"})
+	tests := []struct {
+		name            string
+		selector        ObjectAdmissionSelector
+		node            *Node
+		namespaceLabels labels.Set
+		expected        bool
+	}{
+		{
+			name:     "MatchingRule",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{podRule}},
+			node:     pod,
+			expected: true,
+		},
+		{
+			name: "OtherResource",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{
+				{APIGroups: sets.NewString(""), Resources: sets.NewString("secrets")},
+			}},
+			node:     pod,
+			expected: false,
+		},
+		{
+			name: "Subresource",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{
+				{APIGroups: sets.NewString(""), Resources: sets.NewString("pods/status")},
+			}},
+			node:     pod,
+			expected: false,
+		},
+		{
+			name: "AllResourcesAndSubresources",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{
+				{APIGroups: sets.NewString("*"), Resources: sets.NewString("*/*")},
+			}},
+			node:     pod,
+			expected: true,
+		},
+		{
+			name:     "ExcludedRule",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{anyRule}, ExcludeRules: []ObjectAdmissionRule{podRule}},
+			node:     pod,
+			expected: false,
+		},
+		{
+			name: "OtherResourceName",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{
+				{APIGroups: sets.NewString(""), Resources: sets.NewString("pods"), ResourceNames: sets.NewString("api")},
+			}},
+			node:     pod,
+			expected: false,
+		},
+		{
+			name: "ClusterScopeNamespacedObject",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{
+				{APIGroups: sets.NewString("*"), Resources: sets.NewString("*"), Scope: "Cluster"},
+			}},
+			node:     pod,
+			expected: false,
+		},
+		{
+			name: "ClusterScopeClusterScopedObject",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{
+				{APIGroups: sets.NewString("*"), Resources: sets.NewString("*"), Scope: "Cluster"},
+			}},
+			node:     node,
+			expected: true,
+		},
+		{
+			name: "NamespacedScopeClusterScopedObject",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{
+				{APIGroups: sets.NewString("*"), Resources: sets.NewString("*"), Scope: "Namespaced"},
+			}},
+			node:     node,
+			expected: false,
+		},
+		{
+			name: "AnyScopeClusterScopedObject",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{
+				{APIGroups: sets.NewString("*"), Resources: sets.NewString("*"), Scope: "*"},
+			}},
+			node:     node,
+			expected: true,
+		},
+		{
+			name:            "MatchingNamespaceSelector",
+			selector:        ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{podRule}, NamespaceSelector: envSelector},
+			node:            pod,
+			namespaceLabels: labels.Set{"env": "prod"},
+			expected:        true,
+		},
+		{
+			name:            "OtherNamespaceSelector",
+			selector:        ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{podRule}, NamespaceSelector: envSelector},
+			node:            pod,
+			namespaceLabels: labels.Set{"env": "dev"},
+			expected:        false,
+		},
+		{
+			name:     "UnknownNamespace",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{podRule}, NamespaceSelector: envSelector},
+			node:     pod,
+			expected: false,
+		},
+		{
+			name:     "EmptyNamespaceSelectorUnknownNamespace",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{podRule}, NamespaceSelector: labels.Everything()},
+			node:     pod,
+			expected: true,
+		},
+		{
+			name:     "NamespaceSelectorMatchingNamespaceObject",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{anyRule}, NamespaceSelector: envSelector},
+			node:     prodNamespace,
+			expected: true,
+		},
+		{
+			name:     "NamespaceSelectorOtherNamespaceObject",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{anyRule}, NamespaceSelector: envSelector},
+			node:     devNamespace,
+			expected: false,
+		},
+		{
+			name:     "NamespaceSelectorClusterScopedObject",
+			selector: ObjectAdmissionSelector{Rules: []ObjectAdmissionRule{anyRule}, NamespaceSelector: envSelector},
+			node:     node,
+			expected: true,
+		},
+		{
+			name: "MatchingObjectSelector",
+			selector: ObjectAdmissionSelector{
+				Rules:    []ObjectAdmissionRule{podRule},
+				Selector: labels.SelectorFromSet(labels.Set{"app": "web"}),
+			},
+			node:     pod,
+			expected: true,
+		},
+		{
+			name: "OtherObjectSelector",
+			selector: ObjectAdmissionSelector{
+				Rules:    []ObjectAdmissionRule{podRule},
+				Selector: labels.SelectorFromSet(labels.Set{"app": "api"}),
+			},
+			node:     pod,
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.selector.Matches(tt.node, tt.namespaceLabels); got != tt.expected {
+				t.Fatalf("expected %t got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestObjectPolicyRuleSelectorMatches(t *testing.T) {
+	t.Parallel()
+
+	pod := &Node{Group: "", Resource: "pods", Namespace: "default", Name: "web"}
+	node := &Node{Group: "", Resource: "nodes", Name: "node-1"}
+	deploy := &Node{Group: "apps", Resource: "deployments", Namespace: "default", Name: "web"}
+	tests := []struct {
+		name     string
+		selector ObjectPolicyRuleSelector
+		node     *Node
+		expected bool
+	}{
+		{
+			name:     "MatchingResource",
+			selector: ObjectPolicyRuleSelector{APIGroups: sets.NewString(""), Resources: sets.NewString("pods")},
+			node:     pod,
+			expected: true,
+		},
+		{
+			name:     "OtherResource",
+			selector: ObjectPolicyRuleSelector{APIGroups: sets.NewString(""), Resources: sets.NewString("secrets")},
+			node:     pod,
+			expected: false,
+		},
+		{
+			name:     "OtherAPIGroup",
+			selector: ObjectPolicyRuleSelector{APIGroups: sets.NewString(""), Resources: sets.NewString("deployments")},
+			node:     deploy,
+			expected: false,
+		},
+		{
+			name:     "WildcardAPIGroup",
+			selector: ObjectPolicyRuleSelector{APIGroups: sets.NewString("*"), Resources: sets.NewString("deployments")},
+			node:     deploy,
+			expected: true,
+		},
+		{
+			name:     "WildcardResource",
+			selector: ObjectPolicyRuleSelector{APIGroups: sets.NewString("apps"), Resources: sets.NewString("*")},
+			node:     deploy,
+			expected: true,
+		},
+		{
+			name: "MatchingResourceName",
+			selector: ObjectPolicyRuleSelector{
+				APIGroups:     sets.NewString(""),
+				Resources:     sets.NewString("pods"),
+				ResourceNames: sets.NewString("web"),
+			},
+			node:     pod,
+			expected: true,
+		},
+		{
+			name: "OtherResourceName",
+			selector: ObjectPolicyRuleSelector{
+				APIGroups:     sets.NewString(""),
+				Resources:     sets.NewString("pods"),
+				ResourceNames: sets.NewString("api"),
+			},
+			node:     pod,
+			expected: false,
+		},
+		{
+			name:     "MatchingNamespace",
+			selector: ObjectPolicyRuleSelector{APIGroups: sets.NewString(""), Resources: sets.NewString("pods"), Namespace: "default"},
+			node:     pod,
+			expected: true,
+		},
+		{
+			name:     "OtherNamespace",
+			selector: ObjectPolicyRuleSelector{APIGroups: sets.NewString(""), Resources: sets.NewString("pods"), Namespace: "other"},
+			node:     pod,
+			expected: false,
+		},
+		{
+			name:     "ClusterScopedObjectWithNamespace",
+			selector: ObjectPolicyRuleSelector{APIGroups: sets.NewString(""), Resources: sets.NewString("nodes"), Namespace: "default"},
+			node:     node,
+			expected: false,
+		},
+		{
+			name:     "ClusterScopedObject",
+			selector: ObjectPolicyRuleSelector{APIGroups: sets.NewString(""), Resources: sets.NewString("nodes")},
+			node:     node,
+			expected: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.selector.Matches(tt.node); got != tt.expected {
+				t.Fatalf("expected %t got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolvePolicyRules(t *testing.T) {
+	t.Parallel()
+
+	newRule := func(apiGroup, resource, verb string, resourceNames ...interface{}) interface{} {
+		rule := map[string]interface{}{
+			"apiGroups": []interface{}{apiGroup},
+			"resources": []interface{}{resource},
+			"verbs":     []interface{}{verb},
+		}
+		if len(resourceNames) > 0 {
+			rule["resourceNames"] = resourceNames
+		}
+		return rule
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("cr", "rbac.authorization.k8s.io/v1", "ClusterRole", "", "cr", map[string]interface{}{
+			"rules": []interface{}{
+				newRule("", "pods/log", "get", "web"),
+				newRule("", "configmaps", "get", "allowed"),
+				newRule("", "secrets", "list"),
+				newRule("extensions", "podsecuritypolicies", "use"),
+			},
+		}),
+		newTestObject("cr-psp-get", "rbac.authorization.k8s.io/v1", "ClusterRole", "", "psp-get", map[string]interface{}{
+			"rules": []interface{}{newRule("policy", "podsecuritypolicies", "get")},
+		}),
+		newTestObject("cr-core-wildcard", "rbac.authorization.k8s.io/v1", "ClusterRole", "", "core-wildcard", map[string]interface{}{
+			"rules": []interface{}{newRule("", "*", "get")},
+		}),
+		newTestObject("cr-group-wildcard", "rbac.authorization.k8s.io/v1", "ClusterRole", "", "group-wildcard", map[string]interface{}{
+			"rules": []interface{}{newRule("example.com", "*", "get")},
+		}),
+		newTestObject("role", "rbac.authorization.k8s.io/v1", "Role", "default", "role", map[string]interface{}{
+			"rules": []interface{}{newRule("", "secrets", "get", "creds")},
+		}),
+		newTestObject("pod", "v1", "Pod", "default", "web", nil),
+		newTestObject("cm-allowed", "v1", "ConfigMap", "default", "allowed", nil),
+		newTestObject("cm-denied", "v1", "ConfigMap", "default", "denied", nil),
+		newTestObject("psp", "policy/v1beta1", "PodSecurityPolicy", "", "restricted", nil),
+		newTestObject("secret-default", "v1", "Secret", "default", "creds", nil),
+		newTestObject("secret-other", "v1", "Secret", "other", "creds", nil),
+		newTestObject("apisvc-core", "apiregistration.k8s.io/v1", "APIService", "", "v1", nil),
+		newTestObject("apisvc-apps", "apiregistration.k8s.io/v1", "APIService", "", "v1.apps", nil),
+		newTestObject("apisvc-example", "apiregistration.k8s.io/v1", "APIService", "", "v1.example.com", nil),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+		expected     bool
+	}{
+		{
+			name:         "Subresource",
+			from:         "cr",
+			to:           "pod",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRule, "get"),
+			expected:     true,
+		},
+		{
+			name:         "ResourceName",
+			from:         "cr",
+			to:           "cm-allowed",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRule, "get"),
+			expected:     true,
+		},
+		{
+			name:         "OtherResourceName",
+			from:         "cr",
+			to:           "cm-denied",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRule, "get"),
+			expected:     false,
+		},
+		{
+			name:         "ResourceWideRuleObject",
+			from:         "cr",
+			to:           "secret-default",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRule, "list"),
+			expected:     false,
+		},
+		{
+			name:         "ResourceWideRuleSummary",
+			from:         "cr",
+			to:           "apisvc-core",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRuleWildcard, "secrets:list"),
+			expected:     true,
+		},
+		{
+			name:         "PodSecurityPolicyAlias",
+			from:         "cr",
+			to:           "psp",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRule, "use"),
+			expected:     true,
+		},
+		{
+			name:         "PodSecurityPolicyWithoutUseVerb",
+			from:         "cr-psp-get",
+			to:           "psp",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRule, "get"),
+			expected:     false,
+		},
+		{
+			name:         "CoreGroupWildcard",
+			from:         "cr-core-wildcard",
+			to:           "apisvc-core",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRuleWildcard, "*:get"),
+			expected:     true,
+		},
+		{
+			name:         "CoreGroupWildcardOtherGroup",
+			from:         "cr-core-wildcard",
+			to:           "apisvc-apps",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRuleWildcard, "*:get"),
+			expected:     false,
+		},
+		{
+			name:         "GroupWildcard",
+			from:         "cr-group-wildcard",
+			to:           "apisvc-example",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRuleWildcard, "*:get"),
+			expected:     true,
+		},
+		{
+			name:         "GroupWildcardCoreGroup",
+			from:         "cr-group-wildcard",
+			to:           "apisvc-core",
+			relationship: withEdgeMetadata(RelationshipClusterRolePolicyRuleWildcard, "*:get"),
+			expected:     false,
+		},
+		{
+			name:         "RoleNamespace",
+			from:         "role",
+			to:           "secret-default",
+			relationship: withEdgeMetadata(RelationshipRolePolicyRule, "get"),
+			expected:     true,
+		},
+		{
+			name:         "RoleOtherNamespace",
+			from:         "role",
+			to:           "secret-other",
+			relationship: withEdgeMetadata(RelationshipRolePolicyRule, "get"),
+			expected:     false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\" to be %t, got %v", tt.relationship, tt.from, tt.to, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolveServiceEndpoints(t *testing.T) {
+	t.Parallel()
+
+	newAddresses := func(podName string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"ip":        "10.0.0.1",
+				"targetRef": map[string]interface{}{"kind": "Pod", "name": podName, "namespace": "default"},
+			},
+		}
+	}
+	newEndpoint := func(podName string, ready bool) interface{} {
+		return map[string]interface{}{
+			"addresses":  []interface{}{"10.0.0.1"},
+			"conditions": map[string]interface{}{"ready": ready},
+			"targetRef":  map[string]interface{}{"kind": "Pod", "name": podName, "namespace": "default"},
+		}
+	}
+	objects := []unstructuredv1.Unstructured{
+		// Selector-less Service whose endpoints are managed externally
+		newTestObject("svc", "v1", "Service", "default", "web", nil),
+		newTestObject("ep", "v1", "Endpoints", "default", "web", map[string]interface{}{
+			"subsets": []interface{}{
+				map[string]interface{}{
+					"addresses":         newAddresses("web-ready"),
+					"notReadyAddresses": newAddresses("web-not-ready"),
+				},
+			},
+		}),
+		newTestObject("eps", "discovery.k8s.io/v1", "EndpointSlice", "default", "web-abcde", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{"kubernetes.io/service-name": "web"},
+			},
+			"addressType": "IPv4",
+			"endpoints": []interface{}{
+				newEndpoint("web-ready", true),
+				newEndpoint("web-not-ready", false),
+			},
+		}),
+		newTestObject("pod-ready", "v1", "Pod", "default", "web-ready", nil),
+		newTestObject("pod-not-ready", "v1", "Pod", "default", "web-not-ready", nil),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+	}{
+		{
+			name:         "EndpointsService",
+			from:         "ep",
+			to:           "svc",
+			relationship: RelationshipEndpointsService,
+		},
+		{
+			name:         "EndpointsAddressReady",
+			from:         "pod-ready",
+			to:           "ep",
+			relationship: RelationshipEndpointsAddressReady,
+		},
+		{
+			name:         "EndpointsAddressNotReady",
+			from:         "pod-not-ready",
+			to:           "ep",
+			relationship: RelationshipEndpointsAddressNotReady,
+		},
+		{
+			name:         "EndpointSliceService",
+			from:         "eps",
+			to:           "svc",
+			relationship: RelationshipEndpointSliceService,
+		},
+		{
+			name:         "EndpointSliceEndpointReady",
+			from:         "pod-ready",
+			to:           "eps",
+			relationship: RelationshipEndpointSliceEndpointReady,
+		},
+		{
+			name:         "EndpointSliceEndpointNotReady",
+			from:         "pod-not-ready",
+			to:           "eps",
+			relationship: RelationshipEndpointSliceEndpointNotReady,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; !ok {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\", got %v", tt.relationship, tt.from, tt.to, from.Dependencies)
+			}
+		})
+	}
+
+	// The ready & not ready pods are found in the dependents of the Service
+	subtree := getSubtree(nodeMap, []types.UID{"svc"}, false)
+	for _, uid := range []types.UID{"ep", "eps", "pod-ready", "pod-not-ready"} {
+		if _, ok := subtree[uid]; !ok {
+			t.Fatalf("expected object with UID \"%s\" in the dependents of the Service, got %v", uid, subtree)
+		}
+	}
+}
+
+//nolint:funlen
+func TestResolveWorkloadTemplates(t *testing.T) {
+	t.Parallel()
+
+	podTemplate := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"serviceAccountName": "app",
+			"priorityClassName":  "high",
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":    "app",
+					"envFrom": []interface{}{map[string]interface{}{"configMapRef": map[string]interface{}{"name": "config"}}},
+				},
+			},
+			"volumes": []interface{}{
+				map[string]interface{}{"name": "creds", "secret": map[string]interface{}{"secretName": "creds"}},
+				map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "data"}},
+			},
+		},
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("deploy", "apps/v1", "Deployment", "default", "app", map[string]interface{}{
+			"spec": map[string]interface{}{"replicas": int64(0), "template": podTemplate},
+		}),
+		newTestObject("ds", "apps/v1", "DaemonSet", "default", "app", map[string]interface{}{
+			"spec": map[string]interface{}{"template": podTemplate},
+		}),
+		newTestObject("cj", "batch/v1", "CronJob", "default", "app", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"suspend":     true,
+				"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{"template": podTemplate}},
+			},
+		}),
+		newTestObject("cm", "v1", "ConfigMap", "default", "config", nil),
+		newTestObject("secret", "v1", "Secret", "default", "creds", nil),
+		newTestObject("pvc", "v1", "PersistentVolumeClaim", "default", "data", nil),
+		newTestObject("sa", "v1", "ServiceAccount", "default", "app", nil),
+		newTestObject("pc", "scheduling.k8s.io/v1", "PriorityClass", "", "high", nil),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	type edge struct {
+		to           types.UID
+		relationship Relationship
+	}
+	edges := []edge{
+		{to: "cm", relationship: RelationshipWorkloadTemplateContainerEnv},
+		{to: "secret", relationship: RelationshipWorkloadTemplateVolume},
+		{to: "pvc", relationship: RelationshipWorkloadTemplateVolume},
+		{to: "sa", relationship: RelationshipWorkloadTemplateServiceAccount},
+		{to: "pc", relationship: RelationshipWorkloadTemplatePriorityClass},
+	}
+	tests := []struct {
+		name string
+		from types.UID
+	}{
+		{name: "ScaledDownDeployment", from: "deploy"},
+		{name: "DaemonSet", from: "ds"},
+		{name: "SuspendedCronJobJobTemplate", from: "cj"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			for _, e := range edges {
+				if _, ok := from.Dependencies[e.to][e.relationship]; !ok {
+					t.Fatalf("expected %s relationship from \"%s\" to \"%s\", got %v", e.relationship, tt.from, e.to, from.Dependencies)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveStatefulSetVolumeClaims(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("sts", "apps/v1", "StatefulSet", "default", "web", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"replicas": int64(2),
+				"ordinals": map[string]interface{}{"start": int64(1)},
+				"volumeClaimTemplates": []interface{}{
+					map[string]interface{}{"metadata": map[string]interface{}{"name": "data"}},
+				},
+			},
+		}),
+		newTestObject("pvc-0", "v1", "PersistentVolumeClaim", "default", "data-web-0", nil),
+		newTestObject("pvc-1", "v1", "PersistentVolumeClaim", "default", "data-web-1", nil),
+		newTestObject("pvc-2", "v1", "PersistentVolumeClaim", "default", "data-web-2", nil),
+		newTestObject("pvc-3", "v1", "PersistentVolumeClaim", "default", "data-web-3", nil),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		relationship Relationship
+		expected     bool
+	}{
+		{
+			name:         "RetainedBelowStart",
+			from:         "pvc-0",
+			relationship: RelationshipStatefulSetRetainedVolumeClaim,
+			expected:     true,
+		},
+		{
+			name:         "NotActiveBelowStart",
+			from:         "pvc-0",
+			relationship: RelationshipStatefulSetVolumeClaimTemplate,
+			expected:     false,
+		},
+		{
+			name:         "ActiveFirstOrdinal",
+			from:         "pvc-1",
+			relationship: RelationshipStatefulSetVolumeClaimTemplate,
+			expected:     true,
+		},
+		{
+			name:         "NotRetainedFirstOrdinal",
+			from:         "pvc-1",
+			relationship: RelationshipStatefulSetRetainedVolumeClaim,
+			expected:     false,
+		},
+		{
+			name:         "ActiveLastOrdinal",
+			from:         "pvc-2",
+			relationship: RelationshipStatefulSetVolumeClaimTemplate,
+			expected:     true,
+		},
+		{
+			name:         "NotRetainedLastOrdinal",
+			from:         "pvc-2",
+			relationship: RelationshipStatefulSetRetainedVolumeClaim,
+			expected:     false,
+		},
+		{
+			name:         "RetainedScaledDown",
+			from:         "pvc-3",
+			relationship: RelationshipStatefulSetRetainedVolumeClaim,
+			expected:     true,
+		},
+		{
+			name:         "NotActiveScaledDown",
+			from:         "pvc-3",
+			relationship: RelationshipStatefulSetVolumeClaimTemplate,
+			expected:     false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies["sts"][tt.relationship]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"%s\" to \"sts\" to be %t, got %v", tt.relationship, tt.from, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolveHorizontalPodAutoscaler(t *testing.T) {
+	t.Parallel()
+
+	newHPA := func(uid types.UID, name string, target map[string]interface{}, metrics []interface{}) unstructuredv1.Unstructured {
+		return newTestObject(uid, "autoscaling/v2", "HorizontalPodAutoscaler", "default", name, map[string]interface{}{
+			"spec": map[string]interface{}{
+				"scaleTargetRef": target,
+				"maxReplicas":    int64(3),
+				"metrics":        metrics,
+			},
+		})
+	}
+	objects := []unstructuredv1.Unstructured{
+		newHPA("hpa", "web", map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web"}, []interface{}{
+			map[string]interface{}{
+				"type": "Object",
+				"object": map[string]interface{}{
+					"describedObject": map[string]interface{}{"apiVersion": "v1", "kind": "Service", "name": "web"},
+					"metric":          map[string]interface{}{"name": "requests-per-second"},
+					"target":          map[string]interface{}{"type": "Value", "value": "10"},
+				},
+			},
+			map[string]interface{}{
+				"type":     "Resource",
+				"resource": map[string]interface{}{"name": "cpu", "target": map[string]interface{}{"type": "Utilization", "averageUtilization": int64(80)}},
+			},
+		}),
+		newHPA("hpa-volume", "volume", map[string]interface{}{"apiVersion": "example.com/v1", "kind": "Volume", "name": "volume"}, nil),
+		newTestObject("deploy", "apps/v1", "Deployment", "default", "web", nil),
+		newTestObject("svc", "v1", "Service", "default", "web", nil),
+		newTestObject("example-volume", "example.com/v1", "Volume", "default", "volume", nil),
+		newTestObject("longhorn-volume", "longhorn.io/v1beta2", "Volume", "default", "volume", nil),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+		expected     bool
+	}{
+		{
+			name:         "ScaleTarget",
+			from:         "hpa",
+			to:           "deploy",
+			relationship: RelationshipHorizontalPodAutoscalerScaleTarget,
+			expected:     true,
+		},
+		{
+			name:         "ObjectMetric",
+			from:         "hpa",
+			to:           "svc",
+			relationship: RelationshipHorizontalPodAutoscalerMetricObject,
+			expected:     true,
+		},
+		{
+			name:         "ScaleTargetInGroup",
+			from:         "hpa-volume",
+			to:           "example-volume",
+			relationship: RelationshipHorizontalPodAutoscalerScaleTarget,
+			expected:     true,
+		},
+		{
+			name:         "ScaleTargetInOtherGroup",
+			from:         "hpa-volume",
+			to:           "longhorn-volume",
+			relationship: RelationshipHorizontalPodAutoscalerScaleTarget,
+			expected:     false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\" to be %t, got %v", tt.relationship, tt.from, tt.to, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+func TestResolveKEDAClusterTriggerAuthentication(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("cta", "keda.sh/v1alpha1", "ClusterTriggerAuthentication", "", "cta", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"secretTargetRef": []interface{}{
+					map[string]interface{}{"name": "creds", "key": "token", "parameter": "token"},
+				},
+			},
+		}),
+		newTestObject("secret-default", "v1", "Secret", DefaultKEDANamespace, "creds", nil),
+		newTestObject("secret-custom", "v1", "Secret", "autoscaling", "creds", nil),
+	}
+
+	tests := []struct {
+		name     string
+		opts     ResolveOptions
+		to       types.UID
+		expected bool
+	}{
+		{name: "DefaultNamespace", opts: ResolveOptions{}, to: "secret-default", expected: true},
+		{name: "DefaultNamespaceIgnoresCustom", opts: ResolveOptions{}, to: "secret-custom", expected: false},
+		{name: "CustomNamespace", opts: ResolveOptions{KEDANamespace: "autoscaling"}, to: "secret-custom", expected: true},
+		{name: "CustomNamespaceIgnoresDefault", opts: ResolveOptions{KEDANamespace: "autoscaling"}, to: "secret-default", expected: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, tt.opts)
+			if err != nil {
+				t.Fatalf("failed to resolve relationships: %v", err)
+			}
+			from := nodeMap["cta"]
+			if _, ok := from.Dependencies[tt.to][RelationshipKEDATriggerAuthenticationSecret]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"cta\" to \"%s\" to be %t, got %v", RelationshipKEDATriggerAuthenticationSecret, tt.to, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+func TestResolveAPIServiceCustomResources(t *testing.T) {
+	t.Parallel()
+
+	newAPIService := func(uid types.UID, version string) unstructuredv1.Unstructured {
+		return newTestObject(uid, "apiregistration.k8s.io/v1", "APIService", "", version+".example.com", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{AutoManagedAPIServiceLabel: "true"},
+			},
+			"spec": map[string]interface{}{"group": "example.com", "version": version},
+		})
+	}
+	objects := []unstructuredv1.Unstructured{
+		newAPIService("apisvc-v1", "v1"),
+		newAPIService("apisvc-v1beta1", "v1beta1"),
+		newTestObject("vol", "example.com/v1", "Volume", "default", "data", nil),
+	}
+
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		to       types.UID
+		expected bool
+	}{
+		{name: "ServedVersion", to: "apisvc-v1", expected: true},
+		{name: "UnservedVersion", to: "apisvc-v1beta1", expected: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from := nodeMap["vol"]
+			if _, ok := from.Dependencies[tt.to][RelationshipAPIServiceCustomResource]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"vol\" to \"%s\" to be %t, got %v", RelationshipAPIServiceCustomResource, tt.to, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolveKubeVirt(t *testing.T) {
+	t.Parallel()
+
+	vmiSpec := map[string]interface{}{
+		"domain": map[string]interface{}{"devices": map[string]interface{}{}},
+		"volumes": []interface{}{
+			map[string]interface{}{
+				"name":             "cloudinit",
+				"cloudInitNoCloud": map[string]interface{}{"userData": "#cloud-config"},
+			},
+			map[string]interface{}{
+				"name": "configdrive",
+				"cloudInitConfigDrive": map[string]interface{}{
+					"networkDataSecretRef": map[string]interface{}{"name": "network-data"},
+				},
+			},
+			map[string]interface{}{
+				"name":      "config",
+				"configMap": map[string]interface{}{"name": "settings"},
+			},
+			map[string]interface{}{
+				"name":           "sa",
+				"serviceAccount": map[string]interface{}{"serviceAccountName": "vm"},
+			},
+			map[string]interface{}{
+				"name":       "rootdisk",
+				"dataVolume": map[string]interface{}{"name": "rootdisk"},
+			},
+		},
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("vm", "kubevirt.io/v1", "VirtualMachine", "default", "vm", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"dataVolumeTemplates": []interface{}{
+					map[string]interface{}{"metadata": map[string]interface{}{"name": "rootdisk"}},
+				},
+				"instancetype": map[string]interface{}{"name": "u1.medium"},
+				"preference":   map[string]interface{}{"name": "fedora", "kind": "VirtualMachinePreference"},
+				"template":     map[string]interface{}{"spec": vmiSpec},
+			},
+		}),
+		newTestObject("vmi", "kubevirt.io/v1", "VirtualMachineInstance", "default", "vm", map[string]interface{}{
+			"spec":   vmiSpec,
+			"status": map[string]interface{}{"nodeName": "node-1"},
+		}),
+		newTestObject("migration", "kubevirt.io/v1", "VirtualMachineInstanceMigration", "default", "migration", map[string]interface{}{
+			"spec": map[string]interface{}{"vmiName": "vm"},
+		}),
+		newTestObject("pod", "v1", "Pod", "default", "virt-launcher-vm", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{kubeVirtCreatedByLabel: "vmi"},
+			},
+		}),
+		newTestObject("node", "v1", "Node", "", "node-1", nil),
+		newTestObject("secret", "v1", "Secret", "default", "network-data", nil),
+		newTestObject("cm", "v1", "ConfigMap", "default", "settings", nil),
+		newTestObject("sa", "v1", "ServiceAccount", "default", "vm", nil),
+		newTestObject("dv", "cdi.kubevirt.io/v1beta1", "DataVolume", "default", "rootdisk", nil),
+		newTestObject("instancetype", "instancetype.kubevirt.io/v1beta1", "VirtualMachineClusterInstancetype", "", "u1.medium", nil),
+		newTestObject("preference", "instancetype.kubevirt.io/v1beta1", "VirtualMachinePreference", "default", "fedora", nil),
+	}
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+	}{
+		{
+			name:         "CloudInitConfigDriveNetworkDataSecret",
+			from:         "secret",
+			to:           "vm",
+			relationship: RelationshipVMCloudInitSecret,
+		},
+		{
+			name:         "ConfigMapVolume",
+			from:         "cm",
+			to:           "vm",
+			relationship: RelationshipVMConfigMap,
+		},
+		{
+			name:         "ServiceAccountVolume",
+			from:         "sa",
+			to:           "vmi",
+			relationship: RelationshipVMServiceAccount,
+		},
+		{
+			name:         "DataVolume",
+			from:         "dv",
+			to:           "vmi",
+			relationship: RelationshipVMDataVolume,
+		},
+		{
+			name:         "DataVolumeTemplate",
+			from:         "dv",
+			to:           "vm",
+			relationship: RelationshipVMDataVolumeTemplate,
+		},
+		{
+			name:         "ClusterInstancetype",
+			from:         "vm",
+			to:           "instancetype",
+			relationship: RelationshipVMInstancetype,
+		},
+		{
+			name:         "NamespacedPreference",
+			from:         "vm",
+			to:           "preference",
+			relationship: RelationshipVMPreference,
+		},
+		{
+			name:         "VMINode",
+			from:         "vmi",
+			to:           "node",
+			relationship: RelationshipVMINode,
+		},
+		{
+			name:         "VMIPod",
+			from:         "pod",
+			to:           "vmi",
+			relationship: RelationshipVMIPod,
+		},
+		{
+			name:         "VMIMigration",
+			from:         "migration",
+			to:           "vmi",
+			relationship: RelationshipVMIMigrationVMI,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; !ok {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\", got %v", tt.relationship, tt.from, tt.to, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolvePersistentVolumeClaims(t *testing.T) {
+	t.Parallel()
+
+	newSnapshotRef := func(name, ns string) map[string]interface{} {
+		ref := map[string]interface{}{"apiGroup": SnapshotGroupName, "kind": "VolumeSnapshot", "name": name}
+		if len(ns) > 0 {
+			ref["namespace"] = ns
+		}
+		return ref
+	}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("node", "v1", "Node", "", "node-1", nil),
+		newTestObject("sc", "storage.k8s.io/v1", "StorageClass", "", "standard", nil),
+		newTestObject("snapshot", "snapshot.storage.k8s.io/v1", "VolumeSnapshot", "default", "snapshot", nil),
+		newTestObject("snapshot-ref", "snapshot.storage.k8s.io/v1", "VolumeSnapshot", "default", "snapshot-ref", nil),
+		newTestObject("snapshot-backups", "snapshot.storage.k8s.io/v1", "VolumeSnapshot", "backups", "snapshot", nil),
+		newTestObject("pvc", "v1", "PersistentVolumeClaim", "default", "data", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{SelectedNodeAnnotation: "node-1"},
+			},
+			"spec": map[string]interface{}{"storageClassName": "standard"},
+		}),
+		newTestObject("pvc-both", "v1", "PersistentVolumeClaim", "default", "both", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"dataSource":    newSnapshotRef("snapshot", ""),
+				"dataSourceRef": newSnapshotRef("snapshot-ref", ""),
+			},
+		}),
+		newTestObject("pvc-granted", "v1", "PersistentVolumeClaim", "default", "granted", map[string]interface{}{
+			"spec": map[string]interface{}{"dataSourceRef": newSnapshotRef("snapshot", "backups")},
+		}),
+		newTestObject("pvc-ungranted", "v1", "PersistentVolumeClaim", "other", "ungranted", map[string]interface{}{
+			"spec": map[string]interface{}{"dataSourceRef": newSnapshotRef("snapshot", "backups")},
+		}),
+		newTestObject("grant", "gateway.networking.k8s.io/v1beta1", "ReferenceGrant", "backups", "grant", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"from": []interface{}{
+					map[string]interface{}{"group": "", "kind": "PersistentVolumeClaim", "namespace": "default"},
+				},
+				"to": []interface{}{map[string]interface{}{"group": SnapshotGroupName, "kind": "VolumeSnapshot"}},
+			},
+		}),
+		newTestObject("pod", "v1", "Pod", "default", "web", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"name": "web", "image": "nginx"}},
+				"volumes": []interface{}{
+					map[string]interface{}{
+						"name": "scratch",
+						"ephemeral": map[string]interface{}{
+							"volumeClaimTemplate": map[string]interface{}{"spec": map[string]interface{}{}},
+						},
+					},
+				},
+			},
+		}),
+		newTestObject("pvc-ephemeral", "v1", "PersistentVolumeClaim", "default", "web-scratch", nil),
+		newTestObject("pvc-ephemeral-other", "v1", "PersistentVolumeClaim", "default", "scratch", nil),
+	}
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+		expected     bool
+	}{
+		{
+			name:         "StorageClass",
+			from:         "pvc",
+			to:           "sc",
+			relationship: RelationshipPersistentVolumeClaimStorageClass,
+			expected:     true,
+		},
+		{
+			name:         "SelectedNode",
+			from:         "pvc",
+			to:           "node",
+			relationship: RelationshipPersistentVolumeClaimSelectedNode,
+			expected:     true,
+		},
+		{
+			name:         "DataSourceRefTakesPrecedence",
+			from:         "pvc-both",
+			to:           "snapshot-ref",
+			relationship: RelationshipPersistentVolumeClaimDataSource,
+			expected:     true,
+		},
+		{
+			name:         "DataSourceIgnoredWithDataSourceRef",
+			from:         "pvc-both",
+			to:           "snapshot",
+			relationship: RelationshipPersistentVolumeClaimDataSource,
+			expected:     false,
+		},
+		{
+			name:         "DataSourceRefInGrantedNamespace",
+			from:         "pvc-granted",
+			to:           "snapshot-backups",
+			relationship: RelationshipPersistentVolumeClaimDataSource,
+			expected:     true,
+		},
+		{
+			name:         "DataSourceRefInUngrantedNamespace",
+			from:         "pvc-ungranted",
+			to:           "snapshot-backups",
+			relationship: RelationshipPersistentVolumeClaimDataSource,
+			expected:     false,
+		},
+		{
+			name:         "EphemeralVolume",
+			from:         "pod",
+			to:           "pvc-ephemeral",
+			relationship: RelationshipPodEphemeralVolume,
+			expected:     true,
+		},
+		{
+			name:         "EphemeralVolumeWithoutPodPrefix",
+			from:         "pod",
+			to:           "pvc-ephemeral-other",
+			relationship: RelationshipPodEphemeralVolume,
+			expected:     false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\" to be %t, got %v", tt.relationship, tt.from, tt.to, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolveVolumeSnapshots(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("pvc", "v1", "PersistentVolumeClaim", "default", "data", nil),
+		newTestObject("pvc-restored", "v1", "PersistentVolumeClaim", "default", "restored", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"dataSource": map[string]interface{}{"apiGroup": SnapshotGroupName, "kind": "VolumeSnapshot", "name": "data-1"},
+			},
+		}),
+		newTestObject("snapshot", "snapshot.storage.k8s.io/v1", "VolumeSnapshot", "default", "data-1", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"source":                  map[string]interface{}{"persistentVolumeClaimName": "data"},
+				"volumeSnapshotClassName": "csi-hostpath",
+			},
+			"status": map[string]interface{}{"boundVolumeSnapshotContentName": "snapcontent-1"},
+		}),
+		newTestObject("content", "snapshot.storage.k8s.io/v1", "VolumeSnapshotContent", "", "snapcontent-1", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					snapshotDeletionSecretNameAnnotation:      "snapshotter",
+					snapshotDeletionSecretNamespaceAnnotation: "kube-system",
+				},
+			},
+			"spec": map[string]interface{}{
+				"driver":                  "hostpath.csi.k8s.io",
+				"volumeSnapshotClassName": "csi-hostpath",
+				"volumeSnapshotRef":       map[string]interface{}{"name": "data-1", "namespace": "default"},
+			},
+		}),
+		newTestObject("class", "snapshot.storage.k8s.io/v1", "VolumeSnapshotClass", "", "csi-hostpath", map[string]interface{}{
+			"driver": "hostpath.csi.k8s.io",
+			"parameters": map[string]interface{}{
+				snapshotterSecretNameParameter:      "snapshotter",
+				snapshotterSecretNamespaceParameter: "kube-system",
+			},
+		}),
+		newTestObject("class-templated", "snapshot.storage.k8s.io/v1", "VolumeSnapshotClass", "", "csi-templated", map[string]interface{}{
+			"driver": "hostpath.csi.k8s.io",
+			"parameters": map[string]interface{}{
+				snapshotterSecretNameParameter:      "snapshotter",
+				snapshotterSecretNamespaceParameter: "${volumesnapshotcontent.namespace}",
+			},
+		}),
+		newTestObject("driver", "storage.k8s.io/v1", "CSIDriver", "", "hostpath.csi.k8s.io", nil),
+		newTestObject("secret", "v1", "Secret", "kube-system", "snapshotter", nil),
+	}
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+		expected     bool
+	}{
+		{
+			name:         "VolumeSnapshotSource",
+			from:         "snapshot",
+			to:           "pvc",
+			relationship: RelationshipVolumeSnapshotSource,
+			expected:     true,
+		},
+		{
+			name:         "VolumeSnapshotClass",
+			from:         "snapshot",
+			to:           "class",
+			relationship: RelationshipVolumeSnapshotClass,
+			expected:     true,
+		},
+		{
+			name:         "VolumeSnapshotBoundContent",
+			from:         "snapshot",
+			to:           "content",
+			relationship: RelationshipVolumeSnapshotContent,
+			expected:     true,
+		},
+		{
+			name:         "VolumeSnapshotContentSnapshot",
+			from:         "content",
+			to:           "snapshot",
+			relationship: RelationshipVolumeSnapshotContentSnapshot,
+			expected:     true,
+		},
+		{
+			name:         "VolumeSnapshotContentClass",
+			from:         "content",
+			to:           "class",
+			relationship: RelationshipVolumeSnapshotContentClass,
+			expected:     true,
+		},
+		{
+			name:         "VolumeSnapshotContentCSIDriver",
+			from:         "content",
+			to:           "driver",
+			relationship: RelationshipVolumeSnapshotContentCSIDriver,
+			expected:     true,
+		},
+		{
+			name:         "VolumeSnapshotContentDeletionSecret",
+			from:         "content",
+			to:           "secret",
+			relationship: RelationshipVolumeSnapshotContentDeletionSecret,
+			expected:     true,
+		},
+		{
+			name:         "VolumeSnapshotClassCSIDriver",
+			from:         "class",
+			to:           "driver",
+			relationship: RelationshipVolumeSnapshotClassCSIDriver,
+			expected:     true,
+		},
+		{
+			name:         "VolumeSnapshotClassSecret",
+			from:         "class",
+			to:           "secret",
+			relationship: RelationshipVolumeSnapshotClassSecret,
+			expected:     true,
+		},
+		{
+			name:         "VolumeSnapshotClassTemplatedSecret",
+			from:         "class-templated",
+			to:           "secret",
+			relationship: RelationshipVolumeSnapshotClassSecret,
+			expected:     false,
+		},
+		{
+			name:         "PersistentVolumeClaimRestoredFromSnapshot",
+			from:         "pvc-restored",
+			to:           "snapshot",
+			relationship: RelationshipPersistentVolumeClaimDataSource,
+			expected:     true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\" to be %t, got %v", tt.relationship, tt.from, tt.to, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolveNamespace(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("ns", "v1", "Namespace", "", "team-a", nil),
+		newTestObject("quota", "v1", "ResourceQuota", "team-a", "compute", nil),
+		newTestObject("limits", "v1", "LimitRange", "team-a", "defaults", nil),
+		newTestObject("sa-default", "v1", "ServiceAccount", "team-a", "default", nil),
+		newTestObject("sa-other", "v1", "ServiceAccount", "team-a", "builder", nil),
+		newTestObject("rb", "rbac.authorization.k8s.io/v1", "RoleBinding", "team-a", "admins", nil),
+		newTestObject("quota-other", "v1", "ResourceQuota", "team-b", "compute", nil),
+		newTestObject("cm", "v1", "ConfigMap", "team-a", "settings", nil),
+	}
+
+	tests := []struct {
+		name         string
+		opts         ResolveOptions
+		from         types.UID
+		relationship Relationship
+		expected     bool
+	}{
+		{
+			name:         "ResourceQuota",
+			from:         "quota",
+			relationship: RelationshipNamespaceResourceQuota,
+			expected:     true,
+		},
+		{
+			name:         "ResourceQuotaInOtherNamespace",
+			from:         "quota-other",
+			relationship: RelationshipNamespaceResourceQuota,
+			expected:     false,
+		},
+		{
+			name:         "LimitRange",
+			from:         "limits",
+			relationship: RelationshipNamespaceLimitRange,
+			expected:     true,
+		},
+		{
+			name:         "DefaultServiceAccount",
+			from:         "sa-default",
+			relationship: RelationshipNamespaceDefaultServiceAccount,
+			expected:     true,
+		},
+		{
+			name:         "OtherServiceAccount",
+			from:         "sa-other",
+			relationship: RelationshipNamespaceDefaultServiceAccount,
+			expected:     false,
+		},
+		{
+			name:         "RoleBinding",
+			from:         "rb",
+			relationship: RelationshipNamespaceRoleBinding,
+			expected:     true,
+		},
+		{
+			name:         "NamespaceContentDisabled",
+			from:         "cm",
+			relationship: RelationshipNamespaceContent,
+			expected:     false,
+		},
+		{
+			name:         "NamespaceContentEnabled",
+			opts:         ResolveOptions{NamespaceContents: true},
+			from:         "cm",
+			relationship: RelationshipNamespaceContent,
+			expected:     true,
+		},
+		{
+			name:         "NamespaceContentInOtherNamespace",
+			opts:         ResolveOptions{NamespaceContents: true},
+			from:         "quota-other",
+			relationship: RelationshipNamespaceContent,
+			expected:     false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, tt.opts)
+			if err != nil {
+				t.Fatalf("failed to resolve relationships: %v", err)
+			}
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies["ns"][tt.relationship]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"%s\" to \"ns\" to be %t, got %v", tt.relationship, tt.from, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolvePrometheusOperatorMonitors(t *testing.T) {
+	t.Parallel()
+
+	appLabels := map[string]interface{}{"labels": map[string]interface{}{"app": "web"}}
+	selector := map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("sm-same", "monitoring.coreos.com/v1", "ServiceMonitor", "monitoring", "same", map[string]interface{}{
+			"spec": map[string]interface{}{"selector": selector},
+		}),
+		newTestObject("sm-any", "monitoring.coreos.com/v1", "ServiceMonitor", "monitoring", "any", map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"team": "web"}},
+			"spec": map[string]interface{}{
+				"selector":          selector,
+				"namespaceSelector": map[string]interface{}{"any": true},
+				"endpoints": []interface{}{
+					map[string]interface{}{
+						"basicAuth": map[string]interface{}{
+							"username": map[string]interface{}{"name": "scrape-auth", "key": "username"},
+						},
+						"tlsConfig": map[string]interface{}{
+							"ca": map[string]interface{}{
+								"configMap": map[string]interface{}{"name": "scrape-ca", "key": "ca.crt"},
+							},
+						},
+					},
+				},
+			},
+		}),
+		newTestObject("pm-names", "monitoring.coreos.com/v1", "PodMonitor", "monitoring", "names", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"selector":          selector,
+				"namespaceSelector": map[string]interface{}{"matchNames": []interface{}{"default", "staging"}},
+			},
+		}),
+		newTestObject("prometheus", "monitoring.coreos.com/v1", "Prometheus", "monitoring", "k8s", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"serviceMonitorSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"team": "web"}},
+			},
+		}),
+		newTestObject("svc-monitoring", "v1", "Service", "monitoring", "web", map[string]interface{}{"metadata": appLabels}),
+		newTestObject("svc-default", "v1", "Service", "default", "web", map[string]interface{}{"metadata": appLabels}),
+		newTestObject("pod-default", "v1", "Pod", "default", "web", map[string]interface{}{"metadata": appLabels}),
+		newTestObject("pod-staging", "v1", "Pod", "staging", "web", map[string]interface{}{"metadata": appLabels}),
+		newTestObject("pod-production", "v1", "Pod", "production", "web", map[string]interface{}{"metadata": appLabels}),
+		newTestObject("secret-auth", "v1", "Secret", "monitoring", "scrape-auth", nil),
+		newTestObject("cm-ca", "v1", "ConfigMap", "monitoring", "scrape-ca", nil),
+	}
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+		expected     bool
+	}{
+		{
+			name:         "ServiceMonitorInSameNamespace",
+			from:         "sm-same",
+			to:           "svc-monitoring",
+			relationship: RelationshipPrometheusOperatorServiceMonitor,
+			expected:     true,
+		},
+		{
+			name:         "ServiceMonitorInOtherNamespace",
+			from:         "sm-same",
+			to:           "svc-default",
+			relationship: RelationshipPrometheusOperatorServiceMonitor,
+			expected:     false,
+		},
+		{
+			name:         "ServiceMonitorAnyNamespace",
+			from:         "sm-any",
+			to:           "svc-default",
+			relationship: RelationshipPrometheusOperatorServiceMonitor,
+			expected:     true,
+		},
+		{
+			name:         "PodMonitorInMatchedNamespace",
+			from:         "pm-names",
+			to:           "pod-default",
+			relationship: RelationshipPrometheusOperatorPodMonitor,
+			expected:     true,
+		},
+		{
+			name:         "PodMonitorInOtherMatchedNamespace",
+			from:         "pm-names",
+			to:           "pod-staging",
+			relationship: RelationshipPrometheusOperatorPodMonitor,
+			expected:     true,
+		},
+		{
+			name:         "PodMonitorInUnmatchedNamespace",
+			from:         "pm-names",
+			to:           "pod-production",
+			relationship: RelationshipPrometheusOperatorPodMonitor,
+			expected:     false,
+		},
+		{
+			name:         "EndpointBasicAuth",
+			from:         "sm-any",
+			to:           "secret-auth",
+			relationship: RelationshipPrometheusOperatorEndpointBasicAuth,
+			expected:     true,
+		},
+		{
+			name:         "EndpointTLSConfig",
+			from:         "sm-any",
+			to:           "cm-ca",
+			relationship: RelationshipPrometheusOperatorEndpointTLSConfig,
+			expected:     true,
+		},
+		{
+			name:         "PrometheusServiceMonitor",
+			from:         "prometheus",
+			to:           "sm-any",
+			relationship: RelationshipPrometheusOperatorPrometheusServiceMonitor,
+			expected:     true,
+		},
+		{
+			name:         "PrometheusUnselectedServiceMonitor",
+			from:         "prometheus",
+			to:           "sm-same",
+			relationship: RelationshipPrometheusOperatorPrometheusServiceMonitor,
+			expected:     false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\" to be %t, got %v", tt.relationship, tt.from, tt.to, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestResolveCertManagerRelationships(t *testing.T) {
+	t.Parallel()
+
+	issuerRef := map[string]interface{}{"name": "letsencrypt"}
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("issuer", "cert-manager.io/v1", "Issuer", "default", "letsencrypt", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"acme": map[string]interface{}{
+					"privateKeySecretRef": map[string]interface{}{"name": "letsencrypt-account-key"},
+				},
+			},
+		}),
+		newTestObject("cluster-issuer", "cert-manager.io/v1", "ClusterIssuer", "", "letsencrypt", nil),
+		newTestObject("cert", "cert-manager.io/v1", "Certificate", "default", "web", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"issuerRef":  issuerRef,
+				"secretName": "web-tls",
+				"keystores": map[string]interface{}{
+					"pkcs12": map[string]interface{}{
+						"passwordSecretRef": map[string]interface{}{"name": "web-keystore"},
+					},
+				},
+			},
+		}),
+		newTestObject("cert-cluster", "cert-manager.io/v1", "Certificate", "default", "api", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"issuerRef": map[string]interface{}{"name": "letsencrypt", "kind": "ClusterIssuer", "group": "cert-manager.io"},
+			},
+		}),
+		newTestObject("cr", "cert-manager.io/v1", "CertificateRequest", "default", "web-1", map[string]interface{}{
+			"spec": map[string]interface{}{"issuerRef": issuerRef},
+		}),
+		newTestObject("order", "acme.cert-manager.io/v1", "Order", "default", "web-1-order", map[string]interface{}{
+			"spec": map[string]interface{}{"issuerRef": issuerRef},
+		}),
+		newTestObject("challenge", "acme.cert-manager.io/v1", "Challenge", "default", "web-1-challenge", map[string]interface{}{
+			"spec": map[string]interface{}{"issuerRef": issuerRef},
+		}),
+		newTestObject("ingress", "networking.k8s.io/v1", "Ingress", "default", "web", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{CertManagerIssuerAnnotation: "letsencrypt"},
+			},
+		}),
+		newTestObject("ingress-cluster", "networking.k8s.io/v1", "Ingress", "default", "api", map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{CertManagerClusterIssuerAnnotation: "letsencrypt"},
+			},
+		}),
+		newTestObject("secret-tls", "v1", "Secret", "default", "web-tls", nil),
+		newTestObject("secret-keystore", "v1", "Secret", "default", "web-keystore", nil),
+		newTestObject("secret-account-key", "v1", "Secret", "default", "letsencrypt-account-key", nil),
+	}
+	nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("failed to resolve relationships: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         types.UID
+		to           types.UID
+		relationship Relationship
+		expected     bool
+	}{
+		{
+			name:         "CertificateIssuer",
+			from:         "cert",
+			to:           "issuer",
+			relationship: RelationshipCertManagerCertificateIssuer,
+			expected:     true,
+		},
+		{
+			name:         "CertificateIssuerIgnoresClusterIssuer",
+			from:         "cert",
+			to:           "cluster-issuer",
+			relationship: RelationshipCertManagerCertificateIssuer,
+			expected:     false,
+		},
+		{
+			name:         "CertificateClusterIssuer",
+			from:         "cert-cluster",
+			to:           "cluster-issuer",
+			relationship: RelationshipCertManagerCertificateIssuer,
+			expected:     true,
+		},
+		{
+			name:         "CertificateKeystorePassword",
+			from:         "cert",
+			to:           "secret-keystore",
+			relationship: RelationshipCertManagerCertificateKeystorePassword,
+			expected:     true,
+		},
+		{
+			name:         "CertificateSecret",
+			from:         "secret-tls",
+			to:           "cert",
+			relationship: RelationshipCertManagerCertificateSecret,
+			expected:     true,
+		},
+		{
+			name:         "CertificateRequestIssuer",
+			from:         "cr",
+			to:           "issuer",
+			relationship: RelationshipCertManagerCertificateRequestIssuer,
+			expected:     true,
+		},
+		{
+			name:         "OrderIssuer",
+			from:         "order",
+			to:           "issuer",
+			relationship: RelationshipCertManagerOrderIssuer,
+			expected:     true,
+		},
+		{
+			name:         "ChallengeIssuer",
+			from:         "challenge",
+			to:           "issuer",
+			relationship: RelationshipCertManagerChallengeIssuer,
+			expected:     true,
+		},
+		{
+			name:         "IssuerACMEAccountKey",
+			from:         "issuer",
+			to:           "secret-account-key",
+			relationship: RelationshipCertManagerIssuerACMEAccountKey,
+			expected:     true,
+		},
+		{
+			name:         "IngressIssuerAnnotation",
+			from:         "ingress",
+			to:           "issuer",
+			relationship: RelationshipCertManagerIssuerAnnotation,
+			expected:     true,
+		},
+		{
+			name:         "IngressClusterIssuerAnnotation",
+			from:         "ingress-cluster",
+			to:           "cluster-issuer",
+			relationship: RelationshipCertManagerIssuerAnnotation,
+			expected:     true,
+		},
+		{
+			name:         "IngressClusterIssuerAnnotationIgnoresIssuer",
+			from:         "ingress-cluster",
+			to:           "issuer",
+			relationship: RelationshipCertManagerIssuerAnnotation,
+			expected:     false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, ok := nodeMap[tt.from]
+			if !ok {
+				t.Fatalf("node with UID \"%s\" not found", tt.from)
+			}
+			if _, ok := from.Dependencies[tt.to][tt.relationship]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"%s\" to \"%s\" to be %t, got %v", tt.relationship, tt.from, tt.to, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
+
+func TestResolveCertManagerClusterIssuer(t *testing.T) {
+	t.Parallel()
+
+	objects := []unstructuredv1.Unstructured{
+		newTestObject("issuer", "cert-manager.io/v1", "ClusterIssuer", "", "ca", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"ca": map[string]interface{}{"secretName": "ca-key-pair"},
+			},
+		}),
+		newTestObject("secret-default", "v1", "Secret", DefaultCertManagerNamespace, "ca-key-pair", nil),
+		newTestObject("secret-custom", "v1", "Secret", "security", "ca-key-pair", nil),
+	}
+
+	tests := []struct {
+		name     string
+		opts     ResolveOptions
+		to       types.UID
+		expected bool
+	}{
+		{name: "DefaultNamespace", opts: ResolveOptions{}, to: "secret-default", expected: true},
+		{name: "DefaultNamespaceIgnoresCustom", opts: ResolveOptions{}, to: "secret-custom", expected: false},
+		{name: "CustomNamespace", opts: ResolveOptions{CertManagerNamespace: "security"}, to: "secret-custom", expected: true},
+		{name: "CustomNamespaceIgnoresDefault", opts: ResolveOptions{CertManagerNamespace: "security"}, to: "secret-default", expected: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			nodeMap, err := resolveRelationships(newTestRESTMapper(), objects, tt.opts)
+			if err != nil {
+				t.Fatalf("failed to resolve relationships: %v", err)
+			}
+			from := nodeMap["issuer"]
+			if _, ok := from.Dependencies[tt.to][RelationshipCertManagerIssuerCA]; ok != tt.expected {
+				t.Fatalf("expected %s relationship from \"issuer\" to \"%s\" to be %t, got %v", RelationshipCertManagerIssuerCA, tt.to, tt.expected, from.Dependencies)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package graph
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn"
+	batchv1 "k8s.io/api/batch/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	kubevirt "kubevirt.io/api/core"
+)
+
+// Harvester groups & well-known values.
+const (
+	HarvesterGroupName        = "harvesterhci.io"
+	HarvesterNetworkGroupName = "network.harvesterhci.io"
+
+	// HarvesterImageIDAnnotation is the annotation of PersistentVolumeClaims
+	// created from a VirtualMachineImage, containing the "<namespace>/<name>" of
+	// the image.
+	HarvesterImageIDAnnotation = "harvesterhci.io/imageId"
+	// HarvesterSSHNamesAnnotation is the annotation of VirtualMachines
+	// containing a JSON list of the "<namespace>/<name>" of the KeyPairs
+	// injected into the VirtualMachine.
+	HarvesterSSHNamesAnnotation = "harvesterhci.io/sshNames"
+
+	// harvesterClusterNetworkLabel is the label set on
+	// NetworkAttachmentDefinitions, containing the name of the ClusterNetwork
+	// which the network is attached to.
+	harvesterClusterNetworkLabel = "network.harvesterhci.io/clusternetwork"
+	// harvesterUpgradeLabel is the label set on the Jobs created for an
+	// Upgrade, containing the name of the Upgrade.
+	harvesterUpgradeLabel = "harvesterhci.io/upgrade"
+)
+
+const (
+	// Harvester ClusterNetwork relationships.
+	RelationshipHarvesterClusterNetworkNetworkAttachmentDefinition Relationship = "HarvesterClusterNetworkNetworkAttachmentDefinition"
+
+	// Harvester Upgrade relationships.
+	RelationshipHarvesterUpgradeImage   Relationship = "HarvesterUpgradeImage"
+	RelationshipHarvesterUpgradeJob     Relationship = "HarvesterUpgradeJob"
+	RelationshipHarvesterUpgradeVersion Relationship = "HarvesterUpgradeVersion"
+
+	// Harvester UpgradeLog relationships.
+	RelationshipHarvesterUpgradeLogUpgrade Relationship = "HarvesterUpgradeLogUpgrade"
+
+	// Harvester VirtualMachine relationships.
+	RelationshipHarvesterVMKeyPair Relationship = "HarvesterVMKeyPair"
+
+	// Harvester VirtualMachineBackup relationships.
+	RelationshipHarvesterVMBackupLonghornBackup        Relationship = "HarvesterVMBackupLonghornBackup"
+	RelationshipHarvesterVMBackupPersistentVolumeClaim Relationship = "HarvesterVMBackupPersistentVolumeClaim"
+	RelationshipHarvesterVMBackupSource                Relationship = "HarvesterVMBackupSource"
+	RelationshipHarvesterVMBackupVolumeSnapshot        Relationship = "HarvesterVMBackupVolumeSnapshot"
+
+	// Harvester VirtualMachineImage relationships.
+	RelationshipHarvesterVMImagePersistentVolumeClaim Relationship = "HarvesterVMImagePersistentVolumeClaim"
+	RelationshipHarvesterVMImageStorageClass          Relationship = "HarvesterVMImageStorageClass"
+
+	// Harvester VirtualMachineRestore relationships.
+	RelationshipHarvesterVMRestoreBackup                Relationship = "HarvesterVMRestoreBackup"
+	RelationshipHarvesterVMRestorePersistentVolumeClaim Relationship = "HarvesterVMRestorePersistentVolumeClaim"
+	RelationshipHarvesterVMRestoreTarget                Relationship = "HarvesterVMRestoreTarget"
+
+	// Harvester VirtualMachineTemplate relationships.
+	RelationshipHarvesterVMTemplateDefaultVersion Relationship = "HarvesterVMTemplateDefaultVersion"
+
+	// Harvester VirtualMachineTemplateVersion relationships.
+	RelationshipHarvesterVMTemplateVersionImage    Relationship = "HarvesterVMTemplateVersionImage"
+	RelationshipHarvesterVMTemplateVersionKeyPair  Relationship = "HarvesterVMTemplateVersionKeyPair"
+	RelationshipHarvesterVMTemplateVersionTemplate Relationship = "HarvesterVMTemplateVersionTemplate"
+
+	// Harvester VlanConfig relationships.
+	RelationshipHarvesterVlanConfigClusterNetwork Relationship = "HarvesterVlanConfigClusterNetwork"
+	RelationshipHarvesterVlanConfigNode           Relationship = "HarvesterVlanConfigNode"
+)
+
+// getHarvesterClusterNetworkRelationships returns a map of relationships that
+// this Harvester ClusterNetwork has with other objects, based on what was
+// referenced in its manifest.
+func getHarvesterClusterNetworkRelationships(n *Node) (*RelationshipMap, error) {
+	result := newRelationshipMap()
+
+	// RelationshipHarvesterClusterNetworkNetworkAttachmentDefinition
+	ols := ObjectLabelSelector{
+		Group:         MultusGroupName,
+		Kind:          "NetworkAttachmentDefinition",
+		Selector:      labels.SelectorFromSet(labels.Set{harvesterClusterNetworkLabel: n.Name}),
+		AllNamespaces: true,
+	}
+	result.AddDependentByLabelSelector(ols, RelationshipHarvesterClusterNetworkNetworkAttachmentDefinition)
+
+	return &result, nil
+}
+
+// getHarvesterUpgradeRelationships returns a map of relationships that this
+// Harvester Upgrade has with other objects, based on what was referenced in
+// its manifest.
+func getHarvesterUpgradeRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipHarvesterUpgradeImage
+	for _, id := range []string{n.GetNestedString("spec", "image"), n.GetNestedString("status", "imageID")} {
+		if ns, name, ok := parseHarvesterID(id, n.Namespace); ok {
+			ref = ObjectReference{Group: HarvesterGroupName, Kind: "VirtualMachineImage", Namespace: ns, Name: name}
+			result.AddDependencyByKey(ref.Key(), RelationshipHarvesterUpgradeImage)
+		}
+	}
+
+	// RelationshipHarvesterUpgradeJob
+	ols := ObjectLabelSelector{
+		Group:         batchv1.GroupName,
+		Kind:          "Job",
+		Selector:      labels.SelectorFromSet(labels.Set{harvesterUpgradeLabel: n.Name}),
+		AllNamespaces: true,
+	}
+	result.AddDependentByLabelSelector(ols, RelationshipHarvesterUpgradeJob)
+
+	// RelationshipHarvesterUpgradeVersion
+	if name := n.GetNestedString("spec", "version"); len(name) > 0 {
+		ref = ObjectReference{Group: HarvesterGroupName, Kind: "Version", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipHarvesterUpgradeVersion)
+	}
+
+	return &result, nil
+}
+
+// getHarvesterUpgradeLogRelationships returns a map of relationships that this
+// Harvester UpgradeLog has with other objects, based on what was referenced in
+// its manifest.
+func getHarvesterUpgradeLogRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipHarvesterUpgradeLogUpgrade
+	if name := n.GetNestedString("spec", "upgradeName"); len(name) > 0 {
+		ref = ObjectReference{Group: HarvesterGroupName, Kind: "Upgrade", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipHarvesterUpgradeLogUpgrade)
+	}
+
+	return &result, nil
+}
+
+// getHarvesterVMBackupRelationships returns a map of relationships that this
+// Harvester VirtualMachineBackup has with other objects, based on what was
+// referenced in its manifest. Longhorn Backups are looked up in the provided
+// namespace.
+func getHarvesterVMBackupRelationships(n *Node, longhornNamespace string) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipHarvesterVMBackupSource
+	if name := n.GetNestedString("spec", "source", "name"); len(name) > 0 {
+		ref = getHarvesterTypedLocalObjectReference(n, name, "spec", "source")
+		result.AddDependencyByKey(ref.Key(), RelationshipHarvesterVMBackupSource)
+	}
+
+	// RelationshipHarvesterVMBackupLonghornBackup
+	// RelationshipHarvesterVMBackupPersistentVolumeClaim
+	// RelationshipHarvesterVMBackupVolumeSnapshot
+	volumeBackups, _, err := unstructuredv1.NestedSlice(n.UnstructuredContent(), "status", "volumeBackups")
+	if err != nil {
+		return nil, err
+	}
+	for _, vb := range volumeBackups {
+		vb, ok := vb.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _, _ := unstructuredv1.NestedString(vb, "longhornBackupName"); len(name) > 0 {
+			ref = ObjectReference{Group: longhorn.GroupName, Kind: "Backup", Namespace: longhornNamespace, Name: name}
+			result.AddDependentByKey(ref.Key(), RelationshipHarvesterVMBackupLonghornBackup)
+		}
+		if name, _, _ := unstructuredv1.NestedString(vb, "persistentVolumeClaim", "metadata", "name"); len(name) > 0 {
+			ns, _, _ := unstructuredv1.NestedString(vb, "persistentVolumeClaim", "metadata", "namespace")
+			if len(ns) == 0 {
+				ns = n.Namespace
+			}
+			ref = ObjectReference{Kind: "PersistentVolumeClaim", Namespace: ns, Name: name}
+			result.AddDependencyByKey(ref.Key(), RelationshipHarvesterVMBackupPersistentVolumeClaim)
+		}
+		if name, _, _ := unstructuredv1.NestedString(vb, "volumeSnapshotName"); len(name) > 0 {
+			ref = ObjectReference{Group: SnapshotGroupName, Kind: "VolumeSnapshot", Namespace: n.Namespace, Name: name}
+			result.AddDependentByKey(ref.Key(), RelationshipHarvesterVMBackupVolumeSnapshot)
+		}
+	}
+
+	return &result, nil
+}
+
+// getHarvesterVMImageRelationships returns a map of relationships that this
+// Harvester VirtualMachineImage has with other objects, based on what was
+// referenced in its manifest.
+func getHarvesterVMImageRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipHarvesterVMImagePersistentVolumeClaim (images exported from
+	// a volume)
+	if name := n.GetNestedString("spec", "pvcName"); len(name) > 0 {
+		ns := n.GetNestedString("spec", "pvcNamespace")
+		if len(ns) == 0 {
+			ns = n.Namespace
+		}
+		ref = ObjectReference{Kind: "PersistentVolumeClaim", Namespace: ns, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipHarvesterVMImagePersistentVolumeClaim)
+	}
+
+	// RelationshipHarvesterVMImageStorageClass (storage classes created for
+	// provisioning volumes from the image)
+	if name := n.GetNestedString("status", "storageClassName"); len(name) > 0 {
+		ref = ObjectReference{Group: storagev1.GroupName, Kind: "StorageClass", Name: name}
+		result.AddDependentByKey(ref.Key(), RelationshipHarvesterVMImageStorageClass)
+	}
+
+	return &result, nil
+}
+
+// getHarvesterVMRestoreRelationships returns a map of relationships that this
+// Harvester VirtualMachineRestore has with other objects, based on what was
+// referenced in its manifest.
+func getHarvesterVMRestoreRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipHarvesterVMRestoreBackup
+	if name := n.GetNestedString("spec", "virtualMachineBackupName"); len(name) > 0 {
+		ns := n.GetNestedString("spec", "virtualMachineBackupNamespace")
+		if len(ns) == 0 {
+			ns = n.Namespace
+		}
+		ref = ObjectReference{Group: HarvesterGroupName, Kind: "VirtualMachineBackup", Namespace: ns, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipHarvesterVMRestoreBackup)
+	}
+
+	// RelationshipHarvesterVMRestoreTarget
+	if name := n.GetNestedString("spec", "target", "name"); len(name) > 0 {
+		ref = getHarvesterTypedLocalObjectReference(n, name, "spec", "target")
+		result.AddDependentByKey(ref.Key(), RelationshipHarvesterVMRestoreTarget)
+	}
+
+	// RelationshipHarvesterVMRestorePersistentVolumeClaim
+	restores, _, err := unstructuredv1.NestedSlice(n.UnstructuredContent(), "status", "restores")
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range restores {
+		r, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _, _ := unstructuredv1.NestedString(r, "persistentVolumeClaim", "metadata", "name"); len(name) > 0 {
+			ns, _, _ := unstructuredv1.NestedString(r, "persistentVolumeClaim", "metadata", "namespace")
+			if len(ns) == 0 {
+				ns = n.Namespace
+			}
+			ref = ObjectReference{Kind: "PersistentVolumeClaim", Namespace: ns, Name: name}
+			result.AddDependentByKey(ref.Key(), RelationshipHarvesterVMRestorePersistentVolumeClaim)
+		}
+	}
+
+	return &result, nil
+}
+
+// getHarvesterVMTemplateRelationships returns a map of relationships that this
+// Harvester VirtualMachineTemplate has with other objects, based on what was
+// referenced in its manifest.
+func getHarvesterVMTemplateRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipHarvesterVMTemplateDefaultVersion
+	if ns, name, ok := parseHarvesterID(n.GetNestedString("spec", "defaultVersionId"), n.Namespace); ok {
+		ref = ObjectReference{Group: HarvesterGroupName, Kind: "VirtualMachineTemplateVersion", Namespace: ns, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipHarvesterVMTemplateDefaultVersion)
+	}
+
+	return &result, nil
+}
+
+// getHarvesterVMTemplateVersionRelationships returns a map of relationships
+// that this Harvester VirtualMachineTemplateVersion has with other objects,
+// based on what was referenced in its manifest.
+func getHarvesterVMTemplateVersionRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipHarvesterVMTemplateVersionImage
+	if ns, name, ok := parseHarvesterID(n.GetNestedString("spec", "imageId"), n.Namespace); ok {
+		ref = ObjectReference{Group: HarvesterGroupName, Kind: "VirtualMachineImage", Namespace: ns, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipHarvesterVMTemplateVersionImage)
+	}
+
+	// RelationshipHarvesterVMTemplateVersionKeyPair
+	keyPairIDs, _, err := unstructuredv1.NestedStringSlice(n.UnstructuredContent(), "spec", "keyPairIds")
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range keyPairIDs {
+		if ns, name, ok := parseHarvesterID(id, n.Namespace); ok {
+			ref = ObjectReference{Group: HarvesterGroupName, Kind: "KeyPair", Namespace: ns, Name: name}
+			result.AddDependencyByKey(ref.Key(), RelationshipHarvesterVMTemplateVersionKeyPair)
+		}
+	}
+
+	// RelationshipHarvesterVMTemplateVersionTemplate
+	if ns, name, ok := parseHarvesterID(n.GetNestedString("spec", "templateId"), n.Namespace); ok {
+		ref = ObjectReference{Group: HarvesterGroupName, Kind: "VirtualMachineTemplate", Namespace: ns, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipHarvesterVMTemplateVersionTemplate)
+	}
+
+	return &result, nil
+}
+
+// getHarvesterVlanConfigRelationships returns a map of relationships that this
+// Harvester VlanConfig has with other objects, based on what was referenced in
+// its manifest.
+func getHarvesterVlanConfigRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipHarvesterVlanConfigClusterNetwork
+	if name := n.GetNestedString("spec", "clusterNetwork"); len(name) > 0 {
+		ref = ObjectReference{Group: HarvesterNetworkGroupName, Kind: "ClusterNetwork", Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipHarvesterVlanConfigClusterNetwork)
+	}
+
+	// RelationshipHarvesterVlanConfigNode (an empty node selector selects
+	// every node)
+	nodeSelector, _, err := unstructuredv1.NestedStringMap(n.UnstructuredContent(), "spec", "nodeSelector")
+	if err != nil {
+		return nil, err
+	}
+	ols := ObjectLabelSelector{Kind: "Node", Selector: labels.SelectorFromSet(nodeSelector)}
+	result.AddDependencyByLabelSelector(ols, RelationshipHarvesterVlanConfigNode)
+
+	return &result, nil
+}
+
+// addHarvesterVMRelationships adds the relationships that the provided
+// VirtualMachine has with Harvester objects, based on the annotations set by
+// Harvester on the VirtualMachine.
+func addHarvesterVMRelationships(result *RelationshipMap, n *Node) {
+	var ref ObjectReference
+
+	// RelationshipHarvesterVMKeyPair
+	if sshNames := n.GetAnnotations()[HarvesterSSHNamesAnnotation]; len(sshNames) > 0 {
+		var ids []string
+		if err := json.Unmarshal([]byte(sshNames), &ids); err != nil {
+			klog.V(4).Infof("Failed to parse annotation \"%s\" of VirtualMachine \"%s\" in namespace \"%s\": %s", HarvesterSSHNamesAnnotation, n.Name, n.Namespace, err)
+		}
+		for _, id := range ids {
+			if ns, name, ok := parseHarvesterID(id, n.Namespace); ok {
+				ref = ObjectReference{Group: HarvesterGroupName, Kind: "KeyPair", Namespace: ns, Name: name}
+				result.AddDependencyByKey(ref.Key(), RelationshipHarvesterVMKeyPair)
+			}
+		}
+	}
+}
+
+// getHarvesterTypedLocalObjectReference returns a reference to the object
+// referenced by the TypedLocalObjectReference at the provided fields of the
+// Harvester object, which defaults to a KubeVirt VirtualMachine.
+func getHarvesterTypedLocalObjectReference(n *Node, name string, fields ...string) ObjectReference {
+	group := n.GetNestedString(append(fields, "apiGroup")...)
+	kind := n.GetNestedString(append(fields, "kind")...)
+	if len(kind) == 0 {
+		group, kind = kubevirt.GroupName, "VirtualMachine"
+	}
+	return ObjectReference{Group: group, Kind: kind, Namespace: n.Namespace, Name: name}
+}
+
+// parseHarvesterID returns the namespace & name of the object identified by
+// the provided Harvester ID (ie. "<namespace>/<name>"). IDs without a
+// namespace refer to objects in the provided namespace.
+func parseHarvesterID(id, namespace string) (string, string, bool) {
+	if len(id) == 0 {
+		return "", "", false
+	}
+	nn := strings.SplitN(id, "/", 2)
+	if len(nn) == 1 {
+		return namespace, nn[0], true
+	}
+	if len(nn[0]) == 0 || len(nn[1]) == 0 {
+		return "", "", false
+	}
+	return nn[0], nn[1], true
+}
@@ -0,0 +1,151 @@
+package graph
+
+import (
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// KEDA group & well-known values.
+const (
+	KEDAGroupName = "keda.sh"
+
+	// DefaultKEDANamespace is the namespace which KEDA is installed in by
+	// default, which it reads the Secrets & ConfigMaps referenced by
+	// ClusterTriggerAuthentications from.
+	DefaultKEDANamespace = "keda"
+)
+
+const (
+	// KEDA ScaledJob relationships.
+	RelationshipKEDAScaledJobTriggerAuthentication Relationship = "KEDAScaledJobTriggerAuthentication"
+
+	// KEDA ScaledObject relationships.
+	RelationshipKEDAScaledObjectScaleTarget           Relationship = "KEDAScaledObjectScaleTarget"
+	RelationshipKEDAScaledObjectTriggerAuthentication Relationship = "KEDAScaledObjectTriggerAuthentication"
+
+	// KEDA TriggerAuthentication & ClusterTriggerAuthentication relationships.
+	RelationshipKEDATriggerAuthenticationConfigMap Relationship = "KEDATriggerAuthenticationConfigMap"
+	RelationshipKEDATriggerAuthenticationSecret    Relationship = "KEDATriggerAuthenticationSecret" //nolint:gosec
+)
+
+// getKEDAScaledJobRelationships returns a map of relationships that this KEDA
+// ScaledJob has with other objects, based on what was referenced in its
+// manifest.
+func getKEDAScaledJobRelationships(n *Node) (*RelationshipMap, error) {
+	// RelationshipWorkloadTemplate*
+	result, err := getWorkloadTemplateRelationships(n, "spec", "jobTargetRef", "template")
+	if err != nil {
+		return nil, err
+	}
+
+	// RelationshipKEDAScaledJobTriggerAuthentication
+	for _, ref := range getKEDATriggerAuthenticationReferences(n) {
+		result.AddDependencyByKey(ref.Key(), RelationshipKEDAScaledJobTriggerAuthentication)
+	}
+
+	return result, nil
+}
+
+// getKEDAScaledObjectRelationships returns a map of relationships that this
+// KEDA ScaledObject has with other objects, based on what was referenced in
+// its manifest.
+func getKEDAScaledObjectRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipKEDAScaledObjectScaleTarget (defaults to an apps/v1 Deployment)
+	if name := n.GetNestedString("spec", "scaleTargetRef", "name"); len(name) > 0 {
+		apiVersion := n.GetNestedString("spec", "scaleTargetRef", "apiVersion")
+		if len(apiVersion) == 0 {
+			apiVersion = "apps/v1"
+		}
+		kind := n.GetNestedString("spec", "scaleTargetRef", "kind")
+		if len(kind) == 0 {
+			kind = "Deployment"
+		}
+		if gv, err := schema.ParseGroupVersion(apiVersion); err == nil {
+			ref = ObjectReference{Group: gv.Group, Kind: kind, Name: name, Namespace: n.Namespace}
+			result.AddDependencyByKey(ref.Key(), RelationshipKEDAScaledObjectScaleTarget)
+		}
+	}
+
+	// RelationshipKEDAScaledObjectTriggerAuthentication
+	for _, ref := range getKEDATriggerAuthenticationReferences(n) {
+		result.AddDependencyByKey(ref.Key(), RelationshipKEDAScaledObjectTriggerAuthentication)
+	}
+
+	return &result, nil
+}
+
+// getKEDATriggerAuthenticationRelationships returns a map of relationships
+// that this KEDA TriggerAuthentication or ClusterTriggerAuthentication has
+// with other objects, based on what was referenced in its manifest.
+// ClusterTriggerAuthentications reference objects in the provided KEDA
+// namespace.
+func getKEDATriggerAuthenticationRelationships(n *Node, kedaNamespace string) (*RelationshipMap, error) {
+	var ref ObjectReference
+	ns := n.Namespace
+	if n.Kind == "ClusterTriggerAuthentication" {
+		ns = kedaNamespace
+	}
+	result := newRelationshipMap()
+
+	// RelationshipKEDATriggerAuthenticationConfigMap
+	for _, name := range getNestedSliceStrings(n, "name", "spec", "configMapTargetRef") {
+		ref = ObjectReference{Kind: "ConfigMap", Name: name, Namespace: ns}
+		result.AddDependencyByKey(ref.Key(), RelationshipKEDATriggerAuthenticationConfigMap)
+	}
+
+	// RelationshipKEDATriggerAuthenticationSecret
+	for _, name := range getNestedSliceStrings(n, "name", "spec", "secretTargetRef") {
+		ref = ObjectReference{Kind: "Secret", Name: name, Namespace: ns}
+		result.AddDependencyByKey(ref.Key(), RelationshipKEDATriggerAuthenticationSecret)
+	}
+
+	return &result, nil
+}
+
+// getKEDATriggerAuthenticationReferences returns the references to the
+// TriggerAuthentications & ClusterTriggerAuthentications used by the triggers
+// of this KEDA ScaledObject or ScaledJob.
+func getKEDATriggerAuthenticationReferences(n *Node) []ObjectReference {
+	triggers, _, _ := unstructuredv1.NestedSlice(n.UnstructuredContent(), "spec", "triggers")
+
+	var refs []ObjectReference
+	for _, t := range triggers {
+		trigger, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructuredv1.NestedString(trigger, "authenticationRef", "name")
+		if len(name) == 0 {
+			continue
+		}
+		kind, _, _ := unstructuredv1.NestedString(trigger, "authenticationRef", "kind")
+		switch kind {
+		case "ClusterTriggerAuthentication":
+			refs = append(refs, ObjectReference{Group: KEDAGroupName, Kind: kind, Name: name})
+		default:
+			refs = append(refs, ObjectReference{Group: KEDAGroupName, Kind: "TriggerAuthentication", Name: name, Namespace: n.Namespace})
+		}
+	}
+	return refs
+}
+
+// getNestedSliceStrings returns the non-empty string values of the provided
+// field, for every item in the slice nested in the provided fields of the node.
+func getNestedSliceStrings(n *Node, field string, fields ...string) []string {
+	items, _, _ := unstructuredv1.NestedSlice(n.UnstructuredContent(), fields...)
+
+	var result []string
+	for _, i := range items {
+		item, ok := i.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if val, _, _ := unstructuredv1.NestedString(item, field); len(val) > 0 {
+			result = append(result, val)
+		}
+	}
+	return result
+}
@@ -1,11 +1,16 @@
 package graph
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
-	lhv1beta2 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	"github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	eventsv1 "k8s.io/api/events/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -16,38 +21,61 @@ import (
 	schedulingv1 "k8s.io/api/scheduling/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authentication/serviceaccount"
 	"k8s.io/apiserver/pkg/authentication/user"
-	"k8s.io/klog/v2"
 	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 )
 
-// Well-known labels & annotations.
+// Well-known labels, annotations & values.
 const (
 	// Hardcode "k8s.io/kubernetes/pkg/security/podsecuritypolicy/util.ValidatedPSPAnnotation"
 	// as "kubernetes.io/psp" so we don't need import the entire k8s.io/kubernetes
 	// package.
 	ValidatedPSPAnnotation = "kubernetes.io/psp"
+
+	// AutoManagedAPIServiceLabel is the label of APIServices registered by the
+	// kube-aggregator, set to "onstart" for built-in groups & "true" for groups
+	// served by CRDs.
+	AutoManagedAPIServiceLabel = "kube-aggregator.kubernetes.io/automanaged"
+
+	// SelectedNodeAnnotation is the annotation set by the scheduler on
+	// PersistentVolumeClaims with delayed binding, naming the Node which the
+	// volume should be provisioned for.
+	SelectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+
+	// defaultServiceAccountName is the name of the ServiceAccount created in
+	// every Namespace.
+	defaultServiceAccountName = "default"
 )
 
 const (
 	// Kubernetes APIService relationships.
-	RelationshipAPIService Relationship = "APIService"
+	RelationshipAPIService               Relationship = "APIService"
+	RelationshipAPIServiceCustomResource Relationship = "APIServiceCustomResource"
 
 	// Kubernetes ClusterRole, ClusterRoleBinding, RoleBinding relationships.
-	RelationshipClusterRoleAggregationRule Relationship = "ClusterRoleAggregationRule"
-	RelationshipClusterRolePolicyRule      Relationship = "ClusterRolePolicyRule"
-	RelationshipClusterRoleBindingSubject  Relationship = "ClusterRoleBindingSubject"
-	RelationshipClusterRoleBindingRole     Relationship = "ClusterRoleBindingRole"
-	RelationshipRoleBindingSubject         Relationship = "RoleBindingSubject"
-	RelationshipRoleBindingRole            Relationship = "RoleBindingRole"
-	RelationshipRolePolicyRule             Relationship = "RolePolicyRule"
+	RelationshipClusterRoleAggregationRule    Relationship = "ClusterRoleAggregationRule"
+	RelationshipClusterRolePolicyRule         Relationship = "ClusterRolePolicyRule"
+	RelationshipClusterRolePolicyRuleWildcard Relationship = "ClusterRolePolicyRuleWildcard"
+	RelationshipClusterRoleBindingSubject     Relationship = "ClusterRoleBindingSubject"
+	RelationshipClusterRoleBindingRole        Relationship = "ClusterRoleBindingRole"
+	RelationshipRoleBindingSubject            Relationship = "RoleBindingSubject"
+	RelationshipRoleBindingRole               Relationship = "RoleBindingRole"
+	RelationshipRolePolicyRule                Relationship = "RolePolicyRule"
+	RelationshipRolePolicyRuleWildcard        Relationship = "RolePolicyRuleWildcard"
+
+	// Kubernetes CustomResourceDefinition relationships.
+	RelationshipCustomResourceDefinitionConversionWebhook Relationship = "CustomResourceDefinitionConversionWebhook"
+	RelationshipCustomResourceDefinitionCustomResource    Relationship = "CustomResourceDefinitionCustomResource"
 
 	// Kubernetes CSINode relationships.
 	RelationshipCSINodeDriver Relationship = "CSINodeDriver"
@@ -55,10 +83,24 @@ const (
 	// Kubernetes CSIStorageCapacity relationships.
 	RelationshipCSIStorageCapacityStorageClass Relationship = "CSIStorageCapacityStorageClass"
 
+	// Kubernetes Endpoints relationships.
+	RelationshipEndpointsAddressNotReady Relationship = "EndpointsAddressNotReady"
+	RelationshipEndpointsAddressReady    Relationship = "EndpointsAddressReady"
+	RelationshipEndpointsService         Relationship = "EndpointsService"
+
+	// Kubernetes EndpointSlice relationships.
+	RelationshipEndpointSliceEndpointNotReady Relationship = "EndpointSliceEndpointNotReady"
+	RelationshipEndpointSliceEndpointReady    Relationship = "EndpointSliceEndpointReady"
+	RelationshipEndpointSliceService          Relationship = "EndpointSliceService"
+
 	// Kubernetes Event relationships.
 	RelationshipEventRegarding Relationship = "EventRegarding"
 	RelationshipEventRelated   Relationship = "EventRelated"
 
+	// Kubernetes HorizontalPodAutoscaler relationships.
+	RelationshipHorizontalPodAutoscalerMetricObject Relationship = "HorizontalPodAutoscalerMetricObject"
+	RelationshipHorizontalPodAutoscalerScaleTarget  Relationship = "HorizontalPodAutoscalerScaleTarget"
+
 	// Kubernetes Ingress & IngressClass relationships.
 	RelationshipIngressClass           Relationship = "IngressClass"
 	RelationshipIngressClassParameters Relationship = "IngressClassParameters"
@@ -67,45 +109,49 @@ const (
 	RelationshipIngressTLSSecret       Relationship = "IngressTLSSecret"
 
 	// Kubernetes MutatingWebhookConfiguration & ValidatingWebhookConfiguration relationships.
+	RelationshipWebhookConfigurationRule    Relationship = "WebhookConfigurationRule"
 	RelationshipWebhookConfigurationService Relationship = "WebhookConfigurationService"
 
+	// Kubernetes Namespace relationships.
+	RelationshipNamespaceContent               Relationship = "NamespaceContent"
+	RelationshipNamespaceDefaultServiceAccount Relationship = "NamespaceDefaultServiceAccount"
+	RelationshipNamespaceLimitRange            Relationship = "NamespaceLimitRange"
+	RelationshipNamespaceResourceQuota         Relationship = "NamespaceResourceQuota"
+	RelationshipNamespaceRoleBinding           Relationship = "NamespaceRoleBinding"
+
 	// Kubernetes RelationshipNetworkPolicy relationships.
-	RelationshipNetworkPolicy Relationship = "NetworkPolicy"
+	RelationshipNetworkPolicy              Relationship = "NetworkPolicy"
+	RelationshipNetworkPolicyEgressPeerOf  Relationship = "NetworkPolicyEgressPeerOf"
+	RelationshipNetworkPolicyIngressPeerOf Relationship = "NetworkPolicyIngressPeerOf"
 
 	// Kubernetes Owner-Dependent relationships.
 	RelationshipControllerRef Relationship = "ControllerReference"
 	RelationshipOwnerRef      Relationship = "OwnerReference"
 
 	// Kubernetes PersistentVolume & PersistentVolumeClaim relationships.
-	RelationshipPersistentVolumeClaim           Relationship = "PersistentVolumeClaim"
-	RelationshipPersistentVolumeCSIDriver       Relationship = "PersistentVolumeCSIDriver"
-	RelationshipPersistentVolumeCSIDriverSecret Relationship = "PersistentVolumeCSIDriverSecret"
-	RelationshipPersistentVolumeStorageClass    Relationship = "PersistentVolumeStorageClass"
-
-	// Kubernetes Longhorn relationships.
-	RelationshipLonghornReplicaVolume               Relationship = "LonghornReplicaVolume"
-	RelationshipLonghornReplicaNode                 Relationship = "LonghornReplicaNode"
-	RelationshipLonghornVolumePersistentVolume      Relationship = "LonghornVolumePersistentVolume"
-	RelationshipLonghornVolumePersistentVolumeClaim Relationship = "LonghornVolumePersistentVolumeClaim"
-
-	RelationshipVMPersistentVolumeClaim       Relationship = "VMPersistentVolumeClaim"
-	RelationshipVMCloudInitSecret             Relationship = "VMCloudInitSecret"
-	RelationshipVMNetworkAttachmentDefinition Relationship = "VMNetworkAttachmentDefinition"
-	RelationshipVMINode                       Relationship = "VMINode"
+	RelationshipPersistentVolumeClaim             Relationship = "PersistentVolumeClaim"
+	RelationshipPersistentVolumeClaimDataSource   Relationship = "PersistentVolumeClaimDataSource"
+	RelationshipPersistentVolumeClaimSelectedNode Relationship = "PersistentVolumeClaimSelectedNode"
+	RelationshipPersistentVolumeClaimStorageClass Relationship = "PersistentVolumeClaimStorageClass"
+	RelationshipPersistentVolumeCSIDriver         Relationship = "PersistentVolumeCSIDriver"
+	RelationshipPersistentVolumeCSIDriverSecret   Relationship = "PersistentVolumeCSIDriverSecret"
+	RelationshipPersistentVolumeStorageClass      Relationship = "PersistentVolumeStorageClass"
 
 	RelationshipPersistentVolumeClaimVMImage Relationship = "PersistentVolumeClaimVMImage"
 
 	// Kubernetes Pod relationships.
-	RelationshipPodContainerEnv          Relationship = "PodContainerEnvironment"
-	RelationshipPodImagePullSecret       Relationship = "PodImagePullSecret" //nolint:gosec
-	RelationshipPodNode                  Relationship = "PodNode"
-	RelationshipPodPriorityClass         Relationship = "PodPriorityClass"
-	RelationshipPodRuntimeClass          Relationship = "PodRuntimeClass"
-	RelationshipPodSecurityPolicy        Relationship = "PodSecurityPolicy"
-	RelationshipPodServiceAccount        Relationship = "PodServiceAccount"
-	RelationshipPodVolume                Relationship = "PodVolume"
-	RelationshipPodVolumeCSIDriver       Relationship = "PodVolumeCSIDriver"
-	RelationshipPodVolumeCSIDriverSecret Relationship = "PodVolumeCSIDriverSecret" //nolint:gosec
+	RelationshipPodContainerEnv                Relationship = "PodContainerEnvironment"
+	RelationshipPodEphemeralVolume             Relationship = "PodEphemeralVolume"
+	RelationshipPodImagePullSecret             Relationship = "PodImagePullSecret" //nolint:gosec
+	RelationshipPodNetworkAttachmentDefinition Relationship = "PodNetworkAttachmentDefinition"
+	RelationshipPodNode                        Relationship = "PodNode"
+	RelationshipPodPriorityClass               Relationship = "PodPriorityClass"
+	RelationshipPodRuntimeClass                Relationship = "PodRuntimeClass"
+	RelationshipPodSecurityPolicy              Relationship = "PodSecurityPolicy"
+	RelationshipPodServiceAccount              Relationship = "PodServiceAccount"
+	RelationshipPodVolume                      Relationship = "PodVolume"
+	RelationshipPodVolumeCSIDriver             Relationship = "PodVolumeCSIDriver"
+	RelationshipPodVolumeCSIDriverSecret       Relationship = "PodVolumeCSIDriverSecret" //nolint:gosec
 
 	// Kubernetes PodDisruptionBudget relationships.
 	RelationshipPodDisruptionBudget Relationship = "PodDisruptionBudget"
@@ -125,6 +171,17 @@ const (
 	RelationshipServiceAccountImagePullSecret Relationship = "ServiceAccountImagePullSecret"
 	RelationshipServiceAccountSecret          Relationship = "ServiceAccountSecret"
 
+	// Kubernetes StatefulSet relationships.
+	RelationshipStatefulSetRetainedVolumeClaim Relationship = "StatefulSetRetainedVolumeClaim"
+	RelationshipStatefulSetService             Relationship = "StatefulSetService"
+	RelationshipStatefulSetVolumeClaimTemplate Relationship = "StatefulSetVolumeClaimTemplate"
+
+	// Kubernetes ValidatingAdmissionPolicy & ValidatingAdmissionPolicyBinding relationships.
+	RelationshipValidatingAdmissionPolicyBindingMatch  Relationship = "ValidatingAdmissionPolicyBindingMatch"
+	RelationshipValidatingAdmissionPolicyBindingParam  Relationship = "ValidatingAdmissionPolicyBindingParam"
+	RelationshipValidatingAdmissionPolicyBindingPolicy Relationship = "ValidatingAdmissionPolicyBindingPolicy"
+	RelationshipValidatingAdmissionPolicyMatch         Relationship = "ValidatingAdmissionPolicyMatch"
+
 	// Kubernetes StorageClass relationships.
 	RelationshipStorageClassProvisioner Relationship = "StorageClassProvisioner"
 
@@ -136,6 +193,16 @@ const (
 	RelationshipVolumeAttachmentSourceVolumeCSIDriver       Relationship = "VolumeAttachmentSourceVolumeCSIDriver"
 	RelationshipVolumeAttachmentSourceVolumeCSIDriverSecret Relationship = "VolumeAttachmentSourceVolumeCSIDriverSecret"
 	RelationshipVolumeAttachmentSourceVolumeStorageClass    Relationship = "VolumeAttachmentSourceVolumeStorageClass"
+
+	// Kubernetes workload controller (eg. Deployment, CronJob) relationships.
+	RelationshipWorkloadTemplateContainerEnv          Relationship = "WorkloadTemplateContainerEnvironment"
+	RelationshipWorkloadTemplateImagePullSecret       Relationship = "WorkloadTemplateImagePullSecret" //nolint:gosec
+	RelationshipWorkloadTemplatePriorityClass         Relationship = "WorkloadTemplatePriorityClass"
+	RelationshipWorkloadTemplateRuntimeClass          Relationship = "WorkloadTemplateRuntimeClass"
+	RelationshipWorkloadTemplateServiceAccount        Relationship = "WorkloadTemplateServiceAccount"
+	RelationshipWorkloadTemplateVolume                Relationship = "WorkloadTemplateVolume"
+	RelationshipWorkloadTemplateVolumeCSIDriver       Relationship = "WorkloadTemplateVolumeCSIDriver"
+	RelationshipWorkloadTemplateVolumeCSIDriverSecret Relationship = "WorkloadTemplateVolumeCSIDriverSecret" //nolint:gosec
 )
 
 // getAPIServiceRelationships returns a map of relationships that this
@@ -157,6 +224,16 @@ func getAPIServiceRelationships(n *Node) (*RelationshipMap, error) {
 		result.AddDependencyByKey(ref.Key(), RelationshipAPIService)
 	}
 
+	// RelationshipAPIServiceCustomResource (only for groups served by either an
+	// extension API server or CRDs, skipping built-in groups, and only for kinds
+	// served at the version of the APIService)
+	isAggregated := apisvc.Spec.Service != nil
+	isCRDGroup := apisvc.Labels[AutoManagedAPIServiceLabel] == "true"
+	if len(apisvc.Spec.Group) > 0 && (isAggregated || isCRDGroup) {
+		os := ObjectSelector{Group: apisvc.Spec.Group, Version: apisvc.Spec.Version}
+		result.AddDependentBySelector(os, RelationshipAPIServiceCustomResource)
+	}
+
 	return &result, nil
 }
 
@@ -170,9 +247,7 @@ func getClusterRoleRelationships(n *Node) (*RelationshipMap, error) {
 		return nil, err
 	}
 
-	var os ObjectSelector
 	var ols ObjectLabelSelector
-	var ref ObjectReference
 	result := newRelationshipMap()
 
 	// RelationshipClusterRoleAggregationRule
@@ -188,20 +263,8 @@ func getClusterRoleRelationships(n *Node) (*RelationshipMap, error) {
 	}
 
 	// RelationshipClusterRolePolicyRule
-	for _, r := range cr.Rules {
-		if podSecurityPolicyMatches(r) {
-			switch len(r.ResourceNames) {
-			case 0:
-				os = ObjectSelector{Group: policyv1beta1.GroupName, Kind: "PodSecurityPolicy"}
-				result.AddDependencyBySelector(os, RelationshipClusterRolePolicyRule)
-			default:
-				for _, n := range r.ResourceNames {
-					ref = ObjectReference{Group: policyv1beta1.GroupName, Kind: "PodSecurityPolicy", Name: n}
-					result.AddDependencyByKey(ref.Key(), RelationshipClusterRolePolicyRule)
-				}
-			}
-		}
-	}
+	// RelationshipClusterRolePolicyRuleWildcard
+	addPolicyRuleRelationships(&result, cr.Rules, "", RelationshipClusterRolePolicyRule, RelationshipClusterRolePolicyRuleWildcard)
 
 	return &result, nil
 }
@@ -269,6 +332,39 @@ func getClusterRoleBindingRelationships(n *Node) (*RelationshipMap, error) {
 	return &result, nil
 }
 
+// getCustomResourceDefinitionRelationships returns a map of relationships
+// that this CustomResourceDefinition has with other objects, based on what was
+// referenced in its manifest.
+func getCustomResourceDefinitionRelationships(n *Node) (*RelationshipMap, error) {
+	var crd apiextensionsv1.CustomResourceDefinition
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &crd)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipCustomResourceDefinitionConversionWebhook
+	if c := crd.Spec.Conversion; c != nil && c.Webhook != nil && c.Webhook.ClientConfig != nil {
+		if svc := c.Webhook.ClientConfig.Service; svc != nil {
+			ref = ObjectReference{Kind: "Service", Namespace: svc.Namespace, Name: svc.Name}
+			result.AddDependencyByKey(ref.Key(), RelationshipCustomResourceDefinitionConversionWebhook)
+		}
+	}
+
+	// RelationshipCustomResourceDefinitionCustomResource
+	for _, v := range crd.Spec.Versions {
+		if v.Served {
+			os := ObjectSelector{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}
+			result.AddDependentBySelector(os, RelationshipCustomResourceDefinitionCustomResource)
+			break
+		}
+	}
+
+	return &result, nil
+}
+
 // getCSINodeRelationships returns a map of relationships that this CSINode has
 // with other objects, based on what was referenced in its manifest.
 func getCSINodeRelationships(n *Node) (*RelationshipMap, error) {
@@ -335,6 +431,140 @@ func getEventRelationships(n *Node) (*RelationshipMap, error) {
 	return &result, nil
 }
 
+// getEndpointsRelationships returns a map of relationships that this
+// Endpoints has with other objects, based on what was referenced in its
+// manifest.
+func getEndpointsRelationships(n *Node) (*RelationshipMap, error) {
+	var ep corev1.Endpoints
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &ep)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	ns := ep.Namespace
+	result := newRelationshipMap()
+
+	// RelationshipEndpointsService
+	ref = ObjectReference{Kind: "Service", Name: ep.Name, Namespace: ns}
+	result.AddDependencyByKey(ref.Key(), RelationshipEndpointsService)
+
+	// RelationshipEndpointsAddressReady
+	// RelationshipEndpointsAddressNotReady (the targets of the addresses are
+	// dependents, so that the dependents of a Service include its endpoints)
+	for _, s := range ep.Subsets {
+		for _, addr := range s.Addresses {
+			if ref, ok := getEndpointTargetReference(addr.TargetRef, ns); ok {
+				result.AddDependentByKey(ref.Key(), RelationshipEndpointsAddressReady)
+			}
+		}
+		for _, addr := range s.NotReadyAddresses {
+			if ref, ok := getEndpointTargetReference(addr.TargetRef, ns); ok {
+				result.AddDependentByKey(ref.Key(), RelationshipEndpointsAddressNotReady)
+			}
+		}
+	}
+
+	return &result, nil
+}
+
+// getEndpointSliceRelationships returns a map of relationships that this
+// EndpointSlice has with other objects, based on what was referenced in its
+// manifest.
+func getEndpointSliceRelationships(n *Node) (*RelationshipMap, error) {
+	var eps discoveryv1.EndpointSlice
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &eps)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	ns := eps.Namespace
+	result := newRelationshipMap()
+
+	// RelationshipEndpointSliceService
+	if name := eps.Labels[discoveryv1.LabelServiceName]; len(name) > 0 {
+		ref = ObjectReference{Kind: "Service", Name: name, Namespace: ns}
+		result.AddDependencyByKey(ref.Key(), RelationshipEndpointSliceService)
+	}
+
+	// RelationshipEndpointSliceEndpointReady
+	// RelationshipEndpointSliceEndpointNotReady (the targets of the endpoints
+	// are dependents, so that the dependents of a Service include its endpoints)
+	for _, e := range eps.Endpoints {
+		ref, ok := getEndpointTargetReference(e.TargetRef, ns)
+		if !ok {
+			continue
+		}
+		// Endpoints with an unknown ready condition are treated as ready
+		if e.Conditions.Ready == nil || *e.Conditions.Ready {
+			result.AddDependentByKey(ref.Key(), RelationshipEndpointSliceEndpointReady)
+		} else {
+			result.AddDependentByKey(ref.Key(), RelationshipEndpointSliceEndpointNotReady)
+		}
+	}
+
+	return &result, nil
+}
+
+// getEndpointTargetReference returns the reference to the object (eg. Pod,
+// VirtualMachineInstance) providing an endpoint. References without an API
+// version are resolved by their kind.
+func getEndpointTargetReference(ref *corev1.ObjectReference, ns string) (ObjectReference, bool) {
+	if ref == nil || len(ref.Kind) == 0 || len(ref.Name) == 0 {
+		return ObjectReference{}, false
+	}
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return ObjectReference{}, false
+	}
+	group := gv.Group
+	if len(ref.APIVersion) == 0 && ref.Kind == "VirtualMachineInstance" {
+		group = kubevirtv1.SchemeGroupVersion.Group
+	}
+	if len(ref.Namespace) > 0 {
+		ns = ref.Namespace
+	}
+	return ObjectReference{Group: group, Kind: ref.Kind, Name: ref.Name, Namespace: ns}, true
+}
+
+// getHorizontalPodAutoscalerRelationships returns a map of relationships that
+// this HorizontalPodAutoscaler has with other objects, based on what was
+// referenced in its manifest.
+func getHorizontalPodAutoscalerRelationships(n *Node) (*RelationshipMap, error) {
+	var hpa autoscalingv2.HorizontalPodAutoscaler
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &hpa)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	ns := hpa.Namespace
+	result := newRelationshipMap()
+
+	// RelationshipHorizontalPodAutoscalerScaleTarget
+	if t := hpa.Spec.ScaleTargetRef; len(t.Kind) > 0 && len(t.Name) > 0 {
+		if gv, err := schema.ParseGroupVersion(t.APIVersion); err == nil {
+			ref = ObjectReference{Group: gv.Group, Kind: t.Kind, Name: t.Name, Namespace: ns}
+			result.AddDependencyByKey(ref.Key(), RelationshipHorizontalPodAutoscalerScaleTarget)
+		}
+	}
+
+	// RelationshipHorizontalPodAutoscalerMetricObject
+	for _, m := range hpa.Spec.Metrics {
+		if m.Type != autoscalingv2.ObjectMetricSourceType || m.Object == nil {
+			continue
+		}
+		o := m.Object.DescribedObject
+		if gv, err := schema.ParseGroupVersion(o.APIVersion); err == nil && len(o.Kind) > 0 && len(o.Name) > 0 {
+			ref = ObjectReference{Group: gv.Group, Kind: o.Kind, Name: o.Name, Namespace: ns}
+			result.AddDependencyByKey(ref.Key(), RelationshipHorizontalPodAutoscalerMetricObject)
+		}
+	}
+
+	return &result, nil
+}
+
 // getIngressRelationships returns a map of relationships that this Ingress has
 // with other objects, based on what was referenced in its manifest.
 //
@@ -438,6 +668,11 @@ func getIngressRelationships(n *Node) (*RelationshipMap, error) {
 		}
 	}
 
+	// RelationshipCertManagerIssuerAnnotation
+	if ref, ok := getCertManagerIssuerAnnotationReference(n); ok {
+		result.AddDependencyByKey(ref.Key(), RelationshipCertManagerIssuerAnnotation)
+	}
+
 	return &result, nil
 }
 
@@ -492,6 +727,42 @@ func getMutatingWebhookConfigurationRelationships(n *Node) (*RelationshipMap, er
 		}
 	}
 
+	// RelationshipWebhookConfigurationRule
+	for _, wh := range mwc.Webhooks {
+		oas, err := newWebhookAdmissionSelector(wh.Rules, wh.NamespaceSelector, wh.ObjectSelector)
+		if err != nil {
+			return nil, err
+		}
+		result.AddDependentByAdmissionSelector(oas, withEdgeMetadata(RelationshipWebhookConfigurationRule, wh.Name))
+	}
+
+	return &result, nil
+}
+
+// getNamespaceRelationships returns a map of relationships that this
+// Namespace has with the objects scoped to it.
+func getNamespaceRelationships(n *Node) (*RelationshipMap, error) {
+	var os ObjectSelector
+	var ref ObjectReference
+	nsSet := sets.NewString(n.Name)
+	result := newRelationshipMap()
+
+	// RelationshipNamespaceDefaultServiceAccount
+	ref = ObjectReference{Kind: "ServiceAccount", Name: defaultServiceAccountName, Namespace: n.Name}
+	result.AddDependentByKey(ref.Key(), RelationshipNamespaceDefaultServiceAccount)
+
+	// RelationshipNamespaceLimitRange
+	os = ObjectSelector{Kind: "LimitRange", Namespaces: nsSet}
+	result.AddDependentBySelector(os, RelationshipNamespaceLimitRange)
+
+	// RelationshipNamespaceResourceQuota
+	os = ObjectSelector{Kind: "ResourceQuota", Namespaces: nsSet}
+	result.AddDependentBySelector(os, RelationshipNamespaceResourceQuota)
+
+	// RelationshipNamespaceRoleBinding
+	os = ObjectSelector{Group: rbacv1.GroupName, Kind: "RoleBinding", Namespaces: nsSet}
+	result.AddDependentBySelector(os, RelationshipNamespaceRoleBinding)
+
 	return &result, nil
 }
 
@@ -517,13 +788,60 @@ func getNetworkPolicyRelationships(n *Node) (*RelationshipMap, error) {
 	ols = ObjectLabelSelector{Kind: "Pod", Namespace: ns, Selector: selector}
 	result.AddDependencyByLabelSelector(ols, RelationshipNetworkPolicy)
 
+	// RelationshipNetworkPolicyIngressPeerOf
+	for _, rule := range netpol.Spec.Ingress {
+		err = addNetworkPolicyPeerRelationships(&result, rule.From, ns, RelationshipNetworkPolicyIngressPeerOf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// RelationshipNetworkPolicyEgressPeerOf
+	for _, rule := range netpol.Spec.Egress {
+		err = addNetworkPolicyPeerRelationships(&result, rule.To, ns, RelationshipNetworkPolicyEgressPeerOf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &result, nil
 }
 
+// addNetworkPolicyPeerRelationships adds the relationships to the Pods matched
+// by the provided NetworkPolicy peers to the provided relationship map. Peers
+// with only an IP block are skipped.
+func addNetworkPolicyPeerRelationships(result *RelationshipMap, peers []networkingv1.NetworkPolicyPeer, ns string, r Relationship) error {
+	for _, peer := range peers {
+		if peer.PodSelector == nil && peer.NamespaceSelector == nil {
+			continue
+		}
+		selector := labels.Everything()
+		if peer.PodSelector != nil {
+			s, err := metav1.LabelSelectorAsSelector(peer.PodSelector)
+			if err != nil {
+				return err
+			}
+			selector = s
+		}
+		if peer.NamespaceSelector == nil {
+			ols := ObjectLabelSelector{Kind: "Pod", Namespace: ns, Selector: selector}
+			result.AddDependencyByLabelSelector(ols, r)
+			continue
+		}
+		nsSelector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+		if err != nil {
+			return err
+		}
+		ons := ObjectNamespaceSelector{Kind: "Pod", Selector: selector, NamespaceSelector: nsSelector}
+		result.AddDependencyByNamespaceSelector(ons, r)
+	}
+	return nil
+}
+
 // getPersistentVolumeRelationships returns a map of relationships that this
 // PersistentVolume has with other objects, based on what was referenced in its
-// manifest.
-func getPersistentVolumeRelationships(n *Node) (*RelationshipMap, error) {
+// manifest. Longhorn Volumes are looked up in the provided namespace.
+func getPersistentVolumeRelationships(n *Node, longhornNamespace string) (*RelationshipMap, error) {
 	var pv corev1.PersistentVolume
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &pv)
 	if err != nil {
@@ -574,9 +892,17 @@ func getPersistentVolumeRelationships(n *Node) (*RelationshipMap, error) {
 		result.AddDependencyByKey(ref.Key(), RelationshipPersistentVolumeStorageClass)
 	}
 
-	// RelationshipLonghornVolumePersistentVolume
-	if pv.Annotations["pv.kubernetes.io/provisioned-by"] == "driver.longhorn.io" {
-		ref = ObjectReference{Kind: "Volume", Name: pv.Name, Namespace: "longhorn-system"}
+	// RelationshipLonghornVolumePersistentVolume (Longhorn volumes are named
+	// after the volume handle of statically provisioned volumes, or after the
+	// PersistentVolume itself)
+	var lhVolume string
+	if csi := pv.Spec.CSI; csi != nil && csi.Driver == LonghornDriverName {
+		lhVolume = csi.VolumeHandle
+	} else if pv.Annotations["pv.kubernetes.io/provisioned-by"] == LonghornDriverName {
+		lhVolume = pv.Name
+	}
+	if len(lhVolume) > 0 {
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "Volume", Name: lhVolume, Namespace: longhornNamespace}
 		result.AddDependentByKey(ref.Key(), RelationshipLonghornVolumePersistentVolume)
 	}
 
@@ -585,8 +911,9 @@ func getPersistentVolumeRelationships(n *Node) (*RelationshipMap, error) {
 
 // getPersistentVolumeClaimRelationships returns a map of relationships that
 // this PersistentVolumeClaim has with other objects, based on what was
-// referenced in its manifest.
-func getPersistentVolumeClaimRelationships(n *Node) (*RelationshipMap, error) {
+// referenced in its manifest. Longhorn Volumes are looked up in the provided
+// namespace.
+func getPersistentVolumeClaimRelationships(n *Node, longhornNamespace string) (*RelationshipMap, error) {
 	var pvc corev1.PersistentVolumeClaim
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &pvc)
 	if err != nil {
@@ -600,58 +927,61 @@ func getPersistentVolumeClaimRelationships(n *Node) (*RelationshipMap, error) {
 	if pv := pvc.Spec.VolumeName; len(pv) > 0 {
 		ref = ObjectReference{Kind: "PersistentVolume", Name: pv}
 		result.AddDependencyByKey(ref.Key(), RelationshipPersistentVolumeClaim)
-		if pvc.Annotations["volume.kubernetes.io/storage-provisioner"] == "driver.longhorn.io" {
-			ref = ObjectReference{Kind: "Volume", Name: pv, Namespace: "longhorn-system"}
+		if pvc.Annotations["volume.kubernetes.io/storage-provisioner"] == LonghornDriverName {
+			ref = ObjectReference{Group: longhorn.GroupName, Kind: "Volume", Name: pv, Namespace: longhornNamespace}
 			result.AddDependentByKey(ref.Key(), RelationshipLonghornVolumePersistentVolumeClaim)
 		}
 	}
 
-	if imageID := pvc.Annotations["harvesterhci.io/imageId"]; imageID != "" {
-		klog.V(4).Infof("Found imageId %s in PVC %s/%s", imageID, pvc.Namespace, pvc.Name)
-		nn := strings.SplitN(imageID, "/", 2)
-		ref = ObjectReference{Kind: "VirtualMachineImage", Name: nn[1], Namespace: nn[0]}
-		result.AddDependentByKey(ref.Key(), RelationshipPersistentVolumeClaimVMImage)
+	// RelationshipPersistentVolumeClaimDataSource (dataSourceRef takes precedence
+	// over dataSource if both are set, cross-namespace data sources are only
+	// shown when allowed by a ReferenceGrant)
+	if ds := pvc.Spec.DataSourceRef; ds != nil && len(ds.Name) > 0 {
+		ns := n.GetNestedString("spec", "dataSourceRef", "namespace")
+		if len(ns) == 0 {
+			ns = pvc.Namespace
+		}
+		ref = ObjectReference{Kind: ds.Kind, Namespace: ns, Name: ds.Name}
+		if ds.APIGroup != nil {
+			ref.Group = *ds.APIGroup
+		}
+		result.AddDependencyByGrantedKey(ref.Key(), RelationshipPersistentVolumeClaimDataSource)
+	} else if ds := pvc.Spec.DataSource; ds != nil && len(ds.Name) > 0 {
+		ref = ObjectReference{Kind: ds.Kind, Namespace: pvc.Namespace, Name: ds.Name}
+		if ds.APIGroup != nil {
+			ref.Group = *ds.APIGroup
+		}
+		result.AddDependencyByKey(ref.Key(), RelationshipPersistentVolumeClaimDataSource)
 	}
 
-	return &result, nil
-}
-
-// getLonghornVolumeRelationships returns a map of relationships that
-// this LonghornVolume has with other objects, based on what was
-// referenced in its manifest.
-func getLonghornVolumeRelationships(n *Node) (*RelationshipMap, error) {
-	var volume lhv1beta2.Volume
-	v := n.UnstructuredContent()
-	// remove size from spec to avoid error when converting to Volume
-	v["spec"].(map[string]interface{})["size"] = int64(0)
-	err := runtime.DefaultUnstructuredConverter.FromUnstructured(v, &volume)
-	if err != nil {
-		return nil, err
+	// RelationshipPersistentVolumeClaimSelectedNode
+	if node := pvc.Annotations[SelectedNodeAnnotation]; len(node) > 0 {
+		ref = ObjectReference{Kind: "Node", Name: node}
+		result.AddDependencyByKey(ref.Key(), RelationshipPersistentVolumeClaimSelectedNode)
 	}
 
-	var ref ObjectReference
-	result := newRelationshipMap()
-
-	// RelationshipLonghornVolume
-	if pvName := volume.Status.KubernetesStatus.PVName; len(pvName) > 0 {
-		ref = ObjectReference{Kind: "PersistentVolume", Name: pvName}
-		result.AddDependencyByKey(ref.Key(), RelationshipLonghornVolumePersistentVolume)
+	// RelationshipPersistentVolumeClaimStorageClass
+	if sc := pvc.Spec.StorageClassName; sc != nil && len(*sc) > 0 {
+		ref = ObjectReference{Group: storagev1.GroupName, Kind: "StorageClass", Name: *sc}
+		result.AddDependencyByKey(ref.Key(), RelationshipPersistentVolumeClaimStorageClass)
 	}
 
-	if pvcName := volume.Status.KubernetesStatus.PVCName; len(pvcName) > 0 {
-		ref = ObjectReference{Kind: "PersistentVolumeClaim", Name: pvcName, Namespace: volume.Status.KubernetesStatus.Namespace}
-		result.AddDependencyByKey(ref.Key(), RelationshipLonghornVolumePersistentVolumeClaim)
+	// RelationshipPersistentVolumeClaimVMImage
+	if ns, name, ok := parseHarvesterID(pvc.Annotations[HarvesterImageIDAnnotation], pvc.Namespace); ok {
+		ref = ObjectReference{Group: HarvesterGroupName, Kind: "VirtualMachineImage", Name: name, Namespace: ns}
+		result.AddDependentByKey(ref.Key(), RelationshipPersistentVolumeClaimVMImage)
 	}
 
 	return &result, nil
 }
 
-// getLonghornReplicaRelationships returns a map of relationships that
-// this LonghornReplica has with other objects, based on what was
-// referenced in its manifest.
-func getLonghornReplicaRelationships(n *Node) (*RelationshipMap, error) {
-	var replica lhv1beta2.Replica
-	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &replica)
+// getPodRelationships returns a map of relationships that this Pod has with
+// other objects, based on what was referenced in its manifest.
+//
+//nolint:funlen,gocognit
+func getPodRelationships(n *Node) (*RelationshipMap, error) {
+	var pod corev1.Pod
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &pod)
 	if err != nil {
 		return nil, err
 	}
@@ -659,133 +989,102 @@ func getLonghornReplicaRelationships(n *Node) (*RelationshipMap, error) {
 	var ref ObjectReference
 	result := newRelationshipMap()
 
-	// RelationshipLonghornReplica
-	if nodeName := replica.Spec.NodeID; len(nodeName) > 0 {
-		ref = ObjectReference{Kind: "Node", Name: nodeName}
-		result.AddDependencyByKey(ref.Key(), RelationshipLonghornReplicaNode)
-	}
-
-	if volumeName := replica.Spec.VolumeName; len(volumeName) > 0 {
-		ref = ObjectReference{Kind: "Volume", Name: volumeName, Namespace: "longhorn-system"}
-		result.AddDependencyByKey(ref.Key(), RelationshipLonghornReplicaVolume)
-	}
-
-	return &result, nil
-}
+	// RelationshipPodContainerEnv
+	// RelationshipPodImagePullSecret
+	// RelationshipPodPriorityClass
+	// RelationshipPodRuntimeClass
+	// RelationshipPodServiceAccount
+	// RelationshipPodVolume
+	// RelationshipPodVolumeCSIDriver
+	// RelationshipPodVolumeCSIDriverSecret
+	addPodSpecRelationships(&result, &pod.Spec, pod.Namespace, podRelationships)
 
-// getVMRelationships returns a map of relationships that
-// this VM has with other objects, based on what was
-// referenced in its manifest.
-func getVMRelationships(n *Node) (*RelationshipMap, error) {
-	var vm kubevirtv1.VirtualMachine
-	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &vm)
-	if err != nil {
-		return nil, err
+	// RelationshipPodEphemeralVolume (PersistentVolumeClaims of generic
+	// ephemeral volumes are named "<pod>-<volume>")
+	for _, v := range pod.Spec.Volumes {
+		if v.Ephemeral != nil {
+			ref = ObjectReference{Kind: "PersistentVolumeClaim", Name: fmt.Sprintf("%s-%s", pod.Name, v.Name), Namespace: pod.Namespace}
+			result.AddDependencyByKey(ref.Key(), RelationshipPodEphemeralVolume)
+		}
 	}
 
-	var ref ObjectReference
-	result := newRelationshipMap()
+	// RelationshipPodNode
+	ref = ObjectReference{Kind: "Node", Name: pod.Spec.NodeName}
+	result.AddDependencyByKey(ref.Key(), RelationshipPodNode)
 
-	// RelationshipVMPersistentVolumeClaim and RelationshipVMCloudInitSecret
-	volumes := vm.Spec.Template.Spec.Volumes
-	for _, volume := range volumes {
-		if volume.PersistentVolumeClaim != nil {
-			ref = ObjectReference{Kind: "PersistentVolumeClaim", Name: volume.PersistentVolumeClaim.ClaimName, Namespace: vm.Namespace}
-			result.AddDependentByKey(ref.Key(), RelationshipVMPersistentVolumeClaim)
-		}
-		if volume.CloudInitNoCloud != nil {
-			ref = ObjectReference{Kind: "Secret", Name: volume.CloudInitNoCloud.UserDataSecretRef.Name, Namespace: vm.Namespace}
-			result.AddDependentByKey(ref.Key(), RelationshipVMCloudInitSecret)
-			ref = ObjectReference{Kind: "Secret", Name: volume.CloudInitNoCloud.NetworkDataSecretRef.Name, Namespace: vm.Namespace}
-			result.AddDependentByKey(ref.Key(), RelationshipVMCloudInitSecret)
-		}
+	// RelationshipPodSecurityPolicy
+	if psp, ok := pod.Annotations[ValidatedPSPAnnotation]; ok {
+		ref = ObjectReference{Group: policyv1beta1.GroupName, Kind: "PodSecurityPolicy", Name: psp}
+		result.AddDependencyByKey(ref.Key(), RelationshipPodSecurityPolicy)
 	}
 
-	// RelationshipVMNetworkAttachmentDefinition
-	networks := vm.Spec.Template.Spec.Networks
-	for _, network := range networks {
-		if network.Multus != nil {
-			ref = ObjectReference{Kind: "NetworkAttachmentDefinition", Name: network.Multus.NetworkName, Namespace: vm.Namespace}
-			result.AddDependentByKey(ref.Key(), RelationshipVMNetworkAttachmentDefinition)
-		}
-	}
+	// RelationshipPodNetworkAttachmentDefinition
+	addMultusPodRelationships(&result, n)
 
 	return &result, nil
 }
 
-// getVMIRelationships returns a map of relationships that
-// this VM has with other objects, based on what was
-// referenced in its manifest.
-func getVMIRelationships(n *Node) (*RelationshipMap, error) {
-	var vmi kubevirtv1.VirtualMachineInstance
-	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &vmi)
-	if err != nil {
-		return nil, err
-	}
-
-	var ref ObjectReference
-	result := newRelationshipMap()
-
-	// RelationshipVMPersistentVolumeClaim and RelationshipVMCloudInitSecret
-	volumes := vmi.Spec.Volumes
-	for _, volume := range volumes {
-		if volume.PersistentVolumeClaim != nil {
-			ref = ObjectReference{Kind: "PersistentVolumeClaim", Name: volume.PersistentVolumeClaim.ClaimName, Namespace: vmi.Namespace}
-			result.AddDependentByKey(ref.Key(), RelationshipVMPersistentVolumeClaim)
-		}
-		if volume.CloudInitNoCloud != nil {
-			ref = ObjectReference{Kind: "Secret", Name: volume.CloudInitNoCloud.UserDataSecretRef.Name, Namespace: vmi.Namespace}
-			result.AddDependentByKey(ref.Key(), RelationshipVMCloudInitSecret)
-			ref = ObjectReference{Kind: "Secret", Name: volume.CloudInitNoCloud.NetworkDataSecretRef.Name, Namespace: vmi.Namespace}
-			result.AddDependentByKey(ref.Key(), RelationshipVMCloudInitSecret)
-		}
-	}
+// podSpecRelationships contains the relationship types used for each type of
+// reference in a pod spec.
+type podSpecRelationships struct {
+	ContainerEnv          Relationship
+	ImagePullSecret       Relationship
+	PriorityClass         Relationship
+	RuntimeClass          Relationship
+	ServiceAccount        Relationship
+	Volume                Relationship
+	VolumeCSIDriver       Relationship
+	VolumeCSIDriverSecret Relationship
+}
 
-	// RelationshipVMNetworkAttachmentDefinition
-	networks := vmi.Spec.Networks
-	for _, network := range networks {
-		if network.Multus != nil {
-			ref = ObjectReference{Kind: "NetworkAttachmentDefinition", Name: network.Multus.NetworkName, Namespace: vmi.Namespace}
-			result.AddDependentByKey(ref.Key(), RelationshipVMNetworkAttachmentDefinition)
-		}
+var (
+	// podRelationships contains the relationship types used for references in
+	// the spec of a Pod.
+	podRelationships = podSpecRelationships{
+		ContainerEnv:          RelationshipPodContainerEnv,
+		ImagePullSecret:       RelationshipPodImagePullSecret,
+		PriorityClass:         RelationshipPodPriorityClass,
+		RuntimeClass:          RelationshipPodRuntimeClass,
+		ServiceAccount:        RelationshipPodServiceAccount,
+		Volume:                RelationshipPodVolume,
+		VolumeCSIDriver:       RelationshipPodVolumeCSIDriver,
+		VolumeCSIDriverSecret: RelationshipPodVolumeCSIDriverSecret,
+	}
+	// workloadTemplateRelationships contains the relationship types used for
+	// references in the pod template of a workload controller.
+	workloadTemplateRelationships = podSpecRelationships{
+		ContainerEnv:          RelationshipWorkloadTemplateContainerEnv,
+		ImagePullSecret:       RelationshipWorkloadTemplateImagePullSecret,
+		PriorityClass:         RelationshipWorkloadTemplatePriorityClass,
+		RuntimeClass:          RelationshipWorkloadTemplateRuntimeClass,
+		ServiceAccount:        RelationshipWorkloadTemplateServiceAccount,
+		Volume:                RelationshipWorkloadTemplateVolume,
+		VolumeCSIDriver:       RelationshipWorkloadTemplateVolumeCSIDriver,
+		VolumeCSIDriverSecret: RelationshipWorkloadTemplateVolumeCSIDriverSecret,
 	}
+)
 
-	// RelationshipVMINode
-	node := vmi.Status.NodeName
-	ref = ObjectReference{Kind: "Node", Name: node}
-	result.AddDependencyByKey(ref.Key(), RelationshipVMINode)
-
-	return &result, nil
-}
-
-// getPodRelationships returns a map of relationships that this Pod has with
-// other objects, based on what was referenced in its manifest.
+// addPodSpecRelationships adds the relationships that the provided pod spec
+// has with other objects in the provided namespace into the provided map,
+// using the provided relationship types.
 //
 //nolint:funlen,gocognit
-func getPodRelationships(n *Node) (*RelationshipMap, error) {
-	var pod corev1.Pod
-	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &pod)
-	if err != nil {
-		return nil, err
-	}
-
+func addPodSpecRelationships(result *RelationshipMap, spec *corev1.PodSpec, ns string, rels podSpecRelationships) {
 	var ref ObjectReference
-	ns := pod.Namespace
-	result := newRelationshipMap()
 
-	// RelationshipPodContainerEnv
+	// Container environment variables
 	var cList []corev1.Container
-	cList = append(cList, pod.Spec.InitContainers...)
-	cList = append(cList, pod.Spec.Containers...)
+	cList = append(cList, spec.InitContainers...)
+	cList = append(cList, spec.Containers...)
 	for _, c := range cList {
 		for _, env := range c.EnvFrom {
 			switch {
 			case env.ConfigMapRef != nil:
 				ref = ObjectReference{Kind: "ConfigMap", Name: env.ConfigMapRef.Name, Namespace: ns}
-				result.AddDependencyByKey(ref.Key(), RelationshipPodContainerEnv)
+				result.AddDependencyByKey(ref.Key(), rels.ContainerEnv)
 			case env.SecretRef != nil:
 				ref = ObjectReference{Kind: "Secret", Name: env.SecretRef.Name, Namespace: ns}
-				result.AddDependencyByKey(ref.Key(), RelationshipPodContainerEnv)
+				result.AddDependencyByKey(ref.Key(), rels.ContainerEnv)
 			}
 		}
 		for _, env := range c.Env {
@@ -795,84 +1094,103 @@ func getPodRelationships(n *Node) (*RelationshipMap, error) {
 			switch {
 			case env.ValueFrom.ConfigMapKeyRef != nil:
 				ref = ObjectReference{Kind: "ConfigMap", Name: env.ValueFrom.ConfigMapKeyRef.Name, Namespace: ns}
-				result.AddDependencyByKey(ref.Key(), RelationshipPodContainerEnv)
+				result.AddDependencyByKey(ref.Key(), rels.ContainerEnv)
 			case env.ValueFrom.SecretKeyRef != nil:
 				ref = ObjectReference{Kind: "Secret", Name: env.ValueFrom.SecretKeyRef.Name, Namespace: ns}
-				result.AddDependencyByKey(ref.Key(), RelationshipPodContainerEnv)
+				result.AddDependencyByKey(ref.Key(), rels.ContainerEnv)
 			}
 		}
 	}
 
-	// RelationshipPodImagePullSecret
-	for _, ips := range pod.Spec.ImagePullSecrets {
+	// Image pull secrets
+	for _, ips := range spec.ImagePullSecrets {
 		ref = ObjectReference{Kind: "Secret", Name: ips.Name, Namespace: ns}
-		result.AddDependencyByKey(ref.Key(), RelationshipPodImagePullSecret)
+		result.AddDependencyByKey(ref.Key(), rels.ImagePullSecret)
 	}
 
-	// RelationshipPodNode
-	ref = ObjectReference{Kind: "Node", Name: pod.Spec.NodeName}
-	result.AddDependencyByKey(ref.Key(), RelationshipPodNode)
-
-	// RelationshipPodPriorityClass
-	if pc := pod.Spec.PriorityClassName; len(pc) != 0 {
+	// PriorityClass
+	if pc := spec.PriorityClassName; len(pc) != 0 {
 		ref = ObjectReference{Group: schedulingv1.GroupName, Kind: "PriorityClass", Name: pc}
-		result.AddDependencyByKey(ref.Key(), RelationshipPodPriorityClass)
+		result.AddDependencyByKey(ref.Key(), rels.PriorityClass)
 	}
 
-	// RelationshipPodRuntimeClass
-	if rc := pod.Spec.RuntimeClassName; rc != nil && len(*rc) != 0 {
+	// RuntimeClass
+	if rc := spec.RuntimeClassName; rc != nil && len(*rc) != 0 {
 		ref = ObjectReference{Group: nodev1.GroupName, Kind: "RuntimeClass", Name: *rc}
-		result.AddDependencyByKey(ref.Key(), RelationshipPodRuntimeClass)
+		result.AddDependencyByKey(ref.Key(), rels.RuntimeClass)
 	}
 
-	// RelationshipPodSecurityPolicy
-	if psp, ok := pod.Annotations[ValidatedPSPAnnotation]; ok {
-		ref = ObjectReference{Group: policyv1beta1.GroupName, Kind: "PodSecurityPolicy", Name: psp}
-		result.AddDependencyByKey(ref.Key(), RelationshipPodSecurityPolicy)
-	}
-
-	// RelationshipPodServiceAccount
-	if sa := pod.Spec.ServiceAccountName; len(sa) != 0 {
+	// ServiceAccount
+	if sa := spec.ServiceAccountName; len(sa) != 0 {
 		ref = ObjectReference{Kind: "ServiceAccount", Name: sa, Namespace: ns}
-		result.AddDependencyByKey(ref.Key(), RelationshipPodServiceAccount)
+		result.AddDependencyByKey(ref.Key(), rels.ServiceAccount)
 	}
 
-	// RelationshipPodVolume
-	// RelationshipPodVolumeCSIDriver
-	// RelationshipPodVolumeCSIDriverSecret
-	for _, v := range pod.Spec.Volumes {
+	// Volumes
+	for _, v := range spec.Volumes {
 		vs := v.VolumeSource
 		switch {
 		case vs.ConfigMap != nil:
 			ref = ObjectReference{Kind: "ConfigMap", Name: vs.ConfigMap.Name, Namespace: ns}
-			result.AddDependencyByKey(ref.Key(), RelationshipPodVolume)
+			result.AddDependencyByKey(ref.Key(), rels.Volume)
 		case vs.CSI != nil:
 			csi := vs.CSI
 			ref = ObjectReference{Group: storagev1.GroupName, Kind: "CSIDriver", Name: csi.Driver}
-			result.AddDependencyByKey(ref.Key(), RelationshipPodVolumeCSIDriver)
+			result.AddDependencyByKey(ref.Key(), rels.VolumeCSIDriver)
 			if nps := csi.NodePublishSecretRef; nps != nil {
 				ref = ObjectReference{Kind: "Secret", Name: nps.Name, Namespace: ns}
-				result.AddDependencyByKey(ref.Key(), RelationshipPodVolumeCSIDriverSecret)
+				result.AddDependencyByKey(ref.Key(), rels.VolumeCSIDriverSecret)
 			}
 		case vs.PersistentVolumeClaim != nil:
 			ref = ObjectReference{Kind: "PersistentVolumeClaim", Name: vs.PersistentVolumeClaim.ClaimName, Namespace: ns}
-			result.AddDependencyByKey(ref.Key(), RelationshipPodVolume)
+			result.AddDependencyByKey(ref.Key(), rels.Volume)
 		case vs.Projected != nil:
 			for _, src := range vs.Projected.Sources {
 				switch {
 				case src.ConfigMap != nil:
 					ref = ObjectReference{Kind: "ConfigMap", Name: src.ConfigMap.Name, Namespace: ns}
-					result.AddDependencyByKey(ref.Key(), RelationshipPodVolume)
+					result.AddDependencyByKey(ref.Key(), rels.Volume)
 				case src.Secret != nil:
 					ref = ObjectReference{Kind: "Secret", Name: src.Secret.Name, Namespace: ns}
-					result.AddDependencyByKey(ref.Key(), RelationshipPodVolume)
+					result.AddDependencyByKey(ref.Key(), rels.Volume)
 				}
 			}
 		case vs.Secret != nil:
 			ref = ObjectReference{Kind: "Secret", Name: vs.Secret.SecretName, Namespace: ns}
-			result.AddDependencyByKey(ref.Key(), RelationshipPodVolume)
+			result.AddDependencyByKey(ref.Key(), rels.Volume)
 		}
 	}
+}
+
+// getWorkloadTemplateRelationships returns a map of relationships that this
+// workload controller (eg. Deployment, CronJob) has with other objects, based
+// on what was referenced in the pod template nested in the provided fields of
+// its manifest.
+func getWorkloadTemplateRelationships(n *Node, fields ...string) (*RelationshipMap, error) {
+	result := newRelationshipMap()
+
+	tpl, found, err := unstructuredv1.NestedMap(n.UnstructuredContent(), fields...)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &result, nil
+	}
+	var template corev1.PodTemplateSpec
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(tpl, &template)
+	if err != nil {
+		return nil, err
+	}
+
+	// RelationshipWorkloadTemplateContainerEnv
+	// RelationshipWorkloadTemplateImagePullSecret
+	// RelationshipWorkloadTemplatePriorityClass
+	// RelationshipWorkloadTemplateRuntimeClass
+	// RelationshipWorkloadTemplateServiceAccount
+	// RelationshipWorkloadTemplateVolume
+	// RelationshipWorkloadTemplateVolumeCSIDriver
+	// RelationshipWorkloadTemplateVolumeCSIDriverSecret
+	addPodSpecRelationships(&result, &template.Spec, n.Namespace, workloadTemplateRelationships)
 
 	return &result, nil
 }
@@ -948,25 +1266,11 @@ func getRoleRelationships(n *Node) (*RelationshipMap, error) {
 		return nil, err
 	}
 
-	var os ObjectSelector
-	var ref ObjectReference
 	result := newRelationshipMap()
 
 	// RelationshipRolePolicyRule
-	for _, r := range ro.Rules {
-		if podSecurityPolicyMatches(r) {
-			switch len(r.ResourceNames) {
-			case 0:
-				os = ObjectSelector{Group: policyv1beta1.GroupName, Kind: "PodSecurityPolicy"}
-				result.AddDependencyBySelector(os, RelationshipRolePolicyRule)
-			default:
-				for _, n := range r.ResourceNames {
-					ref = ObjectReference{Group: policyv1beta1.GroupName, Kind: "PodSecurityPolicy", Name: n}
-					result.AddDependencyByKey(ref.Key(), RelationshipRolePolicyRule)
-				}
-			}
-		}
-	}
+	// RelationshipRolePolicyRuleWildcard
+	addPolicyRuleRelationships(&result, ro.Rules, ro.Namespace, RelationshipRolePolicyRule, RelationshipRolePolicyRuleWildcard)
 
 	return &result, nil
 }
@@ -1120,6 +1424,68 @@ func getServiceAccountRelationships(n *Node) (*RelationshipMap, error) {
 	return &result, nil
 }
 
+// getStatefulSetRelationships returns a map of relationships that this
+// StatefulSet has with other objects, based on what was referenced in its
+// manifest.
+func getStatefulSetRelationships(n *Node) (*RelationshipMap, error) {
+	var sts appsv1.StatefulSet
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &sts)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	ns := sts.Namespace
+	result := newRelationshipMap()
+
+	// RelationshipWorkloadTemplate*
+	addPodSpecRelationships(&result, &sts.Spec.Template.Spec, ns, workloadTemplateRelationships)
+
+	// RelationshipStatefulSetService
+	if len(sts.Spec.ServiceName) > 0 {
+		ref = ObjectReference{Kind: "Service", Name: sts.Spec.ServiceName, Namespace: ns}
+		result.AddDependencyByKey(ref.Key(), RelationshipStatefulSetService)
+	}
+
+	// RelationshipStatefulSetVolumeClaimTemplate (pod ordinals start from
+	// "spec.ordinals.start", which isn't available in the typed API yet)
+	replicas := int64(1)
+	if sts.Spec.Replicas != nil {
+		replicas = int64(*sts.Spec.Replicas)
+	}
+	start, _, _ := unstructuredv1.NestedInt64(n.UnstructuredContent(), "spec", "ordinals", "start")
+	claims := map[string]sets.String{}
+	for _, tpl := range sts.Spec.VolumeClaimTemplates {
+		prefix := fmt.Sprintf("%s-%s-", tpl.Name, sts.Name)
+		claims[tpl.Name] = sets.NewString()
+		for i := start; i < start+replicas; i++ {
+			name := fmt.Sprintf("%s%d", prefix, i)
+			claims[tpl.Name].Insert(name)
+			ref = ObjectReference{Kind: "PersistentVolumeClaim", Name: name, Namespace: ns}
+			result.AddDependentByKey(ref.Key(), RelationshipStatefulSetVolumeClaimTemplate)
+		}
+	}
+
+	// RelationshipStatefulSetRetainedVolumeClaim (PVCs of pods outside of the
+	// current ordinal range are retained unless the retention policy deletes
+	// them)
+	if p := sts.Spec.PersistentVolumeClaimRetentionPolicy; p == nil || p.WhenScaled != appsv1.DeletePersistentVolumeClaimRetentionPolicyType {
+		for _, tpl := range sts.Spec.VolumeClaimTemplates {
+			prefix := fmt.Sprintf("%s-%s-", tpl.Name, sts.Name)
+			ols := ObjectLabelSelector{
+				Kind:         "PersistentVolumeClaim",
+				Namespace:    ns,
+				Selector:     labels.Everything(),
+				NamePattern:  regexp.MustCompile("^" + regexp.QuoteMeta(prefix) + "[0-9]+$"),
+				ExcludeNames: claims[tpl.Name],
+			}
+			result.AddDependentByLabelSelector(ols, RelationshipStatefulSetRetainedVolumeClaim)
+		}
+	}
+
+	return &result, nil
+}
+
 // getStorageClassRelationships returns a map of relationships that this
 // StorageClass has with other objects, based on what was referenced in its
 // manifest.
@@ -1142,6 +1508,70 @@ func getStorageClassRelationships(n *Node) (*RelationshipMap, error) {
 	return &result, nil
 }
 
+// getValidatingAdmissionPolicyRelationships returns a map of relationships
+// that this ValidatingAdmissionPolicy has with other objects, based on what
+// was referenced in its manifest.
+func getValidatingAdmissionPolicyRelationships(n *Node) (*RelationshipMap, error) {
+	result := newRelationshipMap()
+
+	// RelationshipValidatingAdmissionPolicyMatch
+	oas, found, err := getAdmissionPolicyMatchResources(n, "spec", "matchConstraints")
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		result.AddDependentByAdmissionSelector(oas, RelationshipValidatingAdmissionPolicyMatch)
+	}
+
+	return &result, nil
+}
+
+// getValidatingAdmissionPolicyBindingRelationships returns a map of
+// relationships that this ValidatingAdmissionPolicyBinding has with other
+// objects, based on what was referenced in its manifest.
+func getValidatingAdmissionPolicyBindingRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipValidatingAdmissionPolicyBindingPolicy
+	policyName := n.GetNestedString("spec", "policyName")
+	if len(policyName) > 0 {
+		ref = ObjectReference{Group: admissionregistrationv1.GroupName, Kind: "ValidatingAdmissionPolicy", Name: policyName}
+		result.AddDependencyByKey(ref.Key(), RelationshipValidatingAdmissionPolicyBindingPolicy)
+	}
+
+	// RelationshipValidatingAdmissionPolicyBindingParam
+	if len(policyName) > 0 {
+		opr := ObjectParamReference{
+			PolicyName: policyName,
+			Namespace:  n.GetNestedString("spec", "paramRef", "namespace"),
+			Name:       n.GetNestedString("spec", "paramRef", "name"),
+		}
+		selector, found, err := getNestedLabelSelector(n, "spec", "paramRef", "selector")
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			opr.Selector = selector
+		}
+		if len(opr.Name) > 0 || found {
+			result.AddDependencyByParamRef(opr, RelationshipValidatingAdmissionPolicyBindingParam)
+		}
+	}
+
+	// RelationshipValidatingAdmissionPolicyBindingMatch
+	if len(policyName) > 0 {
+		oas, _, err := getAdmissionPolicyMatchResources(n, "spec", "matchResources")
+		if err != nil {
+			return nil, err
+		}
+		oas.PolicyName = policyName
+		result.AddDependentByAdmissionSelector(oas, RelationshipValidatingAdmissionPolicyBindingMatch)
+	}
+
+	return &result, nil
+}
+
 // getValidatingWebhookConfigurationRelationships returns a map of relationships
 // that this ValidatingWebhookConfiguration has with other objects, based on
 // what was referenced in its manifest.
@@ -1163,6 +1593,15 @@ func getValidatingWebhookConfigurationRelationships(n *Node) (*RelationshipMap,
 		}
 	}
 
+	// RelationshipWebhookConfigurationRule
+	for _, wh := range vwc.Webhooks {
+		oas, err := newWebhookAdmissionSelector(wh.Rules, wh.NamespaceSelector, wh.ObjectSelector)
+		if err != nil {
+			return nil, err
+		}
+		result.AddDependentByAdmissionSelector(oas, withEdgeMetadata(RelationshipWebhookConfigurationRule, wh.Name))
+	}
+
 	return &result, nil
 }
 
@@ -1242,6 +1681,168 @@ func getVolumeAttachmentRelationships(n *Node) (*RelationshipMap, error) {
 	return &result, nil
 }
 
+// getAdmissionPolicyMatchResources returns the selector of objects matched by
+// the admission policy MatchResources nested in the provided fields of the
+// node.
+func getAdmissionPolicyMatchResources(n *Node, fields ...string) (ObjectAdmissionSelector, bool, error) {
+	mr, found, err := unstructuredv1.NestedMap(n.UnstructuredContent(), fields...)
+	if err != nil {
+		return ObjectAdmissionSelector{}, false, err
+	}
+	getRules := func(field string) []ObjectAdmissionRule {
+		var result []ObjectAdmissionRule
+		rules, _, _ := unstructuredv1.NestedSlice(mr, field)
+		for _, r := range rules {
+			rule, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			apiGroups, _, _ := unstructuredv1.NestedStringSlice(rule, "apiGroups")
+			resources, _, _ := unstructuredv1.NestedStringSlice(rule, "resources")
+			resourceNames, _, _ := unstructuredv1.NestedStringSlice(rule, "resourceNames")
+			scope, _, _ := unstructuredv1.NestedString(rule, "scope")
+			result = append(result, ObjectAdmissionRule{
+				APIGroups:     sets.NewString(apiGroups...),
+				Resources:     sets.NewString(resources...),
+				ResourceNames: sets.NewString(resourceNames...),
+				Scope:         scope,
+			})
+		}
+		return result
+	}
+
+	oas := ObjectAdmissionSelector{
+		Rules:        getRules("resourceRules"),
+		ExcludeRules: getRules("excludeResourceRules"),
+	}
+	// Match every resource if there are no resource rules (ie. bindings without
+	// resource rules match every resource matched by their policy)
+	if len(oas.Rules) == 0 {
+		oas.Rules = []ObjectAdmissionRule{{APIGroups: sets.NewString("*"), Resources: sets.NewString("*")}}
+	}
+	oas.NamespaceSelector, _, err = getNestedLabelSelector(n, append(fields, "namespaceSelector")...)
+	if err != nil {
+		return ObjectAdmissionSelector{}, false, err
+	}
+	oas.Selector, _, err = getNestedLabelSelector(n, append(fields, "objectSelector")...)
+	if err != nil {
+		return ObjectAdmissionSelector{}, false, err
+	}
+	return oas, found, nil
+}
+
+// getValidatingAdmissionPolicyParamKind returns the GroupKind of the parameter
+// objects of this ValidatingAdmissionPolicy.
+func getValidatingAdmissionPolicyParamKind(n *Node) (schema.GroupKind, bool) {
+	kind := n.GetNestedString("spec", "paramKind", "kind")
+	if len(kind) == 0 {
+		return schema.GroupKind{}, false
+	}
+	gv, err := schema.ParseGroupVersion(n.GetNestedString("spec", "paramKind", "apiVersion"))
+	if err != nil {
+		return schema.GroupKind{}, false
+	}
+	return schema.GroupKind{Group: gv.Group, Kind: kind}, true
+}
+
+// newWebhookAdmissionSelector returns the selector of objects intercepted by
+// an admission webhook with the provided rules, namespace selector & object
+// selector.
+func newWebhookAdmissionSelector(rules []admissionregistrationv1.RuleWithOperations, nsSelector, objSelector *metav1.LabelSelector) (ObjectAdmissionSelector, error) {
+	var oas ObjectAdmissionSelector
+	for _, r := range rules {
+		scope := ""
+		if r.Scope != nil {
+			scope = string(*r.Scope)
+		}
+		oas.Rules = append(oas.Rules, ObjectAdmissionRule{
+			APIGroups: sets.NewString(r.APIGroups...),
+			Resources: sets.NewString(r.Resources...),
+			Scope:     scope,
+		})
+	}
+
+	// Webhooks without selectors intercept every object
+	var err error
+	if nsSelector != nil {
+		oas.NamespaceSelector, err = metav1.LabelSelectorAsSelector(nsSelector)
+		if err != nil {
+			return ObjectAdmissionSelector{}, err
+		}
+	}
+	if objSelector != nil {
+		oas.Selector, err = metav1.LabelSelectorAsSelector(objSelector)
+		if err != nil {
+			return ObjectAdmissionSelector{}, err
+		}
+	}
+	return oas, nil
+}
+
+// addPolicyRuleRelationships adds relationships to the objects covered by the
+// provided RBAC policy rules, with the verbs of each rule appended to the
+// relationship (eg. "RolePolicyRule(get,list)"). Only rules restricted to
+// resource names are resolved to the objects that they cover, while rules
+// granting access to every object of a resource are summarised by a single
+// relationship to the APIServices of the API group instead, with the resources
+// prepended to the verbs (eg. "RolePolicyRuleWildcard(secrets:get,list)").
+// Rules granting the "use" verb on PodSecurityPolicies are always resolved to
+// the PodSecurityPolicies that they cover.
+func addPolicyRuleRelationships(result *RelationshipMap, rules []rbacv1.PolicyRule, ns string, r, wr Relationship) {
+	for _, rule := range rules {
+		if len(rule.APIGroups) == 0 || len(rule.Verbs) == 0 {
+			continue
+		}
+		verbs := strings.Join(sets.NewString(rule.Verbs...).List(), ",")
+
+		// PodSecurityPolicies granted to pods
+		if podSecurityPolicyMatches(rule) {
+			switch len(rule.ResourceNames) {
+			case 0:
+				os := ObjectSelector{Group: policyv1beta1.GroupName, Kind: "PodSecurityPolicy"}
+				result.AddDependencyBySelector(os, withEdgeMetadata(r, verbs))
+			default:
+				for _, name := range rule.ResourceNames {
+					ref := ObjectReference{Group: policyv1beta1.GroupName, Kind: "PodSecurityPolicy", Name: name}
+					result.AddDependencyByKey(ref.Key(), withEdgeMetadata(r, verbs))
+				}
+			}
+		}
+
+		// Subresources (eg. "pods/log") are resolved to their parent objects
+		resources := sets.NewString()
+		for _, res := range rule.Resources {
+			resources.Insert(strings.SplitN(res, "/", 2)[0])
+		}
+		if len(resources) == 0 {
+			continue
+		}
+		if len(rule.ResourceNames) > 0 {
+			ops := ObjectPolicyRuleSelector{
+				APIGroups:     sets.NewString(rule.APIGroups...),
+				Resources:     resources,
+				ResourceNames: sets.NewString(rule.ResourceNames...),
+				Namespace:     ns,
+			}
+			result.AddDependencyByPolicyRule(ops, withEdgeMetadata(r, verbs))
+			continue
+		}
+		for _, g := range rule.APIGroups {
+			ols := ObjectLabelSelector{Group: apiregistrationv1.GroupName, Kind: "APIService", Selector: labels.Everything()}
+			switch g {
+			case rbacv1.APIGroupAll:
+			case corev1.GroupName:
+				// APIServices of the core group are named "<version>"
+				ols.NamePattern = regexp.MustCompile(`^[^.]+$`)
+			default:
+				// APIServices are named "<version>.<group>"
+				ols.NamePattern = regexp.MustCompile(fmt.Sprintf(`^[^.]+\.%s$`, regexp.QuoteMeta(g)))
+			}
+			result.AddDependencyByLabelSelector(ols, withEdgeMetadata(wr, strings.Join(resources.List(), ",")+":"+verbs))
+		}
+	}
+}
+
 // podSecurityPolicyMatches returns true if PolicyRule matches "policy" APIGroup,
 // "podsecuritypolicies" resource & "use" verb.
 func podSecurityPolicyMatches(r rbacv1.PolicyRule) bool {
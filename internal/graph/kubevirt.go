@@ -0,0 +1,332 @@
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubevirt "kubevirt.io/api/core"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// KubeVirt groups & well-known values.
+const (
+	KubeVirtCDIGroupName          = "cdi.kubevirt.io"
+	KubeVirtInstancetypeGroupName = "instancetype.kubevirt.io"
+	KubeVirtSnapshotGroupName     = "snapshot.kubevirt.io"
+
+	// kubeVirtCreatedByLabel is the label set on virt-launcher Pods, containing
+	// the UID of the VirtualMachineInstance which the Pod was created for.
+	kubeVirtCreatedByLabel = "kubevirt.io/created-by"
+)
+
+const (
+	// KubeVirt VirtualMachine & VirtualMachineInstance relationships.
+	RelationshipVMCloudInitSecret             Relationship = "VMCloudInitSecret"
+	RelationshipVMConfigMap                   Relationship = "VMConfigMap"
+	RelationshipVMDataVolume                  Relationship = "VMDataVolume"
+	RelationshipVMDataVolumeTemplate          Relationship = "VMDataVolumeTemplate"
+	RelationshipVMInstancetype                Relationship = "VMInstancetype"
+	RelationshipVMNetworkAttachmentDefinition Relationship = "VMNetworkAttachmentDefinition"
+	RelationshipVMPersistentVolumeClaim       Relationship = "VMPersistentVolumeClaim"
+	RelationshipVMPreference                  Relationship = "VMPreference"
+	RelationshipVMSecret                      Relationship = "VMSecret"
+	RelationshipVMServiceAccount              Relationship = "VMServiceAccount"
+	RelationshipVMSysprep                     Relationship = "VMSysprep"
+	RelationshipVMINode                       Relationship = "VMINode"
+	RelationshipVMIPod                        Relationship = "VMIPod"
+
+	// KubeVirt VirtualMachineInstanceMigration relationships.
+	RelationshipVMIMigrationVMI Relationship = "VMIMigrationVMI"
+
+	// KubeVirt VirtualMachineRestore relationships.
+	RelationshipVMRestoreSnapshot Relationship = "VMRestoreSnapshot"
+	RelationshipVMRestoreTarget   Relationship = "VMRestoreTarget"
+
+	// KubeVirt VirtualMachineSnapshot & VirtualMachineSnapshotContent relationships.
+	RelationshipVMSnapshotContent               Relationship = "VMSnapshotContent"
+	RelationshipVMSnapshotContentSnapshot       Relationship = "VMSnapshotContentSnapshot"
+	RelationshipVMSnapshotContentVolumeSnapshot Relationship = "VMSnapshotContentVolumeSnapshot"
+	RelationshipVMSnapshotSource                Relationship = "VMSnapshotSource"
+)
+
+// getVMRelationships returns a map of relationships that
+// this VM has with other objects, based on what was
+// referenced in its manifest.
+func getVMRelationships(n *Node) (*RelationshipMap, error) {
+	var vm kubevirtv1.VirtualMachine
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &vm)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipVMCloudInitSecret
+	// RelationshipVMConfigMap
+	// RelationshipVMDataVolume
+	// RelationshipVMNetworkAttachmentDefinition
+	// RelationshipVMPersistentVolumeClaim
+	// RelationshipVMSecret
+	// RelationshipVMServiceAccount
+	// RelationshipVMSysprep
+	if tpl := vm.Spec.Template; tpl != nil {
+		addVMISpecRelationships(&result, &tpl.Spec, vm.Namespace)
+	}
+
+	// RelationshipVMDataVolumeTemplate
+	for _, dvt := range vm.Spec.DataVolumeTemplates {
+		if len(dvt.Name) > 0 {
+			ref = ObjectReference{Group: KubeVirtCDIGroupName, Kind: "DataVolume", Name: dvt.Name, Namespace: vm.Namespace}
+			result.AddDependentByKey(ref.Key(), RelationshipVMDataVolumeTemplate)
+		}
+	}
+
+	// RelationshipVMInstancetype (defaults to a cluster-scoped instancetype)
+	if name := n.GetNestedString("spec", "instancetype", "name"); len(name) > 0 {
+		kind := n.GetNestedString("spec", "instancetype", "kind")
+		if len(kind) == 0 {
+			kind = "VirtualMachineClusterInstancetype"
+		}
+		ref = ObjectReference{Group: KubeVirtInstancetypeGroupName, Kind: kind, Name: name}
+		if kind == "VirtualMachineInstancetype" {
+			ref.Namespace = vm.Namespace
+		}
+		result.AddDependencyByKey(ref.Key(), RelationshipVMInstancetype)
+	}
+
+	// RelationshipVMPreference (defaults to a cluster-scoped preference)
+	if name := n.GetNestedString("spec", "preference", "name"); len(name) > 0 {
+		kind := n.GetNestedString("spec", "preference", "kind")
+		if len(kind) == 0 {
+			kind = "VirtualMachineClusterPreference"
+		}
+		ref = ObjectReference{Group: KubeVirtInstancetypeGroupName, Kind: kind, Name: name}
+		if kind == "VirtualMachinePreference" {
+			ref.Namespace = vm.Namespace
+		}
+		result.AddDependencyByKey(ref.Key(), RelationshipVMPreference)
+	}
+
+	// RelationshipHarvesterVMKeyPair
+	addHarvesterVMRelationships(&result, n)
+
+	return &result, nil
+}
+
+// getVMIRelationships returns a map of relationships that
+// this VMI has with other objects, based on what was
+// referenced in its manifest.
+func getVMIRelationships(n *Node) (*RelationshipMap, error) {
+	var vmi kubevirtv1.VirtualMachineInstance
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &vmi)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipVMCloudInitSecret
+	// RelationshipVMConfigMap
+	// RelationshipVMDataVolume
+	// RelationshipVMNetworkAttachmentDefinition
+	// RelationshipVMPersistentVolumeClaim
+	// RelationshipVMSecret
+	// RelationshipVMServiceAccount
+	// RelationshipVMSysprep
+	addVMISpecRelationships(&result, &vmi.Spec, vmi.Namespace)
+
+	// RelationshipVMINode (including the source & target nodes of migrations)
+	nodes := map[string]struct{}{vmi.Status.NodeName: {}}
+	for _, node := range vmi.Status.ActivePods {
+		nodes[node] = struct{}{}
+	}
+	for node := range nodes {
+		if len(node) > 0 {
+			ref = ObjectReference{Kind: "Node", Name: node}
+			result.AddDependencyByKey(ref.Key(), RelationshipVMINode)
+		}
+	}
+
+	// RelationshipVMIPod (virt-launcher pods)
+	ols := ObjectLabelSelector{
+		Kind:      "Pod",
+		Namespace: vmi.Namespace,
+		Selector:  labels.SelectorFromSet(labels.Set{kubeVirtCreatedByLabel: string(vmi.UID)}),
+	}
+	result.AddDependentByLabelSelector(ols, RelationshipVMIPod)
+
+	return &result, nil
+}
+
+// getVMIMigrationRelationships returns a map of relationships that this
+// VirtualMachineInstanceMigration has with other objects, based on what was
+// referenced in its manifest.
+func getVMIMigrationRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipVMIMigrationVMI
+	if name := n.GetNestedString("spec", "vmiName"); len(name) > 0 {
+		ref = ObjectReference{Group: kubevirt.GroupName, Kind: "VirtualMachineInstance", Name: name, Namespace: n.Namespace}
+		result.AddDependencyByKey(ref.Key(), RelationshipVMIMigrationVMI)
+	}
+
+	return &result, nil
+}
+
+// getVMRestoreRelationships returns a map of relationships that this
+// VirtualMachineRestore has with other objects, based on what was referenced
+// in its manifest.
+func getVMRestoreRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipVMRestoreSnapshot
+	if name := n.GetNestedString("spec", "virtualMachineSnapshotName"); len(name) > 0 {
+		ref = ObjectReference{Group: KubeVirtSnapshotGroupName, Kind: "VirtualMachineSnapshot", Name: name, Namespace: n.Namespace}
+		result.AddDependencyByKey(ref.Key(), RelationshipVMRestoreSnapshot)
+	}
+
+	// RelationshipVMRestoreTarget
+	if ref, ok := getVMTypedLocalObjectReference(n, "spec", "target"); ok {
+		result.AddDependencyByKey(ref.Key(), RelationshipVMRestoreTarget)
+	}
+
+	return &result, nil
+}
+
+// getVMSnapshotRelationships returns a map of relationships that this
+// VirtualMachineSnapshot has with other objects, based on what was referenced
+// in its manifest.
+func getVMSnapshotRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipVMSnapshotContent
+	if name := n.GetNestedString("status", "virtualMachineSnapshotContentName"); len(name) > 0 {
+		ref = ObjectReference{Group: KubeVirtSnapshotGroupName, Kind: "VirtualMachineSnapshotContent", Name: name, Namespace: n.Namespace}
+		result.AddDependencyByKey(ref.Key(), RelationshipVMSnapshotContent)
+	}
+
+	// RelationshipVMSnapshotSource
+	if ref, ok := getVMTypedLocalObjectReference(n, "spec", "source"); ok {
+		result.AddDependencyByKey(ref.Key(), RelationshipVMSnapshotSource)
+	}
+
+	return &result, nil
+}
+
+// getVMSnapshotContentRelationships returns a map of relationships that this
+// VirtualMachineSnapshotContent has with other objects, based on what was
+// referenced in its manifest.
+func getVMSnapshotContentRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipVMSnapshotContentSnapshot
+	if name := n.GetNestedString("spec", "virtualMachineSnapshotName"); len(name) > 0 {
+		ref = ObjectReference{Group: KubeVirtSnapshotGroupName, Kind: "VirtualMachineSnapshot", Name: name, Namespace: n.Namespace}
+		result.AddDependencyByKey(ref.Key(), RelationshipVMSnapshotContentSnapshot)
+	}
+
+	// RelationshipVMSnapshotContentVolumeSnapshot
+	var content struct {
+		Spec struct {
+			VolumeBackups []struct {
+				VolumeSnapshotName *string `json:"volumeSnapshotName"`
+			} `json:"volumeBackups"`
+		} `json:"spec"`
+	}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(n.UnstructuredContent(), &content)
+	if err != nil {
+		return nil, err
+	}
+	for _, vb := range content.Spec.VolumeBackups {
+		if vb.VolumeSnapshotName != nil && len(*vb.VolumeSnapshotName) > 0 {
+			ref = ObjectReference{Group: SnapshotGroupName, Kind: "VolumeSnapshot", Name: *vb.VolumeSnapshotName, Namespace: n.Namespace}
+			result.AddDependentByKey(ref.Key(), RelationshipVMSnapshotContentVolumeSnapshot)
+		}
+	}
+
+	return &result, nil
+}
+
+// addVMISpecRelationships adds relationships found in the provided VMI spec
+// (either of a VMI or a VM's VMI template) to the provided RelationshipMap.
+func addVMISpecRelationships(result *RelationshipMap, spec *kubevirtv1.VirtualMachineInstanceSpec, ns string) {
+	var ref ObjectReference
+	addSecretRef := func(name string, r Relationship) {
+		if len(name) > 0 {
+			ref = ObjectReference{Kind: "Secret", Name: name, Namespace: ns}
+			result.AddDependentByKey(ref.Key(), r)
+		}
+	}
+
+	// Volumes
+	for _, volume := range spec.Volumes {
+		vs := volume.VolumeSource
+		switch {
+		case vs.CloudInitConfigDrive != nil:
+			if s := vs.CloudInitConfigDrive.UserDataSecretRef; s != nil {
+				addSecretRef(s.Name, RelationshipVMCloudInitSecret)
+			}
+			if s := vs.CloudInitConfigDrive.NetworkDataSecretRef; s != nil {
+				addSecretRef(s.Name, RelationshipVMCloudInitSecret)
+			}
+		case vs.CloudInitNoCloud != nil:
+			if s := vs.CloudInitNoCloud.UserDataSecretRef; s != nil {
+				addSecretRef(s.Name, RelationshipVMCloudInitSecret)
+			}
+			if s := vs.CloudInitNoCloud.NetworkDataSecretRef; s != nil {
+				addSecretRef(s.Name, RelationshipVMCloudInitSecret)
+			}
+		case vs.ConfigMap != nil:
+			ref = ObjectReference{Kind: "ConfigMap", Name: vs.ConfigMap.Name, Namespace: ns}
+			result.AddDependentByKey(ref.Key(), RelationshipVMConfigMap)
+		case vs.DataVolume != nil:
+			ref = ObjectReference{Group: KubeVirtCDIGroupName, Kind: "DataVolume", Name: vs.DataVolume.Name, Namespace: ns}
+			result.AddDependentByKey(ref.Key(), RelationshipVMDataVolume)
+		case vs.PersistentVolumeClaim != nil:
+			ref = ObjectReference{Kind: "PersistentVolumeClaim", Name: vs.PersistentVolumeClaim.ClaimName, Namespace: ns}
+			result.AddDependentByKey(ref.Key(), RelationshipVMPersistentVolumeClaim)
+		case vs.Secret != nil:
+			addSecretRef(vs.Secret.SecretName, RelationshipVMSecret)
+		case vs.ServiceAccount != nil:
+			ref = ObjectReference{Kind: "ServiceAccount", Name: vs.ServiceAccount.ServiceAccountName, Namespace: ns}
+			result.AddDependentByKey(ref.Key(), RelationshipVMServiceAccount)
+		case vs.Sysprep != nil:
+			if cm := vs.Sysprep.ConfigMap; cm != nil {
+				ref = ObjectReference{Kind: "ConfigMap", Name: cm.Name, Namespace: ns}
+				result.AddDependentByKey(ref.Key(), RelationshipVMSysprep)
+			}
+			if s := vs.Sysprep.Secret; s != nil {
+				addSecretRef(s.Name, RelationshipVMSysprep)
+			}
+		}
+	}
+
+	// RelationshipVMNetworkAttachmentDefinition
+	for _, network := range spec.Networks {
+		if network.Multus != nil {
+			ref = ObjectReference{Group: MultusGroupName, Kind: "NetworkAttachmentDefinition", Name: network.Multus.NetworkName, Namespace: ns}
+			result.AddDependentByKey(ref.Key(), RelationshipVMNetworkAttachmentDefinition)
+		}
+	}
+}
+
+// getVMTypedLocalObjectReference returns the object reference in the KubeVirt
+// TypedLocalObjectReference nested in the provided fields of the node,
+// defaulting to a reference to a VirtualMachine.
+func getVMTypedLocalObjectReference(n *Node, fields ...string) (ObjectReference, bool) {
+	name := n.GetNestedString(append(fields, "name")...)
+	if len(name) == 0 {
+		return ObjectReference{}, false
+	}
+	group := n.GetNestedString(append(fields, "apiGroup")...)
+	kind := n.GetNestedString(append(fields, "kind")...)
+	if len(kind) == 0 {
+		group, kind = kubevirt.GroupName, "VirtualMachine"
+	}
+	return ObjectReference{Group: group, Kind: kind, Name: name, Namespace: n.Namespace}, true
+}
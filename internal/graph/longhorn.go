@@ -0,0 +1,372 @@
+package graph
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Longhorn well-known labels & values.
+const (
+	// DefaultLonghornNamespace is the namespace which Longhorn is installed in
+	// by default.
+	DefaultLonghornNamespace = "longhorn-system"
+	// LonghornDriverName is the name of the Longhorn CSI driver.
+	LonghornDriverName = "driver.longhorn.io"
+
+	// longhornBackupVolumeLabel is the label set on Backups, containing the
+	// name of the BackupVolume (ie. the name of the backed up Volume).
+	longhornBackupVolumeLabel = "backup-volume"
+	// longhornRecurringJobLabelPrefix & longhornRecurringJobGroupLabelPrefix
+	// are the prefixes of the labels set on Volumes for every RecurringJob
+	// (or group of RecurringJobs) applied to the Volume.
+	longhornRecurringJobLabelPrefix      = "recurring-job.longhorn.io/"
+	longhornRecurringJobGroupLabelPrefix = "recurring-job-group.longhorn.io/"
+	longhornLabelValueEnabled            = "enabled"
+)
+
+const (
+	// Longhorn BackingImage relationships.
+	RelationshipLonghornBackingImageVolume Relationship = "LonghornBackingImageVolume"
+
+	// Longhorn Backup relationships.
+	RelationshipLonghornBackupBackupVolume Relationship = "LonghornBackupBackupVolume"
+	RelationshipLonghornBackupVolume       Relationship = "LonghornBackupVolume"
+
+	// Longhorn BackupTarget relationships.
+	RelationshipLonghornBackupTargetSecret Relationship = "LonghornBackupTargetSecret" //nolint:gosec
+
+	// Longhorn BackupVolume relationships.
+	RelationshipLonghornBackupVolumeBackingImage Relationship = "LonghornBackupVolumeBackingImage"
+	RelationshipLonghornBackupVolumeVolume       Relationship = "LonghornBackupVolumeVolume"
+
+	// Longhorn Engine relationships.
+	RelationshipLonghornEngineInstanceManager Relationship = "LonghornEngineInstanceManager"
+	RelationshipLonghornEngineNode            Relationship = "LonghornEngineNode"
+	RelationshipLonghornEngineVolume          Relationship = "LonghornEngineVolume"
+
+	// Longhorn InstanceManager relationships.
+	RelationshipLonghornInstanceManagerNode Relationship = "LonghornInstanceManagerNode"
+
+	// Longhorn Node relationships.
+	RelationshipLonghornNodeDisk Relationship = "LonghornNodeDisk"
+	RelationshipLonghornNodeNode Relationship = "LonghornNodeNode"
+
+	// Longhorn RecurringJob relationships.
+	RelationshipLonghornRecurringJobGroup Relationship = "LonghornRecurringJobGroup"
+
+	// Longhorn Replica relationships.
+	RelationshipLonghornReplicaBackingImage    Relationship = "LonghornReplicaBackingImage"
+	RelationshipLonghornReplicaInstanceManager Relationship = "LonghornReplicaInstanceManager"
+	RelationshipLonghornReplicaNode            Relationship = "LonghornReplicaNode"
+	RelationshipLonghornReplicaVolume          Relationship = "LonghornReplicaVolume"
+
+	// Longhorn ShareManager relationships.
+	RelationshipLonghornShareManagerVolume Relationship = "LonghornShareManagerVolume"
+
+	// Longhorn Volume relationships.
+	RelationshipLonghornVolumeBackingImage          Relationship = "LonghornVolumeBackingImage"
+	RelationshipLonghornVolumeDataSource            Relationship = "LonghornVolumeDataSource"
+	RelationshipLonghornVolumeFromBackup            Relationship = "LonghornVolumeFromBackup"
+	RelationshipLonghornVolumePersistentVolume      Relationship = "LonghornVolumePersistentVolume"
+	RelationshipLonghornVolumePersistentVolumeClaim Relationship = "LonghornVolumePersistentVolumeClaim"
+	RelationshipLonghornVolumeRecurringJob          Relationship = "LonghornVolumeRecurringJob"
+)
+
+// getLonghornBackingImageRelationships returns a map of relationships that
+// this Longhorn BackingImage has with other objects, based on what was
+// referenced in its manifest.
+func getLonghornBackingImageRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipLonghornBackingImageVolume (backing images exported from a
+	// volume)
+	if name := n.GetNestedString("spec", "sourceParameters", "volume-name"); len(name) > 0 {
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "Volume", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornBackingImageVolume)
+	}
+
+	return &result, nil
+}
+
+// getLonghornBackupRelationships returns a map of relationships that this
+// Longhorn Backup has with other objects, based on what was referenced in its
+// manifest.
+func getLonghornBackupRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipLonghornBackupBackupVolume
+	if name := n.GetLabels()[longhornBackupVolumeLabel]; len(name) > 0 {
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "BackupVolume", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornBackupBackupVolume)
+	}
+
+	// RelationshipLonghornBackupVolume
+	if name := n.GetNestedString("status", "volumeName"); len(name) > 0 {
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "Volume", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornBackupVolume)
+	}
+
+	return &result, nil
+}
+
+// getLonghornBackupTargetRelationships returns a map of relationships that
+// this Longhorn BackupTarget has with other objects, based on what was
+// referenced in its manifest.
+func getLonghornBackupTargetRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipLonghornBackupTargetSecret
+	if name := n.GetNestedString("spec", "credentialSecret"); len(name) > 0 {
+		ref = ObjectReference{Kind: "Secret", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornBackupTargetSecret)
+	}
+
+	return &result, nil
+}
+
+// getLonghornBackupVolumeRelationships returns a map of relationships that
+// this Longhorn BackupVolume has with other objects, based on what was
+// referenced in its manifest.
+func getLonghornBackupVolumeRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipLonghornBackupVolumeBackingImage
+	if name := n.GetNestedString("status", "backingImageName"); len(name) > 0 {
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "BackingImage", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornBackupVolumeBackingImage)
+	}
+
+	// RelationshipLonghornBackupVolumeVolume (backup volumes are named after
+	// the backed up volume)
+	ref = ObjectReference{Group: longhorn.GroupName, Kind: "Volume", Namespace: n.Namespace, Name: n.Name}
+	result.AddDependencyByKey(ref.Key(), RelationshipLonghornBackupVolumeVolume)
+
+	return &result, nil
+}
+
+// getLonghornEngineRelationships returns a map of relationships that this
+// Longhorn Engine has with other objects, based on what was referenced in its
+// manifest.
+func getLonghornEngineRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipLonghornEngineInstanceManager
+	if name := n.GetNestedString("status", "instanceManagerName"); len(name) > 0 {
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "InstanceManager", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornEngineInstanceManager)
+	}
+
+	// RelationshipLonghornEngineNode
+	if name := n.GetNestedString("spec", "nodeID"); len(name) > 0 {
+		ref = ObjectReference{Kind: "Node", Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornEngineNode)
+	}
+
+	// RelationshipLonghornEngineVolume
+	if name := n.GetNestedString("spec", "volumeName"); len(name) > 0 {
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "Volume", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornEngineVolume)
+	}
+
+	return &result, nil
+}
+
+// getLonghornInstanceManagerRelationships returns a map of relationships that
+// this Longhorn InstanceManager has with other objects, based on what was
+// referenced in its manifest.
+func getLonghornInstanceManagerRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipLonghornInstanceManagerNode
+	if name := n.GetNestedString("spec", "nodeID"); len(name) > 0 {
+		ref = ObjectReference{Kind: "Node", Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornInstanceManagerNode)
+	}
+
+	return &result, nil
+}
+
+// getLonghornNodeRelationships returns a map of relationships that this
+// Longhorn Node has with other objects, based on what was referenced in its
+// manifest.
+func getLonghornNodeRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipLonghornNodeDisk (replicas scheduled on the node's disks)
+	diskStatus, _, err := unstructuredv1.NestedMap(n.UnstructuredContent(), "status", "diskStatus")
+	if err != nil {
+		return nil, err
+	}
+	for disk, status := range diskStatus {
+		status, ok := status.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		replicas, _, err := unstructuredv1.NestedMap(status, "scheduledReplica")
+		if err != nil {
+			return nil, err
+		}
+		r := withEdgeMetadata(RelationshipLonghornNodeDisk, disk)
+		for name := range replicas {
+			ref = ObjectReference{Group: longhorn.GroupName, Kind: "Replica", Namespace: n.Namespace, Name: name}
+			result.AddDependencyByKey(ref.Key(), r)
+		}
+	}
+
+	// RelationshipLonghornNodeNode
+	ref = ObjectReference{Kind: "Node", Name: n.Name}
+	result.AddDependencyByKey(ref.Key(), RelationshipLonghornNodeNode)
+
+	return &result, nil
+}
+
+// getLonghornRecurringJobRelationships returns a map of relationships that
+// this Longhorn RecurringJob has with other objects, based on what was
+// referenced in its manifest.
+func getLonghornRecurringJobRelationships(n *Node) (*RelationshipMap, error) {
+	result := newRelationshipMap()
+
+	// RelationshipLonghornRecurringJobGroup
+	groups, _, err := unstructuredv1.NestedStringSlice(n.UnstructuredContent(), "spec", "groups")
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		ols := ObjectLabelSelector{
+			Group:     longhorn.GroupName,
+			Kind:      "Volume",
+			Namespace: n.Namespace,
+			Selector:  labels.SelectorFromSet(labels.Set{longhornRecurringJobGroupLabelPrefix + g: longhornLabelValueEnabled}),
+		}
+		result.AddDependentByLabelSelector(ols, RelationshipLonghornRecurringJobGroup)
+	}
+
+	return &result, nil
+}
+
+// getLonghornReplicaRelationships returns a map of relationships that this
+// Longhorn Replica has with other objects, based on what was referenced in
+// its manifest.
+func getLonghornReplicaRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipLonghornReplicaBackingImage
+	if name := n.GetNestedString("spec", "backingImage"); len(name) > 0 {
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "BackingImage", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornReplicaBackingImage)
+	}
+
+	// RelationshipLonghornReplicaInstanceManager
+	if name := n.GetNestedString("status", "instanceManagerName"); len(name) > 0 {
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "InstanceManager", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornReplicaInstanceManager)
+	}
+
+	// RelationshipLonghornReplicaNode
+	if name := n.GetNestedString("spec", "nodeID"); len(name) > 0 {
+		ref = ObjectReference{Kind: "Node", Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornReplicaNode)
+	}
+
+	// RelationshipLonghornReplicaVolume
+	if name := n.GetNestedString("spec", "volumeName"); len(name) > 0 {
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "Volume", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornReplicaVolume)
+	}
+
+	return &result, nil
+}
+
+// getLonghornShareManagerRelationships returns a map of relationships that
+// this Longhorn ShareManager has with other objects, based on what was
+// referenced in its manifest.
+func getLonghornShareManagerRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipLonghornShareManagerVolume (share managers are named after
+	// the shared volume)
+	ref = ObjectReference{Group: longhorn.GroupName, Kind: "Volume", Namespace: n.Namespace, Name: n.Name}
+	result.AddDependencyByKey(ref.Key(), RelationshipLonghornShareManagerVolume)
+
+	return &result, nil
+}
+
+// getLonghornVolumeRelationships returns a map of relationships that this
+// Longhorn Volume has with other objects, based on what was referenced in its
+// manifest.
+func getLonghornVolumeRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipLonghornVolumeBackingImage
+	if name := n.GetNestedString("spec", "backingImage"); len(name) > 0 {
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "BackingImage", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornVolumeBackingImage)
+	}
+
+	// RelationshipLonghornVolumeDataSource (volumes cloned from a volume or
+	// a volume snapshot, ie. "vol://<volume>" or "snap://<volume>/<snapshot>")
+	if ds := n.GetNestedString("spec", "dataSource"); len(ds) > 0 {
+		var name string
+		switch {
+		case strings.HasPrefix(ds, "vol://"):
+			name = strings.TrimPrefix(ds, "vol://")
+		case strings.HasPrefix(ds, "snap://"):
+			name = strings.SplitN(strings.TrimPrefix(ds, "snap://"), "/", 2)[0]
+		}
+		if len(name) > 0 {
+			ref = ObjectReference{Group: longhorn.GroupName, Kind: "Volume", Namespace: n.Namespace, Name: name}
+			result.AddDependencyByKey(ref.Key(), RelationshipLonghornVolumeDataSource)
+		}
+	}
+
+	// RelationshipLonghornVolumeFromBackup (volumes restored from a backup,
+	// ie. "<backup-target-url>?backup=<backup>&volume=<volume>")
+	if fromBackup := n.GetNestedString("spec", "fromBackup"); len(fromBackup) > 0 {
+		if u, err := url.Parse(fromBackup); err == nil {
+			if name := u.Query().Get("backup"); len(name) > 0 {
+				ref = ObjectReference{Group: longhorn.GroupName, Kind: "Backup", Namespace: n.Namespace, Name: name}
+				result.AddDependencyByKey(ref.Key(), RelationshipLonghornVolumeFromBackup)
+			}
+		}
+	}
+
+	// RelationshipLonghornVolumePersistentVolume
+	if name := n.GetNestedString("status", "kubernetesStatus", "pvName"); len(name) > 0 {
+		ref = ObjectReference{Kind: "PersistentVolume", Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornVolumePersistentVolume)
+	}
+
+	// RelationshipLonghornVolumePersistentVolumeClaim
+	if name := n.GetNestedString("status", "kubernetesStatus", "pvcName"); len(name) > 0 {
+		ns := n.GetNestedString("status", "kubernetesStatus", "namespace")
+		ref = ObjectReference{Kind: "PersistentVolumeClaim", Namespace: ns, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornVolumePersistentVolumeClaim)
+	}
+
+	// RelationshipLonghornVolumeRecurringJob (recurring jobs applied to the
+	// volume directly, recurring job groups are resolved by the RecurringJob)
+	for k, v := range n.GetLabels() {
+		if !strings.HasPrefix(k, longhornRecurringJobLabelPrefix) || v != longhornLabelValueEnabled {
+			continue
+		}
+		name := strings.TrimPrefix(k, longhornRecurringJobLabelPrefix)
+		ref = ObjectReference{Group: longhorn.GroupName, Kind: "RecurringJob", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipLonghornVolumeRecurringJob)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,150 @@
+package graph
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// ClusterObjects contains the objects fetched from a single cluster.
+type ClusterObjects struct {
+	// Cluster is the name of the cluster (ie. its kubeconfig context name).
+	Cluster string
+	// Server is the address of the cluster's API server.
+	Server  string
+	Mapper  meta.RESTMapper
+	Objects []unstructuredv1.Unstructured
+}
+
+// crossClusterFullObjectGroupKinds contains the GroupKinds which are read
+// beyond their ObjectMeta when resolving cross-cluster relationships.
+var crossClusterFullObjectGroupKinds = map[schema.GroupKind]struct{}{
+	{Kind: "Secret"}: {}, // Cluster API kubeconfig Secrets
+}
+
+// RequiresFullObjectForCrossCluster returns true if full objects of the
+// provided GroupKind are needed to resolve cross-cluster relationships.
+func RequiresFullObjectForCrossCluster(gk schema.GroupKind) bool {
+	_, ok := crossClusterFullObjectGroupKinds[gk]
+	return ok
+}
+
+// clusterIndex contains the nodes of multiple clusters, mapped by their
+// cluster name & node keys.
+type clusterIndex struct {
+	clusters        []ClusterObjects
+	mapByClusterKey map[string]map[ObjectReferenceKey]*Node
+}
+
+func (idx *clusterIndex) getNode(cluster string, key ObjectReferenceKey) (*Node, bool) {
+	n, ok := idx.mapByClusterKey[cluster][key]
+	return n, ok
+}
+
+// ResolveClusterDependencies resolves all dependencies of the provided objects
+// across the provided clusters and returns a relationship tree. Relationships
+// between objects in different clusters are only resolved if crossCluster is
+// set to true.
+func ResolveClusterDependencies(clusters []ClusterObjects, uids []types.UID, crossCluster bool, opts ResolveOptions) (NodeMap, error) {
+	return resolveClusterDeps(clusters, uids, crossCluster, true, opts)
+}
+
+// ResolveClusterDependents resolves all dependents of the provided objects
+// across the provided clusters and returns a relationship tree. Relationships
+// between objects in different clusters are only resolved if crossCluster is
+// set to true.
+func ResolveClusterDependents(clusters []ClusterObjects, uids []types.UID, crossCluster bool, opts ResolveOptions) (NodeMap, error) {
+	return resolveClusterDeps(clusters, uids, crossCluster, false, opts)
+}
+
+// resolveClusterDeps resolves all dependencies or dependents of the provided
+// objects across the provided clusters and returns a relationship tree.
+func resolveClusterDeps(clusters []ClusterObjects, uids []types.UID, crossCluster, depsIsDependencies bool, opts ResolveOptions) (NodeMap, error) {
+	if len(uids) == 0 {
+		return NodeMap{}, nil
+	}
+
+	// Resolve relationships within each cluster & merge the nodes of all
+	// clusters into a single global map
+	globalMapByUID := map[types.UID]*Node{}
+	idx := clusterIndex{
+		clusters:        clusters,
+		mapByClusterKey: map[string]map[ObjectReferenceKey]*Node{},
+	}
+	for _, c := range clusters {
+		mapByUID, err := resolveRelationships(c.Mapper, c.Objects, opts)
+		if err != nil {
+			return nil, err
+		}
+		mapByKey := map[ObjectReferenceKey]*Node{}
+		for uid, node := range mapByUID {
+			// Skip aliases of nodes (eg. Node objects mapped by their names)
+			if uid != node.UID {
+				continue
+			}
+			node.Cluster = c.Cluster
+			mapByKey[node.GetObjectReferenceKey()] = node
+			if _, ok := globalMapByUID[uid]; ok {
+				klog.V(4).Infof("Duplicated %s.%s resource \"%s\" in namespace \"%s\" of cluster \"%s\"", node.Kind, node.Group, node.Name, node.Namespace, c.Cluster)
+				continue
+			}
+			globalMapByUID[uid] = node
+		}
+		idx.mapByClusterKey[c.Cluster] = mapByKey
+	}
+
+	// Populate dependencies & dependents based on cross-cluster relationships
+	if crossCluster {
+		for _, node := range globalMapByUID {
+			rmaps, err := getCrossClusterRelationships(node, &idx)
+			if err != nil {
+				klog.V(4).Infof("Failed to get cross-cluster relationships for %s named \"%s\" in cluster \"%s\": %s", node.Kind, node.Name, node.Cluster, err)
+				continue
+			}
+			for cluster, rmap := range rmaps {
+				updateCrossClusterRelationships(node, idx.mapByClusterKey[cluster], rmap)
+			}
+		}
+	}
+
+	return getSubtree(globalMapByUID, uids, depsIsDependencies), nil
+}
+
+// updateCrossClusterRelationships updates the dependencies & dependents of the
+// provided node & the nodes of another cluster, based on the object references
+// in the provided relationship map.
+func updateCrossClusterRelationships(node *Node, mapByKey map[ObjectReferenceKey]*Node, rmap *RelationshipMap) {
+	for k, rset := range rmap.DependenciesByRef {
+		if n, ok := mapByKey[k]; ok {
+			for r := range rset {
+				node.AddDependency(n.UID, r)
+				n.AddDependent(node.UID, r)
+			}
+		}
+	}
+	for k, rset := range rmap.DependentsByRef {
+		if n, ok := mapByKey[k]; ok {
+			for r := range rset {
+				n.AddDependency(node.UID, r)
+				node.AddDependent(n.UID, r)
+			}
+		}
+	}
+}
+
+// getCrossClusterRelationships returns maps of relationships that the provided
+// node has with objects in other clusters, keyed by the name of the other
+// cluster. Only relationships by object references are supported. Returns a
+// nil map if there's no cross-cluster relationship resolver for the node's
+// GroupKind.
+func getCrossClusterRelationships(node *Node, idx *clusterIndex) (map[string]*RelationshipMap, error) {
+	switch {
+	// Populate dependencies & dependents based on Cluster API Machine relationships
+	case node.Group == ClusterAPIGroupName && node.Kind == "Machine":
+		return getClusterAPIMachineCrossClusterRelationships(node, idx)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"encoding/base64"
+	"testing"
+
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+//nolint:funlen
+func TestResolveClusterAPIWorkloadCluster(t *testing.T) {
+	t.Parallel()
+
+	newCluster := func(host string, port int64) unstructuredv1.Unstructured {
+		fields := map[string]interface{}{}
+		if len(host) > 0 {
+			fields["spec"] = map[string]interface{}{
+				"controlPlaneEndpoint": map[string]interface{}{"host": host, "port": port},
+			}
+		}
+		return newTestObject("cluster", "cluster.x-k8s.io/v1beta1", "Cluster", "default", "wl", fields)
+	}
+	newKubeconfigSecret := func(server string) unstructuredv1.Unstructured {
+		kubeconfig := "apiVersion: v1\nkind: Config\nclusters:\n- name: wl\n  cluster:\n    server: " + server + "\n"
+		return newTestObject("kubeconfig", "v1", "Secret", "default", "wl-kubeconfig", map[string]interface{}{
+			"data": map[string]interface{}{"value": base64.StdEncoding.EncodeToString([]byte(kubeconfig))},
+		})
+	}
+	machine := newTestObject("machine", "cluster.x-k8s.io/v1beta1", "Machine", "default", "wl-md-0", map[string]interface{}{
+		"spec":   map[string]interface{}{"clusterName": "wl"},
+		"status": map[string]interface{}{"nodeRef": map[string]interface{}{"name": "node-1"}},
+	})
+	node := newTestObject("node", "v1", "Node", "", "node-1", nil)
+
+	tests := []struct {
+		name     string
+		mgmt     []unstructuredv1.Unstructured
+		workload ClusterObjects
+		expected bool
+	}{
+		{
+			name:     "ControlPlaneEndpoint",
+			mgmt:     []unstructuredv1.Unstructured{newCluster("10.0.0.1", 6443), machine},
+			workload: ClusterObjects{Cluster: "workload", Server: "https://10.0.0.1:6443"},
+			expected: true,
+		},
+		{
+			name:     "ControlPlaneEndpointPortMismatch",
+			mgmt:     []unstructuredv1.Unstructured{newCluster("10.0.0.1", 6443), machine},
+			workload: ClusterObjects{Cluster: "workload", Server: "https://10.0.0.1:8443"},
+			expected: false,
+		},
+		{
+			name:     "KubeconfigSecret",
+			mgmt:     []unstructuredv1.Unstructured{newCluster("", 0), newKubeconfigSecret("https://wl.example.com"), machine},
+			workload: ClusterObjects{Cluster: "workload", Server: "https://wl.example.com:443"},
+			expected: true,
+		},
+		{
+			name:     "KubeconfigSecretServerMismatch",
+			mgmt:     []unstructuredv1.Unstructured{newCluster("", 0), newKubeconfigSecret("https://other.example.com"), machine},
+			workload: ClusterObjects{Cluster: "workload", Server: "https://wl.example.com"},
+			expected: false,
+		},
+		{
+			name:     "MatchingContextNameOnly",
+			mgmt:     []unstructuredv1.Unstructured{newCluster("", 0), machine},
+			workload: ClusterObjects{Cluster: "wl-admin@wl", Server: "https://wl.example.com"},
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			workload := tt.workload
+			workload.Mapper = newTestRESTMapper()
+			workload.Objects = []unstructuredv1.Unstructured{node}
+			clusters := []ClusterObjects{
+				{Cluster: "mgmt", Server: "https://10.0.0.100:6443", Mapper: newTestRESTMapper(), Objects: tt.mgmt},
+				workload,
+			}
+			nodeMap, err := ResolveClusterDependents(clusters, []types.UID{"machine"}, true, ResolveOptions{})
+			if err != nil {
+				t.Fatalf("failed to resolve relationships: %v", err)
+			}
+			n, ok := nodeMap["node"]
+			if ok != tt.expected {
+				t.Fatalf("expected Node in the dependents of the Machine to be %t, got %v", tt.expected, nodeMap)
+			}
+			if !ok {
+				return
+			}
+			if _, ok := n.Dependencies["machine"][RelationshipClusterAPIMachineNode]; !ok {
+				t.Fatalf("expected %s relationship from \"node\" to \"machine\", got %v", RelationshipClusterAPIMachineNode, n.Dependencies)
+			}
+			if n.Cluster != workload.Cluster {
+				t.Fatalf("expected Node in cluster \"%s\" got \"%s\"", workload.Cluster, n.Cluster)
+			}
+		})
+	}
+}
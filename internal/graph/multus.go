@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// Multus CNI group & well-known values.
+const (
+	MultusGroupName = "k8s.cni.cncf.io"
+
+	// MultusNetworksAnnotation is the annotation of Pods containing the
+	// secondary networks to attach to the Pod, either as a JSON list of network
+	// selection elements or as a comma-separated list of
+	// "[<namespace>/]<name>[@<interface>]".
+	MultusNetworksAnnotation = "k8s.v1.cni.cncf.io/networks"
+	// MultusNetworkStatusAnnotation is the annotation of Pods containing a JSON
+	// list of the status of each network attached to the Pod.
+	MultusNetworkStatusAnnotation = "k8s.v1.cni.cncf.io/network-status"
+)
+
+// multusNetworkSelectionElement represents a network selection element of the
+// "k8s.v1.cni.cncf.io/networks" annotation.
+type multusNetworkSelectionElement struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Interface string `json:"interface,omitempty"`
+}
+
+// multusNetworkStatus represents a network status of the
+// "k8s.v1.cni.cncf.io/network-status" annotation.
+type multusNetworkStatus struct {
+	Name      string `json:"name"`
+	Interface string `json:"interface,omitempty"`
+}
+
+// addMultusPodRelationships adds the relationships that the provided Pod has
+// with NetworkAttachmentDefinitions, based on the Multus annotations set on
+// the Pod.
+func addMultusPodRelationships(result *RelationshipMap, n *Node) {
+	// Interfaces of each network attached to the Pod, in order of appearance
+	var refs []ObjectReference
+	ifaces := map[ObjectReference][]string{}
+	addInterface := func(ns, name, iface string) {
+		ref := ObjectReference{Group: MultusGroupName, Kind: "NetworkAttachmentDefinition", Namespace: ns, Name: name}
+		list, ok := ifaces[ref]
+		if !ok {
+			refs = append(refs, ref)
+		}
+		if len(iface) == 0 {
+			ifaces[ref] = list
+			return
+		}
+		for _, i := range list {
+			if i == iface {
+				return
+			}
+		}
+		ifaces[ref] = append(list, iface)
+	}
+
+	annotations := n.GetAnnotations()
+	if networks := annotations[MultusNetworksAnnotation]; len(networks) > 0 {
+		elems, err := parseMultusNetworks(networks, n.Namespace)
+		if err != nil {
+			klog.V(4).Infof("Failed to parse annotation \"%s\" of Pod \"%s\" in namespace \"%s\": %s", MultusNetworksAnnotation, n.Name, n.Namespace, err)
+		}
+		for _, e := range elems {
+			addInterface(e.Namespace, e.Name, e.Interface)
+		}
+	}
+	if status := annotations[MultusNetworkStatusAnnotation]; len(status) > 0 {
+		var statuses []multusNetworkStatus
+		if err := json.Unmarshal([]byte(status), &statuses); err != nil {
+			klog.V(4).Infof("Failed to parse annotation \"%s\" of Pod \"%s\" in namespace \"%s\": %s", MultusNetworkStatusAnnotation, n.Name, n.Namespace, err)
+		}
+		for _, s := range statuses {
+			// The status of the cluster-wide default network is reported under
+			// the name of its CNI network configuration instead of a
+			// "<namespace>/<name>" reference, so it gets skipped here
+			nn := strings.SplitN(s.Name, "/", 2)
+			if len(nn) != 2 || len(nn[0]) == 0 || len(nn[1]) == 0 {
+				continue
+			}
+			addInterface(nn[0], nn[1], s.Interface)
+		}
+	}
+
+	// RelationshipPodNetworkAttachmentDefinition
+	for _, r := range refs {
+		if len(ifaces[r]) == 0 {
+			result.AddDependencyByKey(r.Key(), RelationshipPodNetworkAttachmentDefinition)
+			continue
+		}
+		for _, iface := range ifaces[r] {
+			result.AddDependencyByKey(r.Key(), withEdgeMetadata(RelationshipPodNetworkAttachmentDefinition, iface))
+		}
+	}
+}
+
+// parseMultusNetworks returns the network selection elements of the provided
+// "k8s.v1.cni.cncf.io/networks" annotation value. Elements without a
+// namespace refer to networks in the provided namespace.
+func parseMultusNetworks(networks, namespace string) ([]multusNetworkSelectionElement, error) {
+	var elems []multusNetworkSelectionElement
+	networks = strings.TrimSpace(networks)
+	if strings.HasPrefix(networks, "[") {
+		if err := json.Unmarshal([]byte(networks), &elems); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, item := range strings.Split(networks, ",") {
+			item = strings.TrimSpace(item)
+			if len(item) == 0 {
+				continue
+			}
+			var e multusNetworkSelectionElement
+			if i := strings.LastIndex(item, "@"); i >= 0 {
+				item, e.Interface = item[:i], item[i+1:]
+			}
+			if i := strings.Index(item, "/"); i >= 0 {
+				e.Namespace, item = item[:i], item[i+1:]
+			}
+			e.Name = item
+			elems = append(elems, e)
+		}
+	}
+
+	result := make([]multusNetworkSelectionElement, 0, len(elems))
+	for _, e := range elems {
+		if len(e.Name) == 0 {
+			return result, fmt.Errorf("missing network name in %q", networks)
+		}
+		if len(e.Namespace) == 0 {
+			e.Namespace = namespace
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
@@ -0,0 +1,281 @@
+package graph
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Prometheus Operator group.
+const PrometheusOperatorGroupName = "monitoring.coreos.com"
+
+const (
+	// Prometheus Operator Alertmanager relationships.
+	RelationshipPrometheusOperatorAlertmanagerConfig       Relationship = "PrometheusOperatorAlertmanagerConfig"
+	RelationshipPrometheusOperatorAlertmanagerConfigSecret Relationship = "PrometheusOperatorAlertmanagerConfigSecret" //nolint:gosec
+
+	// Prometheus Operator Prometheus relationships.
+	RelationshipPrometheusOperatorPrometheusAdditionalScrapeConfigs Relationship = "PrometheusOperatorPrometheusAdditionalScrapeConfigs"
+	RelationshipPrometheusOperatorPrometheusAlertmanager            Relationship = "PrometheusOperatorPrometheusAlertmanager"
+	RelationshipPrometheusOperatorPrometheusPodMonitor              Relationship = "PrometheusOperatorPrometheusPodMonitor"
+	RelationshipPrometheusOperatorPrometheusProbe                   Relationship = "PrometheusOperatorPrometheusProbe"
+	RelationshipPrometheusOperatorPrometheusRule                    Relationship = "PrometheusOperatorPrometheusRule"
+	RelationshipPrometheusOperatorPrometheusServiceMonitor          Relationship = "PrometheusOperatorPrometheusServiceMonitor"
+
+	// Prometheus Operator Alertmanager & Prometheus relationships.
+	RelationshipPrometheusOperatorConfigMap      Relationship = "PrometheusOperatorConfigMap"
+	RelationshipPrometheusOperatorSecret         Relationship = "PrometheusOperatorSecret" //nolint:gosec
+	RelationshipPrometheusOperatorServiceAccount Relationship = "PrometheusOperatorServiceAccount"
+
+	// Prometheus Operator PodMonitor & ServiceMonitor relationships.
+	RelationshipPrometheusOperatorEndpointAuthorization Relationship = "PrometheusOperatorEndpointAuthorization"
+	RelationshipPrometheusOperatorEndpointBasicAuth     Relationship = "PrometheusOperatorEndpointBasicAuth"
+	RelationshipPrometheusOperatorEndpointTLSConfig     Relationship = "PrometheusOperatorEndpointTLSConfig"
+	RelationshipPrometheusOperatorPodMonitor            Relationship = "PrometheusOperatorPodMonitor"
+	RelationshipPrometheusOperatorServiceMonitor        Relationship = "PrometheusOperatorServiceMonitor"
+)
+
+// getPrometheusOperatorAlertmanagerRelationships returns a map of
+// relationships that this Alertmanager has with other objects, based on what
+// was referenced in its manifest.
+func getPrometheusOperatorAlertmanagerRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipPrometheusOperatorAlertmanagerConfig
+	err := addPrometheusOperatorSelectorRelationships(&result, n, "AlertmanagerConfig", "alertmanagerConfigSelector", "alertmanagerConfigNamespaceSelector", RelationshipPrometheusOperatorAlertmanagerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// RelationshipPrometheusOperatorAlertmanagerConfigSecret
+	name := n.GetNestedString("spec", "configSecret")
+	if len(name) == 0 {
+		name = "alertmanager-" + n.Name
+	}
+	ref = ObjectReference{Kind: "Secret", Name: name, Namespace: n.Namespace}
+	result.AddDependencyByKey(ref.Key(), RelationshipPrometheusOperatorAlertmanagerConfigSecret)
+
+	// RelationshipPrometheusOperatorConfigMap
+	// RelationshipPrometheusOperatorSecret
+	// RelationshipPrometheusOperatorServiceAccount
+	addPrometheusOperatorPodRelationships(&result, n)
+
+	return &result, nil
+}
+
+// getPrometheusOperatorMonitorRelationships returns a map of relationships
+// that this PodMonitor or ServiceMonitor has with other objects, based on what
+// was referenced in its manifest.
+func getPrometheusOperatorMonitorRelationships(n *Node) (*RelationshipMap, error) {
+	result := newRelationshipMap()
+
+	kind, endpointsField, r := "Service", "endpoints", RelationshipPrometheusOperatorServiceMonitor
+	if n.Kind == "PodMonitor" {
+		kind, endpointsField, r = "Pod", "podMetricsEndpoints", RelationshipPrometheusOperatorPodMonitor
+	}
+
+	// RelationshipPrometheusOperatorPodMonitor
+	// RelationshipPrometheusOperatorServiceMonitor
+	selector, _, err := getNestedLabelSelector(n, "spec", "selector")
+	if err != nil {
+		return nil, err
+	}
+	if selector == nil {
+		selector = labels.Everything()
+	}
+	anyNamespace, _, _ := unstructuredv1.NestedBool(n.UnstructuredContent(), "spec", "namespaceSelector", "any")
+	matchNames, _, _ := unstructuredv1.NestedStringSlice(n.UnstructuredContent(), "spec", "namespaceSelector", "matchNames")
+	switch {
+	case anyNamespace:
+		ols := ObjectLabelSelector{Kind: kind, Selector: selector, AllNamespaces: true}
+		result.AddDependencyByLabelSelector(ols, r)
+	case len(matchNames) > 0:
+		for _, ns := range matchNames {
+			ols := ObjectLabelSelector{Kind: kind, Namespace: ns, Selector: selector}
+			result.AddDependencyByLabelSelector(ols, r)
+		}
+	default:
+		ols := ObjectLabelSelector{Kind: kind, Namespace: n.Namespace, Selector: selector}
+		result.AddDependencyByLabelSelector(ols, r)
+	}
+
+	// RelationshipPrometheusOperatorEndpointAuthorization
+	// RelationshipPrometheusOperatorEndpointBasicAuth
+	// RelationshipPrometheusOperatorEndpointTLSConfig
+	endpoints, _, _ := unstructuredv1.NestedSlice(n.UnstructuredContent(), "spec", endpointsField)
+	for _, e := range endpoints {
+		endpoint, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		addPrometheusOperatorEndpointRelationships(&result, endpoint, n.Namespace)
+	}
+
+	return &result, nil
+}
+
+// getPrometheusOperatorPrometheusRelationships returns a map of relationships
+// that this Prometheus has with other objects, based on what was referenced
+// in its manifest.
+func getPrometheusOperatorPrometheusRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipPrometheusOperatorPrometheusPodMonitor
+	// RelationshipPrometheusOperatorPrometheusProbe
+	// RelationshipPrometheusOperatorPrometheusRule
+	// RelationshipPrometheusOperatorPrometheusServiceMonitor
+	for _, s := range []struct {
+		kind            string
+		selectorField   string
+		nsSelectorField string
+		relationship    Relationship
+	}{
+		{"PodMonitor", "podMonitorSelector", "podMonitorNamespaceSelector", RelationshipPrometheusOperatorPrometheusPodMonitor},
+		{"Probe", "probeSelector", "probeNamespaceSelector", RelationshipPrometheusOperatorPrometheusProbe},
+		{"PrometheusRule", "ruleSelector", "ruleNamespaceSelector", RelationshipPrometheusOperatorPrometheusRule},
+		{"ServiceMonitor", "serviceMonitorSelector", "serviceMonitorNamespaceSelector", RelationshipPrometheusOperatorPrometheusServiceMonitor},
+	} {
+		err := addPrometheusOperatorSelectorRelationships(&result, n, s.kind, s.selectorField, s.nsSelectorField, s.relationship)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// RelationshipPrometheusOperatorPrometheusAdditionalScrapeConfigs
+	if name := n.GetNestedString("spec", "additionalScrapeConfigs", "name"); len(name) > 0 {
+		ref = ObjectReference{Kind: "Secret", Name: name, Namespace: n.Namespace}
+		result.AddDependencyByKey(ref.Key(), RelationshipPrometheusOperatorPrometheusAdditionalScrapeConfigs)
+	}
+
+	// RelationshipPrometheusOperatorPrometheusAlertmanager
+	alertmanagers, _, _ := unstructuredv1.NestedSlice(n.UnstructuredContent(), "spec", "alerting", "alertmanagers")
+	for _, a := range alertmanagers {
+		am, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructuredv1.NestedString(am, "name")
+		ns, _, _ := unstructuredv1.NestedString(am, "namespace")
+		if len(name) == 0 {
+			continue
+		}
+		if len(ns) == 0 {
+			ns = n.Namespace
+		}
+		ref = ObjectReference{Kind: "Service", Name: name, Namespace: ns}
+		result.AddDependencyByKey(ref.Key(), RelationshipPrometheusOperatorPrometheusAlertmanager)
+		addPrometheusOperatorEndpointRelationships(&result, am, n.Namespace)
+	}
+
+	// RelationshipPrometheusOperatorConfigMap
+	// RelationshipPrometheusOperatorSecret
+	// RelationshipPrometheusOperatorServiceAccount
+	addPrometheusOperatorPodRelationships(&result, n)
+
+	return &result, nil
+}
+
+// addPrometheusOperatorEndpointRelationships adds the relationships to the
+// Secrets & ConfigMaps referenced by the authentication & TLS configuration
+// of the provided endpoint (eg. ServiceMonitor endpoints) to the provided
+// relationship map.
+func addPrometheusOperatorEndpointRelationships(result *RelationshipMap, endpoint map[string]interface{}, ns string) {
+	for _, f := range []struct {
+		kind         string
+		fields       []string
+		relationship Relationship
+	}{
+		{"Secret", []string{"authorization", "credentials", "name"}, RelationshipPrometheusOperatorEndpointAuthorization},
+		{"Secret", []string{"bearerTokenSecret", "name"}, RelationshipPrometheusOperatorEndpointAuthorization},
+		{"Secret", []string{"basicAuth", "password", "name"}, RelationshipPrometheusOperatorEndpointBasicAuth},
+		{"Secret", []string{"basicAuth", "username", "name"}, RelationshipPrometheusOperatorEndpointBasicAuth},
+		{"ConfigMap", []string{"tlsConfig", "ca", "configMap", "name"}, RelationshipPrometheusOperatorEndpointTLSConfig},
+		{"Secret", []string{"tlsConfig", "ca", "secret", "name"}, RelationshipPrometheusOperatorEndpointTLSConfig},
+		{"ConfigMap", []string{"tlsConfig", "cert", "configMap", "name"}, RelationshipPrometheusOperatorEndpointTLSConfig},
+		{"Secret", []string{"tlsConfig", "cert", "secret", "name"}, RelationshipPrometheusOperatorEndpointTLSConfig},
+		{"Secret", []string{"tlsConfig", "keySecret", "name"}, RelationshipPrometheusOperatorEndpointTLSConfig},
+	} {
+		if name, _, _ := unstructuredv1.NestedString(endpoint, f.fields...); len(name) > 0 {
+			ref := ObjectReference{Kind: f.kind, Name: name, Namespace: ns}
+			result.AddDependencyByKey(ref.Key(), f.relationship)
+		}
+	}
+}
+
+// addPrometheusOperatorPodRelationships adds the relationships to the
+// ConfigMaps, Secrets & ServiceAccount mounted into the pods of this
+// Alertmanager or Prometheus to the provided relationship map.
+func addPrometheusOperatorPodRelationships(result *RelationshipMap, n *Node) {
+	var ref ObjectReference
+
+	// RelationshipPrometheusOperatorConfigMap
+	configMaps, _, _ := unstructuredv1.NestedStringSlice(n.UnstructuredContent(), "spec", "configMaps")
+	for _, name := range configMaps {
+		ref = ObjectReference{Kind: "ConfigMap", Name: name, Namespace: n.Namespace}
+		result.AddDependencyByKey(ref.Key(), RelationshipPrometheusOperatorConfigMap)
+	}
+
+	// RelationshipPrometheusOperatorSecret
+	secrets, _, _ := unstructuredv1.NestedStringSlice(n.UnstructuredContent(), "spec", "secrets")
+	for _, name := range secrets {
+		ref = ObjectReference{Kind: "Secret", Name: name, Namespace: n.Namespace}
+		result.AddDependencyByKey(ref.Key(), RelationshipPrometheusOperatorSecret)
+	}
+
+	// RelationshipPrometheusOperatorServiceAccount
+	if name := n.GetNestedString("spec", "serviceAccountName"); len(name) > 0 {
+		ref = ObjectReference{Kind: "ServiceAccount", Name: name, Namespace: n.Namespace}
+		result.AddDependencyByKey(ref.Key(), RelationshipPrometheusOperatorServiceAccount)
+	}
+}
+
+// addPrometheusOperatorSelectorRelationships adds the relationships to the
+// objects of the provided kind selected by the provided selector & namespace
+// selector fields of this Alertmanager or Prometheus to the provided
+// relationship map. A nil selector selects no objects, while a nil namespace
+// selector only selects objects in the same namespace.
+func addPrometheusOperatorSelectorRelationships(result *RelationshipMap, n *Node, kind, selectorField, namespaceSelectorField string, r Relationship) error {
+	selector, found, err := getNestedLabelSelector(n, "spec", selectorField)
+	if err != nil || !found {
+		return err
+	}
+	nsSelector, found, err := getNestedLabelSelector(n, "spec", namespaceSelectorField)
+	if err != nil {
+		return err
+	}
+
+	ols := ObjectLabelSelector{Group: PrometheusOperatorGroupName, Kind: kind, Namespace: n.Namespace, Selector: selector}
+	switch {
+	case !found:
+		result.AddDependencyByLabelSelector(ols, r)
+	case nsSelector.Empty():
+		ols.AllNamespaces = true
+		result.AddDependencyByLabelSelector(ols, r)
+	default:
+		ons := ObjectNamespaceSelector{Group: PrometheusOperatorGroupName, Kind: kind, Selector: selector, NamespaceSelector: nsSelector}
+		result.AddDependencyByNamespaceSelector(ons, r)
+	}
+
+	return nil
+}
+
+// getNestedLabelSelector returns the label selector nested in the provided
+// fields of the node. Returns false if the label selector isn't set.
+func getNestedLabelSelector(n *Node, fields ...string) (labels.Selector, bool, error) {
+	s, found, err := unstructuredv1.NestedMap(n.UnstructuredContent(), fields...)
+	if !found || err != nil {
+		return nil, false, err
+	}
+	var ls metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(s, &ls); err != nil {
+		return nil, false, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&ls)
+	if err != nil {
+		return nil, false, err
+	}
+	return selector, true, nil
+}
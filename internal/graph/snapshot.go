@@ -0,0 +1,142 @@
+package graph
+
+import (
+	"strings"
+
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+// CSI external-snapshotter group & well-known values.
+const (
+	SnapshotGroupName = "snapshot.storage.k8s.io"
+
+	// snapshotDeletionSecretNameAnnotation & snapshotDeletionSecretNamespaceAnnotation
+	// are set on VolumeSnapshotContents by the snapshot controller to record the
+	// Secret used by the CSI driver when deleting the snapshot.
+	snapshotDeletionSecretNameAnnotation      = "snapshot.storage.kubernetes.io/deletion-secret-name"      //nolint:gosec
+	snapshotDeletionSecretNamespaceAnnotation = "snapshot.storage.kubernetes.io/deletion-secret-namespace" //nolint:gosec
+
+	// snapshotterSecretNameParameter & snapshotterSecretNamespaceParameter are
+	// the VolumeSnapshotClass parameters of the Secret used by the CSI driver
+	// when creating & deleting snapshots.
+	snapshotterSecretNameParameter      = "csi.storage.k8s.io/snapshotter-secret-name"      //nolint:gosec
+	snapshotterSecretNamespaceParameter = "csi.storage.k8s.io/snapshotter-secret-namespace" //nolint:gosec
+)
+
+const (
+	// CSI external-snapshotter VolumeSnapshot relationships.
+	RelationshipVolumeSnapshotClass   Relationship = "VolumeSnapshotClass"
+	RelationshipVolumeSnapshotContent Relationship = "VolumeSnapshotContent"
+	RelationshipVolumeSnapshotSource  Relationship = "VolumeSnapshotSource"
+
+	// CSI external-snapshotter VolumeSnapshotClass relationships.
+	RelationshipVolumeSnapshotClassCSIDriver Relationship = "VolumeSnapshotClassCSIDriver"
+	RelationshipVolumeSnapshotClassSecret    Relationship = "VolumeSnapshotClassSecret" //nolint:gosec
+
+	// CSI external-snapshotter VolumeSnapshotContent relationships.
+	RelationshipVolumeSnapshotContentCSIDriver      Relationship = "VolumeSnapshotContentCSIDriver"
+	RelationshipVolumeSnapshotContentClass          Relationship = "VolumeSnapshotContentClass"
+	RelationshipVolumeSnapshotContentDeletionSecret Relationship = "VolumeSnapshotContentDeletionSecret" //nolint:gosec
+	RelationshipVolumeSnapshotContentSnapshot       Relationship = "VolumeSnapshotContentSnapshot"
+)
+
+// getVolumeSnapshotRelationships returns a map of relationships that this
+// VolumeSnapshot has with other objects, based on what was referenced in its
+// manifest.
+func getVolumeSnapshotRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipVolumeSnapshotClass
+	if name := n.GetNestedString("spec", "volumeSnapshotClassName"); len(name) > 0 {
+		ref = ObjectReference{Group: SnapshotGroupName, Kind: "VolumeSnapshotClass", Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipVolumeSnapshotClass)
+	}
+
+	// RelationshipVolumeSnapshotContent (pre-provisioned & dynamically
+	// provisioned snapshots)
+	for _, name := range []string{
+		n.GetNestedString("spec", "source", "volumeSnapshotContentName"),
+		n.GetNestedString("status", "boundVolumeSnapshotContentName"),
+	} {
+		if len(name) > 0 {
+			ref = ObjectReference{Group: SnapshotGroupName, Kind: "VolumeSnapshotContent", Name: name}
+			result.AddDependencyByKey(ref.Key(), RelationshipVolumeSnapshotContent)
+		}
+	}
+
+	// RelationshipVolumeSnapshotSource
+	if name := n.GetNestedString("spec", "source", "persistentVolumeClaimName"); len(name) > 0 {
+		ref = ObjectReference{Kind: "PersistentVolumeClaim", Namespace: n.Namespace, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipVolumeSnapshotSource)
+	}
+
+	return &result, nil
+}
+
+// getVolumeSnapshotClassRelationships returns a map of relationships that this
+// VolumeSnapshotClass has with other objects, based on what was referenced in
+// its manifest.
+func getVolumeSnapshotClassRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipVolumeSnapshotClassCSIDriver
+	if driver := n.GetNestedString("driver"); len(driver) > 0 {
+		ref = ObjectReference{Group: storagev1.GroupName, Kind: "CSIDriver", Name: driver}
+		result.AddDependencyByKey(ref.Key(), RelationshipVolumeSnapshotClassCSIDriver)
+	}
+
+	// RelationshipVolumeSnapshotClassSecret (ignoring templated names &
+	// namespaces which are only resolved when provisioning a snapshot)
+	name := n.GetNestedString("parameters", snapshotterSecretNameParameter)
+	ns := n.GetNestedString("parameters", snapshotterSecretNamespaceParameter)
+	if len(name) > 0 && len(ns) > 0 && !strings.Contains(name+ns, "${") {
+		ref = ObjectReference{Kind: "Secret", Namespace: ns, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipVolumeSnapshotClassSecret)
+	}
+
+	return &result, nil
+}
+
+// getVolumeSnapshotContentRelationships returns a map of relationships that
+// this VolumeSnapshotContent has with other objects, based on what was
+// referenced in its manifest.
+func getVolumeSnapshotContentRelationships(n *Node) (*RelationshipMap, error) {
+	var ref ObjectReference
+	result := newRelationshipMap()
+
+	// RelationshipVolumeSnapshotContentCSIDriver
+	if driver := n.GetNestedString("spec", "driver"); len(driver) > 0 {
+		ref = ObjectReference{Group: storagev1.GroupName, Kind: "CSIDriver", Name: driver}
+		result.AddDependencyByKey(ref.Key(), RelationshipVolumeSnapshotContentCSIDriver)
+	}
+
+	// RelationshipVolumeSnapshotContentClass
+	if name := n.GetNestedString("spec", "volumeSnapshotClassName"); len(name) > 0 {
+		ref = ObjectReference{Group: SnapshotGroupName, Kind: "VolumeSnapshotClass", Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipVolumeSnapshotContentClass)
+	}
+
+	// RelationshipVolumeSnapshotContentDeletionSecret
+	annotations := n.GetAnnotations()
+	name := annotations[snapshotDeletionSecretNameAnnotation]
+	ns := annotations[snapshotDeletionSecretNamespaceAnnotation]
+	if len(name) > 0 && len(ns) > 0 {
+		ref = ObjectReference{Kind: "Secret", Namespace: ns, Name: name}
+		result.AddDependencyByKey(ref.Key(), RelationshipVolumeSnapshotContentDeletionSecret)
+	}
+
+	// RelationshipVolumeSnapshotContentSnapshot
+	if name := n.GetNestedString("spec", "volumeSnapshotRef", "name"); len(name) > 0 {
+		ref = ObjectReference{
+			Group:     SnapshotGroupName,
+			Kind:      "VolumeSnapshot",
+			Namespace: n.GetNestedString("spec", "volumeSnapshotRef", "namespace"),
+			Name:      name,
+		}
+		result.AddDependencyByKey(ref.Key(), RelationshipVolumeSnapshotContentSnapshot)
+	}
+
+	return &result, nil
+}
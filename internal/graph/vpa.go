@@ -0,0 +1,24 @@
+package graph
+
+// Vertical Pod Autoscaler group.
+const VerticalPodAutoscalerGroupName = "autoscaling.k8s.io"
+
+const (
+	// Vertical Pod Autoscaler VerticalPodAutoscaler relationships.
+	RelationshipVerticalPodAutoscalerTarget Relationship = "VerticalPodAutoscalerTarget"
+)
+
+// getVerticalPodAutoscalerRelationships returns a map of relationships that
+// this VerticalPodAutoscaler has with other objects, based on what was
+// referenced in its manifest.
+func getVerticalPodAutoscalerRelationships(n *Node) (*RelationshipMap, error) {
+	result := newRelationshipMap()
+
+	// RelationshipVerticalPodAutoscalerTarget
+	if ref, ok := getNestedObjectReference(n, "spec", "targetRef"); ok {
+		ref.Namespace = n.Namespace
+		result.AddDependencyByKey(ref.Key(), RelationshipVerticalPodAutoscalerTarget)
+	}
+
+	return &result, nil
+}
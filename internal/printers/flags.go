@@ -2,9 +2,11 @@ package printers
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
 	"github.com/tohjustin/kube-lineage/internal/client"
@@ -15,6 +17,11 @@ const (
 	flagOutputFormatShorthand = "o"
 )
 
+// List of supported non-table output formats.
+const (
+	outputFormatJSON = "json"
+)
+
 // Flags composes common printer flag structs used in the command.
 type Flags struct {
 	HumanReadableFlags *HumanPrintFlags
@@ -34,6 +41,7 @@ func (f *Flags) AddFlags(flags *pflag.FlagSet) {
 // AllowedFormats is the list of formats in which data can be displayed.
 func (f *Flags) AllowedFormats() []string {
 	formats := []string{}
+	formats = append(formats, outputFormatJSON)
 	formats = append(formats, f.HumanReadableFlags.AllowedFormats()...)
 	return formats
 }
@@ -55,14 +63,33 @@ func (f *Flags) IsTableOutputFormat(outputFormat string) bool {
 	return f.HumanReadableFlags.IsSupportedOutputFormat(outputFormat)
 }
 
+// StatusRequiresFullObject returns true if printing objects with the provided
+// GroupKind in the current output format requires more than their ObjectMeta.
+// Only the default table output formats print the ready & status values of
+// objects, as the split output formats print server-printed tables instead.
+func (f *Flags) StatusRequiresFullObject(gk schema.GroupKind) bool {
+	outputFormat := ""
+	if f.OutputFormat != nil {
+		outputFormat = *f.OutputFormat
+	}
+	if !f.IsTableOutputFormat(outputFormat) && outputFormat != "" {
+		return false
+	}
+	if f.HumanReadableFlags.IsSplitOutputFormat(outputFormat) {
+		return false
+	}
+	return statusRequiresFullObject(gk)
+}
+
 // SetShowNamespace configures whether human-readable flags return a printer
 // capable of printing with a "namespace" column.
 func (f *Flags) SetShowNamespace(b bool) {
 	f.HumanReadableFlags.SetShowNamespace(b)
 }
 
-// ToPrinter returns a printer based on current flag values.
-func (f *Flags) ToPrinter(client client.Interface) (Interface, error) {
+// ToPrinter returns a printer based on current flag values. Warnings are
+// printed to the provided errOut writer.
+func (f *Flags) ToPrinter(client client.Interface, errOut io.Writer) (Interface, error) {
 	outputFormat := ""
 	if f.OutputFormat != nil {
 		outputFormat = *f.OutputFormat
@@ -76,7 +103,10 @@ func (f *Flags) ToPrinter(client client.Interface) (Interface, error) {
 			configFlags:  configFlags.HumanReadableFlags,
 			outputFormat: outputFormat,
 			client:       client,
+			errOut:       errOut,
 		}
+	case outputFormat == outputFormatJSON:
+		printer = &jsonPrinter{}
 	default:
 		return nil, genericclioptions.NoCompatiblePrinterError{
 			AllowedFormats: f.AllowedFormats(),
@@ -27,7 +27,7 @@ func lessGroupKind(lhs, rhs schema.GroupKind) bool {
 }
 
 type Interface interface {
-	Print(w io.Writer, nodeMap graph.NodeMap, rootUID types.UID, maxDepth uint, depsIsDependencies bool) error
+	Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool, skipped []client.SkippedResource) error
 }
 
 type tablePrinter struct {
@@ -37,32 +37,96 @@ type tablePrinter struct {
 	// client for fetching server-printed tables when printing in split output
 	// format
 	client client.Interface
+	// errOut is the writer which warnings (eg. skipped resources) are printed
+	// to, to keep them separate from the printed relationships
+	errOut io.Writer
 }
 
-func (p *tablePrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUID types.UID, maxDepth uint, depsIsDependencies bool) error {
-	root, ok := nodeMap[rootUID]
-	if !ok {
-		return fmt.Errorf("requested object (uid: %s) not found in list of fetched objects", rootUID)
+func (p *tablePrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool, skipped []client.SkippedResource) error {
+	roots, err := getRootNodes(nodeMap, rootUIDs)
+	if err != nil {
+		return err
 	}
 
 	if p.configFlags.IsSplitOutputFormat(p.outputFormat) {
+		if shouldShowCluster(nodeMap) {
+			return fmt.Errorf("output format \"%s\" is not supported for objects across multiple clusters", p.outputFormat)
+		}
 		if p.client == nil {
 			return fmt.Errorf("client must be provided to get server-printed tables")
 		}
-		return p.printTablesByGK(w, nodeMap, maxDepth)
+		err = p.printTablesByGK(w, nodeMap, maxDepth)
+	} else {
+		err = p.printTable(w, nodeMap, roots, maxDepth, depsIsDependencies)
+	}
+	if err != nil {
+		return err
+	}
+
+	printSkippedResources(p.errOut, skipped)
+	return nil
+}
+
+// printSkippedResources prints a warning listing the provided skipped
+// resources, to indicate that the printed relationships may be incomplete.
+func printSkippedResources(w io.Writer, skipped []client.SkippedResource) {
+	if w == nil || len(skipped) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nWarning: results may be incomplete, unable to fetch the following resources:\n")
+	for _, s := range skipped {
+		fmt.Fprintf(w, "  - %s: %s\n", s, s.Reason)
+	}
+}
+
+// getRootNodes returns the nodes of the provided root UIDs.
+func getRootNodes(nodeMap graph.NodeMap, rootUIDs []types.UID) ([]*graph.Node, error) {
+	roots := make([]*graph.Node, 0, len(rootUIDs))
+	for _, uid := range rootUIDs {
+		root, ok := nodeMap[uid]
+		if !ok {
+			return nil, fmt.Errorf("requested object (uid: %s) not found in list of fetched objects", uid)
+		}
+		roots = append(roots, root)
 	}
+	return roots, nil
+}
 
-	return p.printTable(w, nodeMap, root, maxDepth, depsIsDependencies)
+func (p *tablePrinter) printTable(w io.Writer, nodeMap graph.NodeMap, roots []*graph.Node, maxDepth uint, depsIsDependencies bool) error {
+	// Print a separate subtree for the root objects of each cluster when the
+	// objects span one or more clusters
+	if !shouldShowCluster(nodeMap) {
+		return p.printSubtree(w, nodeMap, roots, maxDepth, depsIsDependencies, false)
+	}
+	var clusters []string
+	rootsByCluster := map[string][]*graph.Node{}
+	for _, root := range roots {
+		if _, ok := rootsByCluster[root.Cluster]; !ok {
+			clusters = append(clusters, root.Cluster)
+		}
+		rootsByCluster[root.Cluster] = append(rootsByCluster[root.Cluster], root)
+	}
+	for ix, cluster := range clusters {
+		if err := p.printSubtree(w, nodeMap, rootsByCluster[cluster], maxDepth, depsIsDependencies, true); err != nil {
+			return err
+		}
+		if ix != len(clusters)-1 {
+			fmt.Fprintf(w, "\n")
+		}
+	}
+	return nil
 }
 
-func (p *tablePrinter) printTable(w io.Writer, nodeMap graph.NodeMap, root *graph.Node, maxDepth uint, depsIsDependencies bool) error {
+// printSubtree prints the provided root objects & either their dependencies
+// or dependents as a single table.
+func (p *tablePrinter) printSubtree(w io.Writer, nodeMap graph.NodeMap, roots []*graph.Node, maxDepth uint, depsIsDependencies, showCluster bool) error {
 	// Generate Table to print
 	showGroup := false
 	if sg := p.configFlags.ShowGroup; sg != nil {
 		showGroup = *sg
 	}
 	showGroupFn := createShowGroupFn(nodeMap, showGroup, maxDepth)
-	t, err := nodeMapToTable(nodeMap, root, maxDepth, depsIsDependencies, showGroupFn)
+	t, err := nodeMapToTable(nodeMap, roots, maxDepth, depsIsDependencies, showCluster, showGroupFn)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,82 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+// accessRuleColumnDefinitions holds table column definition for effective RBAC
+// policy rules.
+var accessRuleColumnDefinitions = []metav1.TableColumnDefinition{
+	{Name: "Namespace", Type: "string", Description: "The namespace which the rule applies to, \"*\" if the rule applies cluster-wide."},
+	{Name: "Binding", Type: "string", Description: "The binding granting the rule."},
+	{Name: "Role", Type: "string", Description: "The role containing the rule."},
+	{Name: "Verbs", Type: "string", Description: "The verbs allowed by the rule."},
+	{Name: "API Groups", Type: "string", Description: "The API groups of the resources covered by the rule."},
+	{Name: "Resources", Type: "string", Description: "The resources covered by the rule."},
+	{Name: "Resource Names", Type: "string", Description: "The names of the resources covered by the rule."},
+	{Name: "Non-Resource URLs", Type: "string", Description: "The non-resource URLs covered by the rule."},
+}
+
+// PrintAccessRules prints the provided effective RBAC policy rules as a table.
+func PrintAccessRules(w io.Writer, rules []graph.AccessRule, noHeaders bool) error {
+	sorted := make([]graph.AccessRule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return nodeName(sorted[i].Binding) < nodeName(sorted[j].Binding)
+	})
+
+	t := metav1.Table{ColumnDefinitions: accessRuleColumnDefinitions}
+	for _, r := range sorted {
+		ns := r.Namespace
+		if len(ns) == 0 {
+			ns = "*"
+		}
+		apiGroups := make([]string, len(r.APIGroups))
+		for ix, g := range r.APIGroups {
+			apiGroups[ix] = g
+			if len(g) == 0 {
+				apiGroups[ix] = `""`
+			}
+		}
+		t.Rows = append(t.Rows, metav1.TableRow{
+			Cells: []interface{}{
+				ns,
+				nodeName(r.Binding),
+				nodeName(r.Role),
+				joinOrNotApplicable(r.Verbs),
+				joinOrNotApplicable(apiGroups),
+				joinOrNotApplicable(r.Resources),
+				joinOrNotApplicable(r.ResourceNames),
+				joinOrNotApplicable(r.NonResourceURLs),
+			},
+		})
+	}
+
+	p := printers.NewTablePrinter(printers.PrintOptions{NoHeaders: noHeaders})
+	return p.PrintObj(&t, w)
+}
+
+// nodeName returns the "<kind>/<name>" name of the provided node.
+func nodeName(n *graph.Node) string {
+	return fmt.Sprintf("%s/%s", n.Kind, n.Name)
+}
+
+// joinOrNotApplicable returns the provided values as a comma separated list,
+// or "-" if there are no values.
+func joinOrNotApplicable(s []string) string {
+	if len(s) == 0 {
+		return cellNotApplicable
+	}
+	return strings.Join(s, ",")
+}
@@ -6,10 +6,17 @@ import (
 	"strings"
 	"time"
 
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	eventsv1 "k8s.io/api/events/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	nodev1 "k8s.io/api/node/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -37,6 +44,9 @@ var (
 		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
 		{Name: "Relationships", Type: "array", Description: "The relationships this object has with its parent.", Priority: -1},
 	}
+	// clusterColumnDefinition holds table column definition for the cluster
+	// that Kubernetes objects belong to.
+	clusterColumnDefinition = metav1.TableColumnDefinition{Name: "Cluster", Type: "string", Description: "The name of the cluster this object belongs to."}
 	// objectReadyReasonJSONPath is the JSON path to get a Kubernetes object's
 	// "Ready" condition reason.
 	objectReadyReasonJSONPath = newJSONPath("reason", "{.status.conditions[?(@.type==\"Ready\")].reason}")
@@ -45,6 +55,59 @@ var (
 	objectReadyStatusJSONPath = newJSONPath("status", "{.status.conditions[?(@.type==\"Ready\")].status}")
 )
 
+// statusFullObjectGroupKinds contains the GroupKinds which have their ready &
+// status values computed from fields beyond the object's ObjectMeta.
+var statusFullObjectGroupKinds = map[schema.GroupKind]struct{}{
+	{Group: apiregistrationv1.GroupName, Kind: "APIService"}: {},
+	{Group: appsv1.GroupName, Kind: "DaemonSet"}:             {},
+	{Group: appsv1.GroupName, Kind: "Deployment"}:            {},
+	{Group: appsv1.GroupName, Kind: "ReplicaSet"}:            {},
+	{Group: appsv1.GroupName, Kind: "StatefulSet"}:           {},
+	{Group: corev1.GroupName, Kind: "Event"}:                 {},
+	{Group: corev1.GroupName, Kind: "Pod"}:                   {},
+	{Group: corev1.GroupName, Kind: "ReplicationController"}: {},
+	{Group: eventsv1.GroupName, Kind: "Event"}:               {},
+	{Group: policyv1.GroupName, Kind: "PodDisruptionBudget"}: {},
+	{Group: storagev1.GroupName, Kind: "VolumeAttachment"}:   {},
+}
+
+// metadataOnlyGroupKinds contains the GroupKinds which have no ready & status
+// values, as their objects have neither a "status" field nor a "Ready"
+// condition. Objects of any other GroupKind may have their ready & status
+// values computed from their "Ready" condition.
+var metadataOnlyGroupKinds = map[schema.GroupKind]struct{}{
+	{Group: admissionregistrationv1.GroupName, Kind: "MutatingWebhookConfiguration"}:   {},
+	{Group: admissionregistrationv1.GroupName, Kind: "ValidatingWebhookConfiguration"}: {},
+	{Group: appsv1.GroupName, Kind: "ControllerRevision"}:                              {},
+	{Group: coordinationv1.GroupName, Kind: "Lease"}:                                   {},
+	{Group: corev1.GroupName, Kind: "ConfigMap"}:                                       {},
+	{Group: corev1.GroupName, Kind: "Endpoints"}:                                       {},
+	{Group: corev1.GroupName, Kind: "LimitRange"}:                                      {},
+	{Group: corev1.GroupName, Kind: "PodTemplate"}:                                     {},
+	{Group: corev1.GroupName, Kind: "Secret"}:                                          {},
+	{Group: corev1.GroupName, Kind: "ServiceAccount"}:                                  {},
+	{Group: discoveryv1.GroupName, Kind: "EndpointSlice"}:                              {},
+	{Group: networkingv1.GroupName, Kind: "IngressClass"}:                              {},
+	{Group: nodev1.GroupName, Kind: "RuntimeClass"}:                                    {},
+	{Group: rbacv1.GroupName, Kind: "ClusterRole"}:                                     {},
+	{Group: rbacv1.GroupName, Kind: "ClusterRoleBinding"}:                              {},
+	{Group: rbacv1.GroupName, Kind: "Role"}:                                            {},
+	{Group: rbacv1.GroupName, Kind: "RoleBinding"}:                                     {},
+	{Group: schedulingv1.GroupName, Kind: "PriorityClass"}:                             {},
+	{Group: storagev1.GroupName, Kind: "CSIDriver"}:                                    {},
+	{Group: storagev1.GroupName, Kind: "StorageClass"}:                                 {},
+}
+
+// statusRequiresFullObject returns true if printing the ready & status values
+// of objects with the provided GroupKind requires more than their ObjectMeta.
+func statusRequiresFullObject(gk schema.GroupKind) bool {
+	if _, ok := statusFullObjectGroupKinds[gk]; ok {
+		return true
+	}
+	_, ok := metadataOnlyGroupKinds[gk]
+	return !ok
+}
+
 // createShowGroupFn creates a function that takes in a resource's kind &
 // determines whether the resource's group should be included in its name.
 func createShowGroupFn(nodeMap graph.NodeMap, showGroup bool, maxDepth uint) func(string) bool {
@@ -512,9 +575,10 @@ func nodeToTableRow(node *graph.Node, rset graph.RelationshipSet, namePrefix str
 // dependents into table rows.
 func nodeMapToTable(
 	nodeMap graph.NodeMap,
-	root *graph.Node,
+	roots []*graph.Node,
 	maxDepth uint,
 	depsIsDependencies bool,
+	showCluster bool,
 	showGroupFn func(kind string) bool) (*metav1.Table, error) {
 	// Sorts the list of UIDs based on the underlying object in following order:
 	// Namespace, Kind, Group, Name
@@ -533,22 +597,49 @@ func nodeMapToTable(
 	}
 
 	var rows []metav1.TableRow
-	row := nodeToTableRow(root, nil, "", showGroupFn)
-	uidSet := map[types.UID]struct{}{}
-	depRows, err := nodeDepsToTableRows(nodeMap, uidSet, root, "", 1, maxDepth, depsIsDependencies, sortDepsFn, showGroupFn)
-	if err != nil {
-		return nil, err
+	for _, root := range roots {
+		row := nodeToTableRow(root, nil, "", showGroupFn)
+		uidSet := map[types.UID]struct{}{}
+		depRows, err := nodeDepsToTableRows(nodeMap, uidSet, root, "", 1, maxDepth, depsIsDependencies, sortDepsFn, showGroupFn)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+		rows = append(rows, depRows...)
+	}
+	columns := objectColumnDefinitions
+	if showCluster {
+		// Prepend the name of the cluster that each object belongs to
+		columns = append([]metav1.TableColumnDefinition{clusterColumnDefinition}, columns...)
+		for ix := range rows {
+			cluster := cellNotApplicable
+			if obj, ok := rows[ix].Object.Object.(metav1.Object); ok {
+				if node, ok := nodeMap[obj.GetUID()]; ok && len(node.Cluster) > 0 {
+					cluster = node.Cluster
+				}
+			}
+			rows[ix].Cells = append([]interface{}{cluster}, rows[ix].Cells...)
+		}
 	}
-	rows = append(rows, row)
-	rows = append(rows, depRows...)
 	table := metav1.Table{
-		ColumnDefinitions: objectColumnDefinitions,
+		ColumnDefinitions: columns,
 		Rows:              rows,
 	}
 
 	return &table, nil
 }
 
+// shouldShowCluster returns true if the provided node map contains objects
+// from one or more clusters.
+func shouldShowCluster(nodeMap graph.NodeMap) bool {
+	for _, node := range nodeMap {
+		if len(node.Cluster) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // nodeDepsToTableRows converts either the dependencies or dependents of the
 // provided node into table rows.
 func nodeDepsToTableRows(
@@ -0,0 +1,135 @@
+package printers
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/client"
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+// jsonOutput is the document printed by jsonPrinter.
+type jsonOutput struct {
+	Roots   []types.UID           `json:"roots"`
+	Objects []jsonObject          `json:"objects"`
+	Skipped []jsonSkippedResource `json:"skipped"`
+}
+
+// jsonObject represents an object in the relationship tree.
+type jsonObject struct {
+	Cluster      string           `json:"cluster,omitempty"`
+	APIVersion   string           `json:"apiVersion"`
+	Kind         string           `json:"kind"`
+	Namespace    string           `json:"namespace,omitempty"`
+	Name         string           `json:"name"`
+	UID          types.UID        `json:"uid"`
+	Depth        uint             `json:"depth"`
+	Dependencies []jsonDependency `json:"dependencies,omitempty"`
+	Dependents   []jsonDependency `json:"dependents,omitempty"`
+}
+
+// jsonDependency represents either a dependency or dependent of an object.
+type jsonDependency struct {
+	UID           types.UID `json:"uid"`
+	Relationships []string  `json:"relationships"`
+}
+
+// jsonSkippedResource represents objects that couldn't be fetched from the
+// server, resulting in partial results.
+type jsonSkippedResource struct {
+	Cluster   string `json:"cluster,omitempty"`
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+type jsonPrinter struct{}
+
+func (p *jsonPrinter) Print(w io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, maxDepth uint, depsIsDependencies bool, skipped []client.SkippedResource) error {
+	if _, err := getRootNodes(nodeMap, rootUIDs); err != nil {
+		return err
+	}
+
+	// Filter objects to print based on depth
+	var nodes graph.NodeList
+	for _, node := range nodeMap {
+		if maxDepth == 0 || node.Depth <= maxDepth {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Sort(nodes)
+	uidSet := map[types.UID]struct{}{}
+	for _, node := range nodes {
+		uidSet[node.UID] = struct{}{}
+	}
+
+	output := jsonOutput{
+		Roots:   rootUIDs,
+		Objects: make([]jsonObject, 0, len(nodes)),
+		Skipped: make([]jsonSkippedResource, 0, len(skipped)),
+	}
+	for _, node := range nodes {
+		obj := jsonObject{
+			Cluster:    node.Cluster,
+			APIVersion: schema.GroupVersion{Group: node.Group, Version: node.Version}.String(),
+			Kind:       node.Kind,
+			Namespace:  node.Namespace,
+			Name:       node.Name,
+			UID:        node.UID,
+			Depth:      node.Depth,
+		}
+		deps := nodeDepsToJSON(nodeMap, uidSet, node.GetDeps(depsIsDependencies))
+		if depsIsDependencies {
+			obj.Dependencies = deps
+		} else {
+			obj.Dependents = deps
+		}
+		output.Objects = append(output.Objects, obj)
+	}
+	for _, s := range skipped {
+		output.Skipped = append(output.Skipped, jsonSkippedResource{
+			Cluster:   s.Cluster,
+			Group:     s.APIResource.Group,
+			Version:   s.APIResource.Version,
+			Resource:  s.APIResource.Name,
+			Namespace: s.Namespace,
+			Name:      s.Name,
+			Reason:    s.Reason,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(output)
+}
+
+// nodeDepsToJSON converts the provided dependencies or dependents of a node
+// into a sorted list, only including the objects in the provided UID set.
+func nodeDepsToJSON(nodeMap graph.NodeMap, uidSet map[types.UID]struct{}, deps map[types.UID]graph.RelationshipSet) []jsonDependency {
+	var nodes graph.NodeList
+	for uid := range deps {
+		if _, ok := uidSet[uid]; !ok {
+			continue
+		}
+		if node, ok := nodeMap[uid]; ok {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Sort(nodes)
+
+	result := make([]jsonDependency, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, jsonDependency{
+			UID:           node.UID,
+			Relationships: deps[node.UID].List(),
+		})
+	}
+	return result
+}
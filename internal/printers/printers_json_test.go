@@ -0,0 +1,70 @@
+package printers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tohjustin/kube-lineage/internal/client"
+	"github.com/tohjustin/kube-lineage/internal/graph"
+)
+
+func TestJSONPrinterSkippedResources(t *testing.T) {
+	t.Parallel()
+
+	nodeMap := graph.NodeMap{
+		"pod": &graph.Node{UID: "pod", Version: "v1", Kind: "Pod", Namespace: "default", Name: "pod"},
+	}
+	tests := []struct {
+		name     string
+		skipped  []client.SkippedResource
+		expected []jsonSkippedResource
+	}{
+		{
+			name:     "NoSkippedResources",
+			skipped:  nil,
+			expected: []jsonSkippedResource{},
+		},
+		{
+			name: "SkippedResources",
+			skipped: []client.SkippedResource{
+				{
+					APIResource: client.APIResource{Version: "v1", Kind: "Secret", Name: "secrets", Namespaced: true},
+					Namespace:   "default",
+					Reason:      "Forbidden",
+				},
+				{
+					Cluster:     "workload",
+					APIResource: client.APIResource{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole", Name: "clusterroles"},
+					Name:        "admin",
+					Reason:      "Forbidden",
+				},
+			},
+			expected: []jsonSkippedResource{
+				{Version: "v1", Resource: "secrets", Namespace: "default", Reason: "Forbidden"},
+				{Cluster: "workload", Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles", Name: "admin", Reason: "Forbidden"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			p := &jsonPrinter{}
+			if err := p.Print(&buf, nodeMap, []types.UID{"pod"}, 0, false, tt.skipped); err != nil {
+				t.Fatalf("failed to print: %v", err)
+			}
+			var output jsonOutput
+			if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+				t.Fatalf("failed to decode output: %v", err)
+			}
+			if !reflect.DeepEqual(output.Skipped, tt.expected) {
+				t.Fatalf("expected skipped resources %#v got %#v", tt.expected, output.Skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/klog/v2"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/completion"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/tohjustin/kube-lineage/internal/client"
+	"github.com/tohjustin/kube-lineage/internal/graph"
+	"github.com/tohjustin/kube-lineage/internal/log"
+	lineageprinters "github.com/tohjustin/kube-lineage/internal/printers"
+)
+
+var (
+	cmdPath    string
+	cmdName    = "access"
+	cmdUse     = "%CMD% (SUBJECT_TYPE NAME | SUBJECT_TYPE/NAME) [flags]"
+	cmdExample = templates.Examples(`
+		# List the effective rules & objects accessible by the serviceaccount named "bar" in the current namespace
+		%CMD_PATH% serviceaccount bar
+
+		# List the effective rules & objects accessible by the serviceaccount named "bar" in namespace "foo"
+		%CMD_PATH% sa/bar --namespace=foo
+
+		# List the effective rules & objects accessible by the user named "jane" across all namespaces, including the verbs allowed on each object
+		%CMD_PATH% user/jane --all-namespaces --output=wide
+
+		# List the effective rules & secrets accessible by the group named "system:nodes"
+		%CMD_PATH% group/system:nodes --include-types=secrets`)
+	cmdShort = "Display the effective RBAC rules of a subject & the objects they cover"
+	cmdLong  = templates.LongDesc(`
+		Display the effective RBAC rules of a subject & the objects they cover.
+
+		SUBJECT_TYPE is one of "serviceaccount" (or "sa"), "user" or "group".
+		NAME is the name of the subject.
+
+		The rules are resolved from the ClusterRoleBindings, RoleBindings,
+		ClusterRoles & Roles listed from the cluster, without sending any
+		SubjectAccessReview requests. Only objects in the selected namespaces &
+		cluster-scoped objects are listed.`)
+)
+
+// CmdOptions contains all the options for running the access command.
+type CmdOptions struct {
+	// SubjectKind represents the RBAC subject kind of the requested subject.
+	SubjectKind string
+	// SubjectName represents the name of the requested subject.
+	SubjectName string
+	Flags       *Flags
+
+	Namespace   string
+	Client      client.Interface
+	ClientFlags *client.Flags
+
+	Printer    lineageprinters.Interface
+	PrintFlags *lineageprinters.Flags
+
+	genericclioptions.IOStreams
+}
+
+// NewCmd returns an initialized Command for the access command.
+func NewCmd(streams genericclioptions.IOStreams, name, parentCmdPath string) *cobra.Command {
+	o := &CmdOptions{
+		Flags:       NewFlags(),
+		ClientFlags: client.NewFlags(),
+		PrintFlags:  lineageprinters.NewFlags(),
+		IOStreams:   streams,
+	}
+
+	f := cmdutil.NewFactory(o.ClientFlags)
+	completion.SetFactoryForCompletion(f)
+
+	if len(name) > 0 {
+		cmdName = name
+	}
+	cmdPath = cmdName
+	if len(parentCmdPath) > 0 {
+		cmdPath = parentCmdPath + " " + cmdName
+	}
+	cmd := &cobra.Command{
+		Use:                   strings.ReplaceAll(cmdUse, "%CMD%", cmdName),
+		Example:               strings.ReplaceAll(cmdExample, "%CMD_PATH%", cmdPath),
+		Short:                 cmdShort,
+		Long:                  cmdLong,
+		Args:                  cobra.MaximumNArgs(2),
+		DisableFlagsInUseLine: true,
+		DisableSuggestions:    true,
+		SilenceUsage:          true,
+		Run: func(c *cobra.Command, args []string) {
+			klog.V(4).Infof("Version: %s", c.Root().Version)
+			cmdutil.CheckErr(o.Complete(c, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			var comps []string
+			switch {
+			case len(args) == 0:
+				comps = []string{"group", "serviceaccount", "user"}
+			case len(args) == 1 && parseSubjectKind(args[0]) == rbacv1.ServiceAccountKind:
+				comps = completion.CompGetResource(f, cmd, "serviceaccount", toComplete)
+			}
+			return comps, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	// Setup flags
+	o.Flags.AddFlags(cmd.Flags())
+	o.ClientFlags.AddFlags(cmd.Flags())
+	o.PrintFlags.AddFlags(cmd.Flags())
+	log.AddFlags(cmd.Flags())
+
+	// Setup flag completion function
+	o.Flags.RegisterFlagCompletionFunc(cmd, f)
+	o.ClientFlags.RegisterFlagCompletionFunc(cmd, f)
+
+	return cmd
+}
+
+// Complete completes all the required options for the access command.
+func (o *CmdOptions) Complete(cmd *cobra.Command, args []string) error {
+	var err error
+
+	switch len(args) {
+	case 1:
+		subjectTokens := strings.SplitN(args[0], "/", 2)
+		if len(subjectTokens) != 2 {
+			return fmt.Errorf("arguments in <subject_type>/<name> form must have a single subject type and name\nSee '%s -h' for help and examples", cmdPath)
+		}
+		o.SubjectKind = parseSubjectKind(subjectTokens[0])
+		o.SubjectName = subjectTokens[1]
+	case 2:
+		o.SubjectKind = parseSubjectKind(args[0])
+		o.SubjectName = args[1]
+	}
+
+	// Setup client
+	o.Namespace, _, err = o.ClientFlags.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.Client, err = o.ClientFlags.ToClient()
+	if err != nil {
+		return err
+	}
+
+	// Setup printer
+	o.Printer, err = o.PrintFlags.ToPrinter(o.Client, o.ErrOut)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates all the required options for the access command.
+func (o *CmdOptions) Validate() error {
+	if len(o.SubjectKind) == 0 || len(o.SubjectName) == 0 {
+		return fmt.Errorf("subject must be specified as <subject_type> <name> or <subject_type>/<name>, with a subject type of either \"serviceaccount\", \"user\" or \"group\"\nSee '%s -h' for help and examples", cmdPath)
+	}
+	if o.SubjectKind != rbacv1.ServiceAccountKind && o.PrintFlags.HumanReadableFlags.IsSplitOutputFormat(*o.PrintFlags.OutputFormat) {
+		return fmt.Errorf("output format \"%s\" is not supported for %s subjects", *o.PrintFlags.OutputFormat, strings.ToLower(o.SubjectKind))
+	}
+
+	klog.V(4).Infof("Namespace: %s", o.Namespace)
+	klog.V(4).Infof("SubjectKind: %v", o.SubjectKind)
+	klog.V(4).Infof("SubjectName: %v", o.SubjectName)
+	klog.V(4).Infof("Flags.AllNamespaces: %t", *o.Flags.AllNamespaces)
+	klog.V(4).Infof("Flags.Depth: %v", *o.Flags.Depth)
+	klog.V(4).Infof("Flags.ExcludeTypes: %v", *o.Flags.ExcludeTypes)
+	klog.V(4).Infof("Flags.IncludeTypes: %v", *o.Flags.IncludeTypes)
+	klog.V(4).Infof("Flags.Scopes: %v", *o.Flags.Scopes)
+	klog.V(4).Infof("ClientFlags.Context: %s", *o.ClientFlags.Context)
+	klog.V(4).Infof("ClientFlags.Namespace: %s", *o.ClientFlags.Namespace)
+	klog.V(4).Infof("PrintFlags.OutputFormat: %s", *o.PrintFlags.OutputFormat)
+	klog.V(4).Infof("PrintFlags.NoHeaders: %t", *o.PrintFlags.HumanReadableFlags.NoHeaders)
+	klog.V(4).Infof("PrintFlags.ShowGroup: %t", *o.PrintFlags.HumanReadableFlags.ShowGroup)
+	klog.V(4).Infof("PrintFlags.ShowLabels: %t", *o.PrintFlags.HumanReadableFlags.ShowLabels)
+	klog.V(4).Infof("PrintFlags.ShowNamespace: %t", *o.PrintFlags.HumanReadableFlags.ShowNamespace)
+
+	return nil
+}
+
+// Run implements all the necessary functionality for the access command.
+func (o *CmdOptions) Run() error {
+	ctx := context.Background()
+
+	// First check if Kubernetes cluster is reachable
+	if err := o.Client.IsReachable(); err != nil {
+		return err
+	}
+
+	// Fetch the ServiceAccount to ensure it exists before proceeding
+	var root *unstructuredv1.Unstructured
+	if o.SubjectKind == rbacv1.ServiceAccountKind {
+		var err error
+		root, err = o.Client.Get(ctx, o.SubjectName, client.GetOptions{
+			APIResource: client.APIResource{Version: "v1", Kind: "ServiceAccount", Name: "serviceaccounts", Namespaced: true},
+			Namespace:   o.Namespace,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Fetch resources in the cluster
+	objs, err := o.fetchObjects(ctx, root)
+	if err != nil {
+		return err
+	}
+
+	// Find the bindings of the subject, either via the relationships of the
+	// ServiceAccount or the subjects of the bindings for Users & Groups
+	var rootUID types.UID
+	var nodeMap graph.NodeMap
+	mapper := o.Client.GetMapper()
+	if root != nil {
+		rootUID = root.GetUID()
+		nodeMap, err = graph.ResolveDependencies(mapper, objs, []types.UID{rootUID}, graph.ResolveOptions{})
+		if err != nil {
+			return err
+		}
+	} else {
+		bindings := graph.GetSubjectBindings(objs, o.SubjectKind, o.SubjectName)
+		var uids []types.UID
+		for uid := range bindings {
+			uids = append(uids, uid)
+		}
+		nodeMap, err = graph.ResolveDependencies(mapper, objs, uids, graph.ResolveOptions{})
+		if err != nil {
+			return err
+		}
+
+		// Add the subject to the root of the relationship tree
+		rootNode := newSubjectNode(o.SubjectKind, o.SubjectName)
+		for uid, r := range bindings {
+			rootNode.AddDependency(uid, r)
+		}
+		rootUID = rootNode.GetUID()
+		nodeMap[rootUID] = rootNode
+	}
+
+	// Keep only the bindings, roles & objects covered by the access of the
+	// subject
+	nodeMap, rules, err := graph.ResolveAccess(nodeMap, rootUID)
+	if err != nil {
+		return err
+	}
+
+	// Print output, including the effective rules for table output formats
+	outputFormat := *o.PrintFlags.OutputFormat
+	if len(outputFormat) == 0 || o.PrintFlags.IsTableOutputFormat(outputFormat) {
+		if err := lineageprinters.PrintAccessRules(o.Out, rules, *o.PrintFlags.HumanReadableFlags.NoHeaders); err != nil {
+			return err
+		}
+		fmt.Fprintf(o.Out, "\n")
+	}
+	return o.Printer.Print(o.Out, nodeMap, []types.UID{rootUID}, *o.Flags.Depth, true, o.Client.SkippedResources())
+}
+
+// fetchObjects fetches the objects to find the access of the subject with,
+// always including RBAC objects regardless of the included resource types.
+func (o *CmdOptions) fetchObjects(ctx context.Context, root *unstructuredv1.Unstructured) ([]unstructuredv1.Unstructured, error) {
+	// Determine resources to list
+	excludeAPIs := []client.APIResource{}
+	if o.Flags.ExcludeTypes != nil {
+		for _, kind := range *o.Flags.ExcludeTypes {
+			api, err := o.Client.ResolveAPIResource(kind)
+			if err != nil {
+				return nil, err
+			}
+			excludeAPIs = append(excludeAPIs, *api)
+		}
+	}
+	includeAPIs := []client.APIResource{}
+	if o.Flags.IncludeTypes != nil && len(*o.Flags.IncludeTypes) > 0 {
+		for _, kind := range append(*o.Flags.IncludeTypes, rbacResources...) {
+			api, err := o.Client.ResolveAPIResource(kind)
+			if err != nil {
+				return nil, err
+			}
+			includeAPIs = append(includeAPIs, *api)
+		}
+	}
+
+	// Determine the namespaces to list objects
+	namespaces := []string{o.Namespace}
+	if o.Flags.AllNamespaces != nil && *o.Flags.AllNamespaces {
+		namespaces = append(namespaces, "")
+	}
+	if o.Flags.Scopes != nil {
+		namespaces = append(namespaces, *o.Flags.Scopes...)
+	}
+
+	objs, err := o.Client.List(ctx, client.ListOptions{
+		APIResourcesToExclude: excludeAPIs,
+		APIResourcesToInclude: includeAPIs,
+		Namespaces:            namespaces,
+		FullObjectFn:          o.requiresFullObject,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Include root object into objects to handle cases where user has access
+	// to get the root object but unable to list its resource type
+	if root != nil {
+		objs.Items = append(objs.Items, *root)
+	}
+	return objs.Items, nil
+}
+
+// rbacResources contains the resource types required to find the access of a
+// subject.
+var rbacResources = []string{
+	"clusterrolebindings.rbac.authorization.k8s.io",
+	"clusterroles.rbac.authorization.k8s.io",
+	"rolebindings.rbac.authorization.k8s.io",
+	"roles.rbac.authorization.k8s.io",
+}
+
+// parseSubjectKind returns the RBAC subject kind of the provided subject type,
+// or an empty string if the subject type isn't supported.
+func parseSubjectKind(s string) string {
+	switch strings.ToLower(s) {
+	case "group", "groups":
+		return rbacv1.GroupKind
+	case "sa", "serviceaccount", "serviceaccounts":
+		return rbacv1.ServiceAccountKind
+	case "user", "users":
+		return rbacv1.UserKind
+	}
+	return ""
+}
+
+// requiresFullObject returns true if full objects of the provided API resource
+// are needed to either resolve their relationships or print their status in
+// the requested output format.
+func (o *CmdOptions) requiresFullObject(api client.APIResource) bool {
+	gk := api.GroupKind()
+	return graph.RequiresFullObject(gk) || o.PrintFlags.StatusRequiresFullObject(gk)
+}
+
+// newSubjectNode converts a User or Group subject into a Node in the
+// relationship tree.
+func newSubjectNode(kind, name string) *graph.Node {
+	root := new(unstructuredv1.Unstructured)
+	root.SetAPIVersion(rbacv1.SchemeGroupVersion.String())
+	root.SetKind(kind)
+	root.SetUID(types.UID(""))
+	root.SetName(name)
+
+	return &graph.Node{
+		Unstructured: root,
+		UID:          root.GetUID(),
+		Group:        rbacv1.GroupName,
+		Kind:         kind,
+		Name:         root.GetName(),
+		Dependencies: map[types.UID]graph.RelationshipSet{},
+		Dependents:   map[types.UID]graph.RelationshipSet{},
+	}
+}
@@ -155,7 +155,7 @@ func (o *CmdOptions) Complete(cmd *cobra.Command, args []string) error {
 	}
 
 	// Setup printer
-	o.Printer, err = o.PrintFlags.ToPrinter(o.Client)
+	o.Printer, err = o.PrintFlags.ToPrinter(o.Client, o.ErrOut)
 	if err != nil {
 		return err
 	}
@@ -296,6 +296,7 @@ func (o *CmdOptions) Run() error {
 		APIResourcesToExclude: excludeAPIs,
 		APIResourcesToInclude: includeAPIs,
 		Namespaces:            namespaces,
+		FullObjectFn:          o.requiresFullObject,
 	})
 	if err != nil {
 		return err
@@ -320,7 +321,7 @@ func (o *CmdOptions) Run() error {
 
 	// Find all dependents of the release & storage objects
 	mapper := o.Client.GetMapper()
-	nodeMap, err := graph.ResolveDependents(mapper, objs.Items, uids)
+	nodeMap, err := graph.ResolveDependents(mapper, objs.Items, uids, graph.ResolveOptions{})
 	if err != nil {
 		return err
 	}
@@ -340,7 +341,7 @@ func (o *CmdOptions) Run() error {
 	nodeMap[rootUID] = rootNode
 
 	// Print output
-	return o.Printer.Print(o.Out, nodeMap, rootUID, *o.Flags.Depth, false)
+	return o.Printer.Print(o.Out, nodeMap, []types.UID{rootUID}, *o.Flags.Depth, false, o.Client.SkippedResources())
 }
 
 // getManifestObjects fetches all objects found in the manifest of the provided
@@ -394,6 +395,14 @@ func (o *CmdOptions) getStorageObject(ctx context.Context, rls *release.Release)
 	})
 }
 
+// requiresFullObject returns true if full objects of the provided API resource
+// are needed to either resolve their relationships or print their status in
+// the requested output format.
+func (o *CmdOptions) requiresFullObject(api client.APIResource) bool {
+	gk := api.GroupKind()
+	return graph.RequiresFullObject(gk) || o.PrintFlags.StatusRequiresFullObject(gk)
+}
+
 // getReleaseReadyStatus returns the ready & status value of a Helm release
 // object.
 func getReleaseReadyStatus(rls *release.Release) (string, string) {
@@ -6,31 +6,45 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	kubectlcompletion "k8s.io/kubectl/pkg/util/completion"
 
 	"github.com/tohjustin/kube-lineage/internal/completion"
+	"github.com/tohjustin/kube-lineage/internal/graph"
 )
 
 const (
 	flagAllNamespaces          = "all-namespaces"
 	flagAllNamespacesShorthand = "A"
+	flagCertManagerNamespace   = "cert-manager-namespace"
+	flagContexts               = "contexts"
+	flagCrossCluster           = "cross-cluster"
 	flagDependencies           = "dependencies"
 	flagDependenciesShorthand  = "D"
 	flagDepth                  = "depth"
 	flagDepthShorthand         = "d"
 	flagExcludeTypes           = "exclude-types"
 	flagIncludeTypes           = "include-types"
+	flagKEDANamespace          = "keda-namespace"
+	flagLonghornNamespace      = "longhorn-namespace"
+	flagNamespaceContents      = "namespace-contents"
 	flagScopes                 = "scopes"
 	flagScopesShorthand        = "S"
 )
 
 // Flags composes common configuration flag structs used in the command.
 type Flags struct {
-	AllNamespaces *bool
-	Dependencies  *bool
-	Depth         *uint
-	ExcludeTypes  *[]string
-	IncludeTypes  *[]string
-	Scopes        *[]string
+	AllNamespaces        *bool
+	CertManagerNamespace *string
+	Contexts             *[]string
+	CrossCluster         *bool
+	Dependencies         *bool
+	Depth                *uint
+	ExcludeTypes         *[]string
+	IncludeTypes         *[]string
+	KEDANamespace        *string
+	LonghornNamespace    *string
+	NamespaceContents    *bool
+	Scopes               *[]string
 }
 
 // Copy returns a copy of Flags for mutation.
@@ -45,6 +59,16 @@ func (f *Flags) AddFlags(flags *pflag.FlagSet) {
 	if f.AllNamespaces != nil {
 		flags.BoolVarP(f.AllNamespaces, flagAllNamespaces, flagAllNamespacesShorthand, *f.AllNamespaces, "If present, list object relationships across all namespaces")
 	}
+	if f.CertManagerNamespace != nil {
+		flags.StringVar(f.CertManagerNamespace, flagCertManagerNamespace, *f.CertManagerNamespace, "Namespace which cert-manager is installed in, used to find the Secrets referenced by ClusterIssuers")
+	}
+	if f.Contexts != nil {
+		usage := fmt.Sprintf("Accepts a comma separated list of kubeconfig contexts to find relationships across their clusters. You can also use multiple flag options like --%s context1 --%s context2...", flagContexts, flagContexts)
+		flags.StringSliceVar(f.Contexts, flagContexts, *f.Contexts, usage)
+	}
+	if f.CrossCluster != nil {
+		flags.BoolVar(f.CrossCluster, flagCrossCluster, *f.CrossCluster, fmt.Sprintf("If present, find relationships between objects in different clusters of the contexts provided via --%s", flagContexts))
+	}
 	if f.Dependencies != nil {
 		flags.BoolVarP(f.Dependencies, flagDependencies, flagDependenciesShorthand, *f.Dependencies, "If present, list object dependencies instead of dependents")
 	}
@@ -59,6 +83,15 @@ func (f *Flags) AddFlags(flags *pflag.FlagSet) {
 		usage := fmt.Sprintf("Accepts a comma separated list of resource types to only include in relationship discovery. You can also use multiple flag options like --%s kind1 --%s kind1...", flagIncludeTypes, flagIncludeTypes)
 		flags.StringSliceVar(f.IncludeTypes, flagIncludeTypes, *f.IncludeTypes, usage)
 	}
+	if f.KEDANamespace != nil {
+		flags.StringVar(f.KEDANamespace, flagKEDANamespace, *f.KEDANamespace, "Namespace which KEDA is installed in, used to find the objects referenced by ClusterTriggerAuthentications")
+	}
+	if f.LonghornNamespace != nil {
+		flags.StringVar(f.LonghornNamespace, flagLonghornNamespace, *f.LonghornNamespace, "Namespace which Longhorn is installed in, used to find the Longhorn objects of PersistentVolumes")
+	}
+	if f.NamespaceContents != nil {
+		flags.BoolVar(f.NamespaceContents, flagNamespaceContents, *f.NamespaceContents, "If present, relate every namespaced object to the Namespace containing it")
+	}
 	if f.Scopes != nil {
 		usage := fmt.Sprintf("Accepts a comma separated list of additional namespaces to find relationships. You can also use multiple flag options like -%s namespace1 -%s namespace2...", flagScopesShorthand, flagScopesShorthand)
 		flags.StringSliceVarP(f.Scopes, flagScopes, flagScopesShorthand, *f.Scopes, usage)
@@ -73,24 +106,41 @@ func (*Flags) RegisterFlagCompletionFunc(cmd *cobra.Command, f cmdutil.Factory)
 		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return completion.GetScopeNamespaceList(f, cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
 		}))
+	cmdutil.CheckErr(cmd.RegisterFlagCompletionFunc(
+		flagContexts,
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return kubectlcompletion.ListContextsInConfig(toComplete), cobra.ShellCompDirectiveNoFileComp
+		}))
 }
 
 // NewFlags returns flags associated with command configuration, with default
 // values set.
 func NewFlags() *Flags {
 	allNamespaces := false
+	certManagerNamespace := graph.DefaultCertManagerNamespace
+	contexts := []string{}
+	crossCluster := false
 	dependencies := false
 	depth := uint(0)
 	excludeTypes := []string{}
 	includeTypes := []string{}
+	kedaNamespace := graph.DefaultKEDANamespace
+	longhornNamespace := graph.DefaultLonghornNamespace
+	namespaceContents := false
 	scopes := []string{}
 
 	return &Flags{
-		AllNamespaces: &allNamespaces,
-		Dependencies:  &dependencies,
-		Depth:         &depth,
-		ExcludeTypes:  &excludeTypes,
-		IncludeTypes:  &includeTypes,
-		Scopes:        &scopes,
+		AllNamespaces:        &allNamespaces,
+		CertManagerNamespace: &certManagerNamespace,
+		Contexts:             &contexts,
+		CrossCluster:         &crossCluster,
+		Dependencies:         &dependencies,
+		Depth:                &depth,
+		ExcludeTypes:         &excludeTypes,
+		IncludeTypes:         &includeTypes,
+		KEDANamespace:        &kedaNamespace,
+		LonghornNamespace:    &longhornNamespace,
+		NamespaceContents:    &namespaceContents,
+		Scopes:               &scopes,
 	}
 }
@@ -8,6 +8,10 @@ import (
 	"k8s.io/kubectl/pkg/util/completion"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/klog/v2"
@@ -41,7 +45,13 @@ var (
 		%CMD_PATH% pod.v1. bar-5cc79d4bf5-xgvkc --dependencies
 
 		# List all dependencies of the serviceaccount named "default" in the current namespace, grouped by resource type
-		%CMD_PATH% sa/default --dependencies --output=split`)
+		%CMD_PATH% sa/default --dependencies --output=split
+
+		# List all dependents of the deployment named "bar" in the "mgmt" & "workload" contexts
+		%CMD_PATH% deployments bar --contexts=mgmt,workload
+
+		# List all dependents of the Cluster API cluster named "bar", including objects in its workload cluster
+		%CMD_PATH% clusters.cluster.x-k8s.io/bar --contexts=mgmt,bar-admin@bar --cross-cluster`)
 	cmdShort = "Display all dependencies or dependents of a Kubernetes object"
 	cmdLong  = templates.LongDesc(`
 		Display all dependencies or dependents of a Kubernetes object.
@@ -62,12 +72,25 @@ type CmdOptions struct {
 	Client      client.Interface
 	ClientFlags *client.Flags
 
+	// clusters contains the clusters of the contexts provided via the
+	// "--contexts" flag.
+	clusters []cluster
+
 	Printer    lineageprinters.Interface
 	PrintFlags *lineageprinters.Flags
 
 	genericclioptions.IOStreams
 }
 
+// cluster contains the client & configuration for the cluster of a kubeconfig
+// context.
+type cluster struct {
+	name      string
+	namespace string
+	server    string
+	client    client.Interface
+}
+
 // NewCmd returns an initialized Command for the lineage command.
 func NewCmd(streams genericclioptions.IOStreams, name, parentCmdPath string) *cobra.Command {
 	o := &CmdOptions{
@@ -144,18 +167,38 @@ func (o *CmdOptions) Complete(cmd *cobra.Command, args []string) error {
 		o.RequestName = args[1]
 	}
 
-	// Setup client
-	o.Namespace, _, err = o.ClientFlags.ToRawKubeConfigLoader().Namespace()
-	if err != nil {
-		return err
-	}
-	o.Client, err = o.ClientFlags.ToClient()
-	if err != nil {
-		return err
+	// Setup a client for each of the provided contexts, otherwise setup a
+	// client for the current context
+	if o.Flags.Contexts != nil && len(*o.Flags.Contexts) > 0 {
+		for _, name := range *o.Flags.Contexts {
+			flags := o.ClientFlags.ForContext(name)
+			ns, _, err := flags.ToRawKubeConfigLoader().Namespace()
+			if err != nil {
+				return err
+			}
+			config, err := flags.ToRESTConfig()
+			if err != nil {
+				return err
+			}
+			c, err := flags.ToClient()
+			if err != nil {
+				return err
+			}
+			o.clusters = append(o.clusters, cluster{name: name, namespace: ns, server: config.Host, client: c})
+		}
+	} else {
+		o.Namespace, _, err = o.ClientFlags.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return err
+		}
+		o.Client, err = o.ClientFlags.ToClient()
+		if err != nil {
+			return err
+		}
 	}
 
 	// Setup printer
-	o.Printer, err = o.PrintFlags.ToPrinter(o.Client)
+	o.Printer, err = o.PrintFlags.ToPrinter(o.Client, o.ErrOut)
 	if err != nil {
 		return err
 	}
@@ -168,15 +211,27 @@ func (o *CmdOptions) Validate() error {
 	if len(o.RequestType) == 0 || len(o.RequestName) == 0 {
 		return fmt.Errorf("resource must be specified as <resource> <name> or <resource>/<name>\nSee '%s -h' for help and examples", cmdPath)
 	}
+	if len(o.clusters) > 0 && len(*o.ClientFlags.Context) > 0 {
+		return fmt.Errorf("--%s and --context flags cannot be used together", flagContexts)
+	}
+	if len(o.clusters) == 0 && *o.Flags.CrossCluster {
+		return fmt.Errorf("--%s flag requires the --%s flag to be set", flagCrossCluster, flagContexts)
+	}
 
 	klog.V(4).Infof("Namespace: %s", o.Namespace)
 	klog.V(4).Infof("RequestType: %v", o.RequestType)
 	klog.V(4).Infof("RequestName: %v", o.RequestName)
 	klog.V(4).Infof("Flags.AllNamespaces: %t", *o.Flags.AllNamespaces)
+	klog.V(4).Infof("Flags.CertManagerNamespace: %s", *o.Flags.CertManagerNamespace)
+	klog.V(4).Infof("Flags.Contexts: %v", *o.Flags.Contexts)
+	klog.V(4).Infof("Flags.CrossCluster: %t", *o.Flags.CrossCluster)
 	klog.V(4).Infof("Flags.Dependencies: %t", *o.Flags.Dependencies)
 	klog.V(4).Infof("Flags.Depth: %v", *o.Flags.Depth)
 	klog.V(4).Infof("Flags.ExcludeTypes: %v", *o.Flags.ExcludeTypes)
 	klog.V(4).Infof("Flags.IncludeTypes: %v", *o.Flags.IncludeTypes)
+	klog.V(4).Infof("Flags.KEDANamespace: %s", *o.Flags.KEDANamespace)
+	klog.V(4).Infof("Flags.LonghornNamespace: %s", *o.Flags.LonghornNamespace)
+	klog.V(4).Infof("Flags.NamespaceContents: %t", *o.Flags.NamespaceContents)
 	klog.V(4).Infof("Flags.Scopes: %v", *o.Flags.Scopes)
 	klog.V(4).Infof("ClientFlags.Context: %s", *o.ClientFlags.Context)
 	klog.V(4).Infof("ClientFlags.Namespace: %s", *o.ClientFlags.Namespace)
@@ -190,41 +245,132 @@ func (o *CmdOptions) Validate() error {
 }
 
 // Run implements all the necessary functionality for the lineage command.
-//
-//nolint:funlen
 func (o *CmdOptions) Run() error {
 	ctx := context.Background()
+	if len(o.clusters) > 0 {
+		return o.runClusters(ctx)
+	}
 
-	// First check if Kubernetes cluster is reachable
-	if err := o.Client.IsReachable(); err != nil {
+	// Fetch the provided object to ensure it exists before proceeding
+	root, err := o.getRoot(ctx, o.Client, o.Namespace)
+	if err != nil {
 		return err
 	}
 
-	// Fetch the provided object to ensure it exists before proceeding
-	api, err := o.Client.ResolveAPIResource(o.RequestType)
+	// Fetch resources in the cluster
+	objs, err := o.fetchObjects(ctx, o.Client, o.Namespace, root)
 	if err != nil {
 		return err
 	}
+
+	// Find either all dependencies or dependents of the root object
+	depsIsDependencies, resolveDeps := false, graph.ResolveDependents
+	if *o.Flags.Dependencies {
+		depsIsDependencies, resolveDeps = true, graph.ResolveDependencies
+	}
+	mapper := o.Client.GetMapper()
+	rootUIDs := []types.UID{root.GetUID()}
+	nodeMap, err := resolveDeps(mapper, objs, rootUIDs, o.resolveOptions())
+	if err != nil {
+		return err
+	}
+
+	// Print output
+	return o.Printer.Print(o.Out, nodeMap, rootUIDs, *o.Flags.Depth, depsIsDependencies, o.Client.SkippedResources())
+}
+
+// runClusters implements the functionality for the lineage command when
+// finding relationships across the clusters of multiple contexts.
+func (o *CmdOptions) runClusters(ctx context.Context) error {
+	var clusterObjs []graph.ClusterObjects
+	var rootUIDs []types.UID
+	var skipped []client.SkippedResource
+	for _, c := range o.clusters {
+		// Fetch the provided object from the cluster, objects from clusters
+		// without the provided object are only needed for resolving cross-cluster
+		// relationships
+		root, err := o.getRoot(ctx, c.client, c.namespace)
+		switch {
+		case apierrors.IsNotFound(err) || meta.IsNoMatchError(err):
+			klog.V(4).Infof("Object not found in the cluster of context \"%s\": %s", c.name, err)
+			if !*o.Flags.CrossCluster {
+				continue
+			}
+			root = nil
+		case err != nil:
+			return fmt.Errorf("context \"%s\": %w", c.name, err)
+		}
+
+		// Fetch resources in the cluster
+		objs, err := o.fetchObjects(ctx, c.client, c.namespace, root)
+		if err != nil {
+			return fmt.Errorf("context \"%s\": %w", c.name, err)
+		}
+		clusterObjs = append(clusterObjs, graph.ClusterObjects{
+			Cluster: c.name,
+			Server:  c.server,
+			Mapper:  c.client.GetMapper(),
+			Objects: objs,
+		})
+		if root != nil {
+			rootUIDs = append(rootUIDs, root.GetUID())
+		}
+		skipped = append(skipped, c.client.SkippedResources()...)
+	}
+	if len(rootUIDs) == 0 {
+		return fmt.Errorf("%s \"%s\" not found in the clusters of any of the provided contexts", o.RequestType, o.RequestName)
+	}
+
+	// Find either all dependencies or dependents of the root objects
+	depsIsDependencies, resolveDeps := false, graph.ResolveClusterDependents
+	if *o.Flags.Dependencies {
+		depsIsDependencies, resolveDeps = true, graph.ResolveClusterDependencies
+	}
+	nodeMap, err := resolveDeps(clusterObjs, rootUIDs, *o.Flags.CrossCluster, o.resolveOptions())
+	if err != nil {
+		return err
+	}
+
+	// Print output
+	return o.Printer.Print(o.Out, nodeMap, rootUIDs, *o.Flags.Depth, depsIsDependencies, skipped)
+}
+
+// getRoot fetches the requested object using the provided client.
+func (o *CmdOptions) getRoot(ctx context.Context, c client.Interface, namespace string) (*unstructuredv1.Unstructured, error) {
+	// First check if Kubernetes cluster is reachable
+	if err := c.IsReachable(); err != nil {
+		return nil, err
+	}
+
+	api, err := c.ResolveAPIResource(o.RequestType)
+	if err != nil {
+		return nil, err
+	}
 	obj := client.ObjectMeta{
 		APIResource: *api,
 		Name:        o.RequestName,
-		Namespace:   o.Namespace,
+		Namespace:   namespace,
 	}
-	root, err := o.Client.Get(ctx, obj.Name, client.GetOptions{
+	return c.Get(ctx, obj.Name, client.GetOptions{
 		APIResource: obj.APIResource,
-		Namespace:   o.Namespace,
+		Namespace:   namespace,
 	})
-	if err != nil {
-		return err
-	}
+}
 
+// fetchObjects fetches the objects to find relationships with using the
+// provided client. If a root object is provided & the depth of the
+// relationship tree is limited, only objects reachable from the root object
+// are fetched.
+//
+//nolint:funlen
+func (o *CmdOptions) fetchObjects(ctx context.Context, c client.Interface, namespace string, root *unstructuredv1.Unstructured) ([]unstructuredv1.Unstructured, error) {
 	// Determine resources to list
 	excludeAPIs := []client.APIResource{}
 	if o.Flags.ExcludeTypes != nil {
 		for _, kind := range *o.Flags.ExcludeTypes {
-			api, err := o.Client.ResolveAPIResource(kind)
+			api, err := c.ResolveAPIResource(kind)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			excludeAPIs = append(excludeAPIs, *api)
 		}
@@ -232,16 +378,16 @@ func (o *CmdOptions) Run() error {
 	includeAPIs := []client.APIResource{}
 	if o.Flags.IncludeTypes != nil {
 		for _, kind := range *o.Flags.IncludeTypes {
-			api, err := o.Client.ResolveAPIResource(kind)
+			api, err := c.ResolveAPIResource(kind)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			includeAPIs = append(includeAPIs, *api)
 		}
 	}
 
 	// Determine the namespaces to list objects
-	namespaces := []string{o.Namespace}
+	namespaces := []string{namespace}
 	if o.Flags.AllNamespaces != nil && *o.Flags.AllNamespaces {
 		namespaces = append(namespaces, "")
 	}
@@ -249,32 +395,69 @@ func (o *CmdOptions) Run() error {
 		namespaces = append(namespaces, *o.Flags.Scopes...)
 	}
 
-	// Fetch resources in the cluster
-	objs, err := o.Client.List(ctx, client.ListOptions{
+	// Include objects in the namespace when the root object is a Namespace
+	if root != nil && isNamespace(root) {
+		namespaces = append(namespaces, root.GetName())
+	}
+
+	// Fetch resources in the cluster, only fetching objects reachable from the
+	// root object if the depth of the relationship tree is limited (relating
+	// namespaced objects to their Namespace requires all objects)
+	if root != nil && *o.Flags.Depth > 0 && !*o.Flags.NamespaceContents {
+		return graph.FetchReachable(ctx, c, root, graph.FetchOptions{
+			APIResourcesToExclude: excludeAPIs,
+			APIResourcesToInclude: includeAPIs,
+			Namespaces:            namespaces,
+			CertManagerNamespace:  *o.Flags.CertManagerNamespace,
+			Depth:                 *o.Flags.Depth,
+			DepsIsDependencies:    *o.Flags.Dependencies,
+			FullObjectFn:          o.requiresFullObject,
+			KEDANamespace:         *o.Flags.KEDANamespace,
+			LonghornNamespace:     *o.Flags.LonghornNamespace,
+		})
+	}
+	objs, err := c.List(ctx, client.ListOptions{
 		APIResourcesToExclude: excludeAPIs,
 		APIResourcesToInclude: includeAPIs,
 		Namespaces:            namespaces,
+		FullObjectFn:          o.requiresFullObject,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Include root object into objects to handle cases where user has access
 	// to get the root object but unable to list its resource type
-	objs.Items = append(objs.Items, *root)
-
-	// Find either all dependencies or dependents of the root object
-	depsIsDependencies, resolveDeps := false, graph.ResolveDependents
-	if o.Flags.Dependencies != nil && *o.Flags.Dependencies {
-		depsIsDependencies, resolveDeps = true, graph.ResolveDependencies
+	if root != nil {
+		objs.Items = append(objs.Items, *root)
 	}
-	mapper := o.Client.GetMapper()
-	rootUID := root.GetUID()
-	nodeMap, err := resolveDeps(mapper, objs.Items, []types.UID{rootUID})
-	if err != nil {
-		return err
+	return objs.Items, nil
+}
+
+// resolveOptions returns the options for resolving relationships between
+// objects.
+func (o *CmdOptions) resolveOptions() graph.ResolveOptions {
+	return graph.ResolveOptions{
+		CertManagerNamespace: *o.Flags.CertManagerNamespace,
+		KEDANamespace:        *o.Flags.KEDANamespace,
+		LonghornNamespace:    *o.Flags.LonghornNamespace,
+		NamespaceContents:    *o.Flags.NamespaceContents,
 	}
+}
 
-	// Print output
-	return o.Printer.Print(o.Out, nodeMap, rootUID, *o.Flags.Depth, depsIsDependencies)
+// isNamespace returns true if the provided object is a Namespace.
+func isNamespace(obj *unstructuredv1.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	return gvk.Group == corev1.GroupName && gvk.Kind == "Namespace"
+}
+
+// requiresFullObject returns true if full objects of the provided API resource
+// are needed to either resolve their relationships (including cross-cluster
+// ones) or print their status in the requested output format.
+func (o *CmdOptions) requiresFullObject(api client.APIResource) bool {
+	gk := api.GroupKind()
+	if *o.Flags.CrossCluster && graph.RequiresFullObjectForCrossCluster(gk) {
+		return true
+	}
+	return graph.RequiresFullObject(gk) || o.PrintFlags.StatusRequiresFullObject(gk)
 }
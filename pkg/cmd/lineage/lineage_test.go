@@ -0,0 +1,208 @@
+package lineage
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/tohjustin/kube-lineage/internal/client"
+	"github.com/tohjustin/kube-lineage/internal/graph"
+	lineageprinters "github.com/tohjustin/kube-lineage/internal/printers"
+)
+
+var testDeploymentAPIResource = client.APIResource{Group: "apps", Version: "v1", Kind: "Deployment", Name: "deployments", Namespaced: true}
+
+// fakeClient is a client.Interface serving the provided objects of a single
+// cluster. Methods which aren't needed by the command aren't implemented.
+type fakeClient struct {
+	client.Interface
+	objects []unstructuredv1.Unstructured
+	skipped []client.SkippedResource
+}
+
+func (c *fakeClient) GetMapper() meta.RESTMapper {
+	m := meta.NewDefaultRESTMapper(nil)
+	m.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	m.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}, meta.RESTScopeNamespace)
+	return m
+}
+
+func (c *fakeClient) IsReachable() error {
+	return nil
+}
+
+func (c *fakeClient) ResolveAPIResource(_ string) (*client.APIResource, error) {
+	api := testDeploymentAPIResource
+	return &api, nil
+}
+
+func (c *fakeClient) Get(_ context.Context, name string, opts client.GetOptions) (*unstructuredv1.Unstructured, error) {
+	for ix := range c.objects {
+		obj := c.objects[ix]
+		if obj.GetKind() == opts.APIResource.Kind && obj.GetNamespace() == opts.Namespace && obj.GetName() == name {
+			return obj.DeepCopy(), nil
+		}
+	}
+	return nil, apierrors.NewNotFound(opts.APIResource.GroupVersionResource().GroupResource(), name)
+}
+
+func (c *fakeClient) List(_ context.Context, _ client.ListOptions) (*unstructuredv1.UnstructuredList, error) {
+	list := &unstructuredv1.UnstructuredList{}
+	for ix := range c.objects {
+		list.Items = append(list.Items, *c.objects[ix].DeepCopy())
+	}
+	return list, nil
+}
+
+func (c *fakeClient) SkippedResources() []client.SkippedResource {
+	return c.skipped
+}
+
+// recordingPrinter records the relationship tree it's requested to print.
+type recordingPrinter struct {
+	nodeMap  graph.NodeMap
+	rootUIDs []types.UID
+	skipped  []client.SkippedResource
+}
+
+func (p *recordingPrinter) Print(_ io.Writer, nodeMap graph.NodeMap, rootUIDs []types.UID, _ uint, _ bool, skipped []client.SkippedResource) error {
+	p.nodeMap, p.rootUIDs, p.skipped = nodeMap, rootUIDs, skipped
+	return nil
+}
+
+var _ lineageprinters.Interface = &recordingPrinter{}
+
+func newTestObject(uid types.UID, kind, name string, owner types.UID) unstructuredv1.Unstructured {
+	obj := unstructuredv1.Unstructured{}
+	obj.SetAPIVersion("apps/v1")
+	obj.SetKind(kind)
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetUID(uid)
+	if len(owner) > 0 {
+		obj.Object["metadata"].(map[string]interface{})["ownerReferences"] = []interface{}{
+			map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": name, "uid": string(owner)},
+		}
+	}
+	return obj
+}
+
+//nolint:funlen
+func TestRunClusters(t *testing.T) {
+	t.Parallel()
+
+	forbidden := func(cluster string) client.SkippedResource {
+		return client.SkippedResource{
+			Cluster:     cluster,
+			APIResource: client.APIResource{Version: "v1", Kind: "Secret", Name: "secrets", Namespaced: true},
+			Namespace:   "default",
+			Reason:      "Forbidden",
+		}
+	}
+	tests := []struct {
+		name            string
+		clusters        []cluster
+		expectedRoots   []types.UID
+		expectedObjects map[types.UID]string
+		expectedSkipped []client.SkippedResource
+		expectErr       bool
+	}{
+		{
+			name: "RootInEveryCluster",
+			clusters: []cluster{
+				{name: "a", namespace: "default", client: &fakeClient{
+					objects: []unstructuredv1.Unstructured{
+						newTestObject("deploy-a", "Deployment", "web", ""),
+						newTestObject("rs-a", "ReplicaSet", "web", "deploy-a"),
+					},
+					skipped: []client.SkippedResource{forbidden("a")},
+				}},
+				{name: "b", namespace: "default", client: &fakeClient{
+					objects: []unstructuredv1.Unstructured{
+						newTestObject("deploy-b", "Deployment", "web", ""),
+					},
+					skipped: []client.SkippedResource{forbidden("b")},
+				}},
+			},
+			expectedRoots:   []types.UID{"deploy-a", "deploy-b"},
+			expectedObjects: map[types.UID]string{"deploy-a": "a", "rs-a": "a", "deploy-b": "b"},
+			expectedSkipped: []client.SkippedResource{forbidden("a"), forbidden("b")},
+		},
+		{
+			name: "RootInSomeClusters",
+			clusters: []cluster{
+				{name: "a", namespace: "default", client: &fakeClient{
+					objects: []unstructuredv1.Unstructured{
+						newTestObject("rs-a", "ReplicaSet", "web", ""),
+					},
+					skipped: []client.SkippedResource{forbidden("a")},
+				}},
+				{name: "b", namespace: "default", client: &fakeClient{
+					objects: []unstructuredv1.Unstructured{
+						newTestObject("deploy-b", "Deployment", "web", ""),
+					},
+				}},
+			},
+			expectedRoots:   []types.UID{"deploy-b"},
+			expectedObjects: map[types.UID]string{"deploy-b": "b"},
+			expectedSkipped: nil,
+		},
+		{
+			name: "RootInNoCluster",
+			clusters: []cluster{
+				{name: "a", namespace: "default", client: &fakeClient{}},
+				{name: "b", namespace: "default", client: &fakeClient{}},
+			},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			printer := &recordingPrinter{}
+			o := &CmdOptions{
+				RequestType: "deployments",
+				RequestName: "web",
+				Flags:       NewFlags(),
+				PrintFlags:  lineageprinters.NewFlags(),
+				Printer:     printer,
+				IOStreams:   genericclioptions.NewTestIOStreamsDiscard(),
+				clusters:    tt.clusters,
+			}
+			err := o.runClusters(context.Background())
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error got roots %v", printer.rootUIDs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to run: %v", err)
+			}
+			if !reflect.DeepEqual(printer.rootUIDs, tt.expectedRoots) {
+				t.Fatalf("expected roots %v got %v", tt.expectedRoots, printer.rootUIDs)
+			}
+			got := map[types.UID]string{}
+			for uid, node := range printer.nodeMap {
+				got[uid] = node.Cluster
+			}
+			if !reflect.DeepEqual(got, tt.expectedObjects) {
+				t.Fatalf("expected objects %v got %v", tt.expectedObjects, got)
+			}
+			sort.Slice(printer.skipped, func(i, j int) bool { return printer.skipped[i].Cluster < printer.skipped[j].Cluster })
+			if !reflect.DeepEqual(printer.skipped, tt.expectedSkipped) {
+				t.Fatalf("expected skipped resources %v got %v", tt.expectedSkipped, printer.skipped)
+			}
+		})
+	}
+}